@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"gmf_transmission_response/config"
+)
+
+// main consume los mensajes TransmisionResponse de la cola de SQS configurada en QUEUE_URL hasta
+// recibir SIGTERM o SIGINT.
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run consume la cola hasta recibir la señal de terminación. Retorna el error en lugar de terminar el
+// proceso para que se cierre la conexión a la base de datos.
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error al cargar la configuración: %w", err)
+	}
+
+	consumer, dbManager, err := config.InitConsumer(cfg)
+	if err != nil {
+		return err
+	}
+	defer dbManager.CloseDB()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	return consumer.Run(ctx)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"gmf_transmission_response/config"
+	"gmf_transmission_response/internal/apigateway"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/metrics"
+)
+
+// main procesa los eventos de API Gateway con HandleTransmisionResponses. En Lambda los eventos se
+// reciben con lambda.Start; fuera de Lambda se leen de la entrada estándar y las respuestas se
+// escriben en la salida estándar.
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run procesa los eventos hasta que terminen o se reciba la señal de terminación. Retorna el error en lugar
+// de terminar el proceso para que se cierre la conexión a la base de datos.
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("error al cargar la configuración: %w", err)
+	}
+
+	// Sin el manager de jobs, las solicitudes con Prefer: respond-async se procesan de forma síncrona
+	archivoHandler, dbManager := config.InitLambda(cfg)
+	defer dbManager.CloseDB()
+
+	adapter := apigateway.NewAdapter(
+		metrics.InstrumentHandler("HandleTransmisionResponses", archivoHandler.HandleTransmisionResponses))
+
+	if os.Getenv(apigateway.EnvRuntimeAPI) != "" {
+		// lambda.Start no retorna, la conexión se cierra cuando Lambda detiene el entorno de ejecución
+		logs.Logger.LogInfo("Procesando eventos del Runtime API de Lambda 🚀", "LAMBDA_START")
+		lambda.StartWithOptions(adapter.Invocar, lambda.WithEnableSIGTERM(dbManager.CloseDB))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	logs.Logger.LogInfo("Procesando eventos de la entrada estándar", "LAMBDA_START")
+	if err := apigateway.EjecutarLocal(ctx, os.Stdin, os.Stdout, adapter); err != nil {
+		return fmt.Errorf("error al procesar los eventos: %w", err)
+	}
+	return nil
+}
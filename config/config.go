@@ -1,69 +1,410 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"gmf_transmission_response/connection"
+	"gmf_transmission_response/internal/aws"
+	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/jobs"
 	"gmf_transmission_response/internal/logs"
-	"log"
+	"gmf_transmission_response/internal/queue"
+	"gmf_transmission_response/internal/secrets"
+	"gmf_transmission_response/internal/service"
 )
 
-// Manager maneja la carga de configuración de la aplicación.
-type Manager struct{}
+// EnvConfigFile es la variable de entorno con la ruta del archivo YAML de configuración opcional.
+const EnvConfigFile = "CONFIG_FILE"
+
+// Valores permitidos de LOG_FORMAT y LOG_LEVEL.
+var (
+	formatosLog = []string{"JSON", "STRING"}
+	nivelesLog  = []string{"DEBUG", "INFO", "WARNING", "ERROR"}
+)
+
+// Config contiene toda la configuración de la aplicación.
+type Config struct {
+	AppEnv string
+
+	Server       connection.ServerConfig
+	DB           connection.DBConfig
+	DBReintentos connection.ReintentoConfig
+	Secrets      secrets.Config
+	Log          logs.Config
+	// Queue es la configuración de la cola que consume el entrypoint cmd/consumer.
+	Queue queue.Config
 
-// NewConfigManager crea una nueva instancia de Manager.
-func NewConfigManager() *Manager {
-	return &Manager{}
+	// Concurrencia es la cantidad de archivos de una solicitud que se procesan en paralelo.
+	Concurrencia int
+	// UmbralLote es el tamaño a partir del cual una solicitud se procesa como un lote; 0 lo deshabilita.
+	UmbralLote int
+	// JobsConcurrencia es la cantidad de jobs asíncronos que se procesan en paralelo.
+	JobsConcurrencia int
+	// JobsCola es la cantidad de jobs que pueden esperar un cupo; con la cola llena se responde 503.
+	JobsCola int
+	// JobsReserva es el tiempo por el que una instancia reserva un job; si se detiene sin terminarlo,
+	// otra instancia lo retoma cuando vence la reserva.
+	JobsReserva time.Duration
+	// JobsRetencion es el tiempo que se conservan los jobs terminados antes de eliminarlos cada
+	// JobsPurgeInterval.
+	JobsRetencion     time.Duration
+	JobsPurgeInterval time.Duration
+
+	IdempotencyRetention     time.Duration
+	IdempotencyPurgeInterval time.Duration
+	HealthCheckTimeout       time.Duration
 }
 
-// InitConfig carga la configuración desde el archivo .env y las variables de entorno.
-func (cm *Manager) InitConfig() {
-	// Cargar el archivo .env si existe
-	if err := godotenv.Load(); err != nil {
-		logs.Logger.LogWarn(
-			"Archivo .env no encontrado, usando solo variables de entorno",
-			"CONFIG_INIT",
-		)
+// Load carga la configuración desde las variables de entorno, el archivo YAML indicado en CONFIG_FILE
+// y el archivo .env, en ese orden de prioridad, y por último los valores por defecto. Retorna un error
+// que nombra cada campo faltante o inválido.
+func Load() (*Config, error) {
+	f := fuente{dotenv: cargarDotenv()}
+	if archivo := os.Getenv(EnvConfigFile); archivo != "" {
+		f.yaml = viper.New()
+		f.yaml.SetConfigFile(archivo)
+		f.yaml.SetConfigType("yaml")
+		if err := f.yaml.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error al leer el archivo de configuración %s: %w", archivo, err)
+		}
 	}
 
-	// Configurar Viper para usar el prefijo "APP" y cargar variables automáticamente del entorno
-	viper.SetEnvPrefix("APP")
-	viper.AutomaticEnv()
+	cfg, err := cargar(f)
+	if err != nil {
+		return nil, err
+	}
+
+	logs.Logger.LogInfo("Configuración cargada correctamente", "CONFIG_INIT")
+	return cfg, nil
+}
+
+// cargar arma la configuración con los valores de la fuente y la valida.
+func cargar(f fuente) (*Config, error) {
+	c := &cargador{fuente: f}
 
-	// Especificar el archivo .env y cargarlo con Viper
-	viper.SetConfigFile(".env")
-	viper.SetConfigType("env")
+	cfg := &Config{
+		AppEnv: c.texto("app_env", ""),
+		Server: connection.ServerConfig{
+			Host:            c.texto("host", ""),
+			Port:            c.puerto("port", "8080"),
+			ReadTimeout:     c.duracion("server.read_timeout", connection.DefaultReadTimeout),
+			WriteTimeout:    c.duracion("server.write_timeout", connection.DefaultWriteTimeout),
+			IdleTimeout:     c.duracion("server.idle_timeout", connection.DefaultIdleTimeout),
+			ShutdownTimeout: c.duracion("server.shutdown_timeout", connection.DefaultShutdownTimeout),
+		},
+		DB: connection.DBConfig{
+			Host:            c.requerido("db.host"),
+			Port:            c.puerto("db.port", ""),
+			Name:            c.requerido("db.name"),
+			SecretName:      c.texto("secrets.db", ""),
+			SecretsCacheTTL: c.duracion("secrets.cache_ttl", secrets.TTLPorDefecto),
+			SSLMode:         c.texto("db.sslmode", connection.SSLModeDisable),
+			SSLRootCert:     c.texto("db.sslrootcert", ""),
+			SSLCert:         c.texto("db.sslcert", ""),
+			SSLKey:          c.texto("db.sslkey", ""),
+			MaxOpenConns:    c.entero("db.max_open_conns", 0, 0),
+			MaxIdleConns:    c.entero("db.max_idle_conns", 0, 0),
+			ConnMaxLifetime: c.duracionOCero("db.conn_max_lifetime", 0),
+			ConnMaxIdleTime: c.duracionOCero("db.conn_max_idle_time", 0),
+		},
+		DBReintentos: connection.ReintentoConfig{
+			Timeout:        c.duracionOCero("db.startup_timeout", connection.DefaultStartupTimeout),
+			BackoffInicial: c.duracionOCero("db.retry_initial_backoff", connection.DefaultBackoffInicial),
+			BackoffMaximo:  c.duracionOCero("db.retry_max_backoff", connection.DefaultBackoffMaximo),
+		},
+		Secrets: secrets.Config{
+			Provider: c.opcion("secrets.provider", secrets.ProviderAWS,
+				[]string{secrets.ProviderEnv, secrets.ProviderFile, secrets.ProviderAWS, secrets.ProviderChain}),
+			Chain:    c.lista("secrets.chain"),
+			FilePath: c.texto("secrets.file_path", ""),
+		},
+		Log: logs.Config{
+			Format: c.opcion("log.format", "STRING", formatosLog),
+			Level:  c.opcion("log.level", "INFO", nivelesLog),
+		},
+		Queue: queue.Config{
+			URL:               c.url("queue.url"),
+			DeadLetterURL:     c.url("queue.dlq_url"),
+			MaxReceiveCount:   c.entero("queue.max_receive_count", queue.MaxReceiveCountPorDefecto, 1),
+			VisibilityTimeout: c.duracion("queue.visibility_timeout", queue.VisibilityTimeoutPorDefecto),
+			WaitTime:          c.duracionOCero("queue.wait_time", queue.WaitTimePorDefecto),
+		},
+		Concurrencia:         c.entero("processing.concurrency", service.ConcurrenciaPorDefecto, 1),
+		UmbralLote:           c.entero("batch.threshold", service.UmbralLotePorDefecto, 0),
+		JobsConcurrencia:     c.entero("jobs.concurrency", jobs.ConcurrenciaPorDefecto, 1),
+		JobsCola:             c.entero("jobs.queue_size", jobs.ColaPorDefecto, 0),
+		JobsReserva:          c.duracion("jobs.lease", jobs.ReservaPorDefecto),
+		JobsRetencion:        c.duracion("jobs.retention", jobs.RetencionPorDefecto),
+		JobsPurgeInterval:    c.duracion("jobs.purge_interval", jobs.IntervaloDepuracionPorDefecto),
+		IdempotencyRetention: c.duracion("idempotency.retention", idempotency.RetencionPorDefecto),
+		IdempotencyPurgeInterval: c.duracion("idempotency.purge_interval",
+			idempotency.IntervaloDepuracionPorDefecto),
+		HealthCheckTimeout: c.duracion("health_check.timeout", handler.DefaultHealthCheckTimeout),
+	}
+	cfg.Secrets.AWS = aws.Config{
+		Local:    cfg.AppEnv == "local",
+		Endpoint: c.url("aws.endpoint"),
+		Region:   c.texto("region_zone", ""),
+		Profile:  c.texto("aws.profile", ""),
+	}
 
-	// Leer las configuraciones del archivo .env
-	if err := viper.ReadInConfig(); err != nil {
-		logs.Logger.LogWarn(
-			"Error al leer el archivo de configuración",
-			"CONFIG_INIT", err.Error(),
-		)
+	c.validarSecretos(cfg)
+	if err := cfg.DB.Validate(); err != nil {
+		c.errs = append(c.errs, fmt.Errorf("configuración de la base de datos: %w", err))
 	}
 
-	// Lista de variables de entorno obligatorias
-	requiredEnvVars := []string{
-		"DB_HOST",
-		"DB_PORT",
-		"DB_USER",
-		"DB_PASSWORD",
-		"DB_NAME",
+	if len(c.errs) > 0 {
+		return nil, fmt.Errorf("configuración inválida:\n%w", errors.Join(c.errs...))
 	}
+	return cfg, nil
+}
 
-	// Validar que todas las variables de entorno requeridas estén presentes
-	for _, envVar := range requiredEnvVars {
-		value := viper.GetString(envVar)
-		if value == "" {
-			msg := "La variable de entorno " + envVar + " no está configurada"
-			logs.Logger.LogError(msg, nil, "CONFIG_INIT")
-			log.Fatalf("Error: %s", msg)
+// cargarDotenv lee el archivo .env si existe. Sus variables se agregan al entorno, sin reemplazar las ya
+// definidas, para los componentes que leen el entorno directamente, como el proveedor de secretos env o el
+// SDK de AWS. Retorna las variables agregadas, que para la configuración tienen menor prioridad que el YAML.
+func cargarDotenv() map[string]string {
+	dotenv, err := godotenv.Read()
+	if err != nil {
+		logs.Logger.LogWarn("Archivo .env no encontrado, usando solo variables de entorno", "CONFIG_INIT")
+		return nil
+	}
+	for nombre, valor := range dotenv {
+		if _, ok := os.LookupEnv(nombre); ok {
+			delete(dotenv, nombre)
+			continue
 		}
+		os.Setenv(nombre, valor)
 	}
+	return dotenv
+}
 
-	// Enlazar las variables de entorno con Viper
-	for _, envVar := range requiredEnvVars {
-		viper.BindEnv(envVar)
+// validarSecretos verifica los datos que requiere el proveedor de secretos configurado. Con el proveedor
+// chain basta con que un proveedor de la cadena tenga sus datos, porque los demás se omiten al consultarla.
+func (c *cargador) validarSecretos(cfg *Config) {
+	if cfg.Secrets.Provider != secrets.ProviderChain {
+		c.errs = append(c.errs, c.requisitosSecretos(cfg, cfg.Secrets.Proveedores()[0])...)
+		return
+	}
+	if len(cfg.Secrets.Chain) == 0 {
+		c.invalido("secrets.chain", "es obligatorio con el proveedor chain")
+		return
 	}
 
-	logs.Logger.LogInfo("Configuración cargada correctamente", "CONFIG_INIT")
+	var faltantes []error
+	disponible := false
+	for _, proveedor := range cfg.Secrets.Chain {
+		switch proveedor {
+		case secrets.ProviderEnv, secrets.ProviderFile, secrets.ProviderAWS:
+			errs := c.requisitosSecretos(cfg, proveedor)
+			disponible = disponible || len(errs) == 0
+			faltantes = append(faltantes, errs...)
+		case secrets.ProviderChain:
+			c.invalido("secrets.chain", "no puede contener chain")
+		default:
+			c.invalido("secrets.chain", fmt.Sprintf("proveedor %q no permitido", proveedor))
+		}
+	}
+	if !disponible {
+		c.invalido("secrets.chain", "ningún proveedor de la cadena tiene los datos que requiere")
+		c.errs = append(c.errs, faltantes...)
+	}
+}
+
+// requisitosSecretos retorna un error por cada dato faltante que requiere el proveedor indicado.
+func (c *cargador) requisitosSecretos(cfg *Config, proveedor string) []error {
+	var errs []error
+	switch proveedor {
+	case secrets.ProviderEnv:
+		// El proveedor env lee las credenciales directamente de las variables de entorno
+		variables := slices.Sorted(maps.Values(secrets.VariablesDB))
+		for _, variable := range variables {
+			if _, ok := os.LookupEnv(variable); !ok {
+				errs = append(errs, fmt.Errorf("%s: es obligatorio con el proveedor de secretos env", variable))
+			}
+		}
+	case secrets.ProviderFile:
+		if cfg.Secrets.FilePath == "" {
+			errs = append(errs, errorClave("secrets.file_path", "es obligatorio con el proveedor de secretos file"))
+		}
+	case secrets.ProviderAWS:
+		if cfg.DB.SecretName == "" {
+			errs = append(errs, errorClave("secrets.db", "es obligatorio con el proveedor de secretos aws"))
+		}
+	}
+	return errs
+}
+
+// fuente obtiene los valores de configuración de las variables de entorno, del YAML opcional y del
+// archivo .env. Cada clave es la ruta en el YAML, por ejemplo db.host, y su variable es DB_HOST.
+type fuente struct {
+	yaml *viper.Viper
+	// dotenv son las variables que se tomaron del archivo .env y no estaban definidas en el entorno.
+	dotenv map[string]string
+}
+
+// valor retorna el valor de la clave y si está configurado.
+func (f fuente) valor(clave string) (string, bool) {
+	nombre := variable(clave)
+	if _, deDotenv := f.dotenv[nombre]; !deDotenv {
+		if valor, ok := os.LookupEnv(nombre); ok {
+			return valor, true
+		}
+	}
+	if f.yaml == nil || !f.yaml.IsSet(clave) {
+		valor, ok := f.dotenv[nombre]
+		return valor, ok
+	}
+
+	// Las listas del YAML se convierten al mismo formato separado por comas de las variables de entorno
+	if lista, ok := f.yaml.Get(clave).([]any); ok {
+		valores := make([]string, 0, len(lista))
+		for _, elemento := range lista {
+			valores = append(valores, fmt.Sprint(elemento))
+		}
+		return strings.Join(valores, ","), true
+	}
+	return f.yaml.GetString(clave), true
+}
+
+// variable retorna el nombre de la variable de entorno de la clave.
+func variable(clave string) string {
+	return strings.ToUpper(strings.ReplaceAll(clave, ".", "_"))
+}
+
+// cargador lee los valores de la fuente y acumula los errores de todos los campos.
+type cargador struct {
+	fuente
+	errs []error
+}
+
+// invalido registra un error nombrando la variable de entorno y la clave del YAML.
+func (c *cargador) invalido(clave, mensaje string) {
+	c.errs = append(c.errs, errorClave(clave, mensaje))
+}
+
+// errorClave retorna un error nombrando la variable de entorno y la clave del YAML.
+func errorClave(clave, mensaje string) error {
+	return fmt.Errorf("%s (%s): %s", variable(clave), clave, mensaje)
+}
+
+// texto retorna el valor de la clave o el valor por defecto si no está configurado o está vacío.
+func (c *cargador) texto(clave, porDefecto string) string {
+	valor, _ := c.valor(clave)
+	if valor = strings.TrimSpace(valor); valor == "" {
+		return porDefecto
+	}
+	return valor
+}
+
+// requerido retorna el valor de la clave y registra un error si no está configurado.
+func (c *cargador) requerido(clave string) string {
+	valor := c.texto(clave, "")
+	if valor == "" {
+		c.invalido(clave, "es obligatorio")
+	}
+	return valor
+}
+
+// puerto retorna un número de puerto válido; sin valor por defecto el puerto es obligatorio.
+func (c *cargador) puerto(clave, porDefecto string) string {
+	valor := c.texto(clave, porDefecto)
+	if valor == "" {
+		c.invalido(clave, "es obligatorio")
+		return ""
+	}
+	if numero, err := strconv.Atoi(valor); err != nil || numero < 1 || numero > 65535 {
+		c.invalido(clave, fmt.Sprintf("puerto %q inválido", valor))
+	}
+	return valor
+}
+
+// entero retorna un entero mayor o igual a minimo.
+func (c *cargador) entero(clave string, porDefecto, minimo int) int {
+	valor := c.texto(clave, "")
+	if valor == "" {
+		return porDefecto
+	}
+	numero, err := strconv.Atoi(valor)
+	if err != nil {
+		c.invalido(clave, fmt.Sprintf("valor %q inválido, se esperaba un entero", valor))
+		return porDefecto
+	}
+	if numero < minimo {
+		c.invalido(clave, fmt.Sprintf("debe ser mayor o igual a %d", minimo))
+		return porDefecto
+	}
+	return numero
+}
+
+// duracion retorna una duración positiva, por ejemplo "30s".
+func (c *cargador) duracion(clave string, porDefecto time.Duration) time.Duration {
+	valor := c.texto(clave, "")
+	if valor == "" {
+		return porDefecto
+	}
+	duracion, err := time.ParseDuration(valor)
+	if err != nil || duracion <= 0 {
+		c.invalido(clave, fmt.Sprintf("duración %q inválida, se esperaba un valor positivo como 30s", valor))
+		return porDefecto
+	}
+	return duracion
+}
+
+// duracionOCero retorna una duración positiva o cero, para las claves en las que cero tiene un significado
+// propio, como deshabilitar un límite o hacer un solo intento.
+func (c *cargador) duracionOCero(clave string, porDefecto time.Duration) time.Duration {
+	valor := c.texto(clave, "")
+	if valor == "" {
+		return porDefecto
+	}
+	duracion, err := time.ParseDuration(valor)
+	if err != nil || duracion < 0 {
+		c.invalido(clave, fmt.Sprintf("duración %q inválida, se esperaba cero o un valor positivo como 30s", valor))
+		return porDefecto
+	}
+	return duracion
+}
+
+// url retorna una URL absoluta http o https, o vacío si no está configurada.
+func (c *cargador) url(clave string) string {
+	valor := c.texto(clave, "")
+	if valor == "" {
+		return ""
+	}
+	if u, err := url.Parse(valor); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.invalido(clave, fmt.Sprintf("URL %q inválida, se esperaba una URL http o https", valor))
+	}
+	return valor
+}
+
+// opcion retorna el valor de la clave si es uno de los permitidos.
+func (c *cargador) opcion(clave, porDefecto string, permitidos []string) string {
+	valor := c.texto(clave, porDefecto)
+	if !slices.Contains(permitidos, valor) {
+		c.invalido(clave, fmt.Sprintf("valor %q no permitido, se esperaba uno de %s", valor, strings.Join(permitidos, ", ")))
+		return porDefecto
+	}
+	return valor
+}
+
+// lista retorna los valores separados por comas de la clave.
+func (c *cargador) lista(clave string) []string {
+	var valores []string
+	for _, valor := range strings.Split(c.texto(clave, ""), ",") {
+		if valor = strings.TrimSpace(valor); valor != "" {
+			valores = append(valores, valor)
+		}
+	}
+	return valores
 }
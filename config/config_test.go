@@ -0,0 +1,342 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gmf_transmission_response/connection"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/jobs"
+	"gmf_transmission_response/internal/queue"
+	"gmf_transmission_response/internal/secrets"
+	"gmf_transmission_response/internal/service"
+)
+
+// variablesConfig contiene las variables de entorno que lee la configuración.
+var variablesConfig = []string{
+	"APP_ENV", "HOST", "PORT",
+	"SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT", "SERVER_IDLE_TIMEOUT", "SERVER_SHUTDOWN_TIMEOUT",
+	"DB_HOST", "DB_PORT", "DB_NAME", "DB_USER", "DB_PASSWORD",
+	"DB_SSLMODE", "DB_SSLROOTCERT", "DB_SSLCERT", "DB_SSLKEY",
+	"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME",
+	"DB_STARTUP_TIMEOUT", "DB_RETRY_INITIAL_BACKOFF", "DB_RETRY_MAX_BACKOFF",
+	"AWS_ENDPOINT", "AWS_PROFILE", "REGION_ZONE",
+	"SECRETS_PROVIDER", "SECRETS_CHAIN", "SECRETS_FILE_PATH", "SECRETS_DB", "SECRETS_CACHE_TTL",
+	"LOG_FORMAT", "LOG_LEVEL",
+	"PROCESSING_CONCURRENCY", "BATCH_THRESHOLD", "JOBS_CONCURRENCY", "JOBS_LEASE",
+	"QUEUE_URL", "QUEUE_DLQ_URL", "QUEUE_MAX_RECEIVE_COUNT", "QUEUE_VISIBILITY_TIMEOUT", "QUEUE_WAIT_TIME",
+	"IDEMPOTENCY_RETENTION", "IDEMPOTENCY_PURGE_INTERVAL", "HEALTH_CHECK_TIMEOUT",
+}
+
+// limpiarEntorno elimina las variables de la configuración y las restaura al terminar la prueba.
+func limpiarEntorno(t *testing.T) {
+	t.Helper()
+	for _, variable := range variablesConfig {
+		t.Setenv(variable, "")
+		os.Unsetenv(variable)
+	}
+}
+
+// entornoMinimo define las variables obligatorias con el proveedor de secretos por defecto.
+func entornoMinimo(t *testing.T) {
+	t.Helper()
+	limpiarEntorno(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("DB_NAME", "postgres")
+	t.Setenv("SECRETS_DB", "gmf-secret")
+}
+
+// yamlDesde escribe el contenido en un archivo YAML temporal y lo carga.
+func yamlDesde(t *testing.T, contenido string) fuente {
+	t.Helper()
+	archivo := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(archivo, []byte(contenido), 0o600))
+
+	v := viper.New()
+	v.SetConfigFile(archivo)
+	require.NoError(t, v.ReadInConfig())
+	return fuente{yaml: v}
+}
+
+func TestCargar_ValoresPorDefecto(t *testing.T) {
+	entornoMinimo(t)
+
+	cfg, err := cargar(fuente{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, connection.DefaultReadTimeout, cfg.Server.ReadTimeout)
+	assert.Equal(t, connection.DefaultShutdownTimeout, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "localhost", cfg.DB.Host)
+	assert.Equal(t, "5432", cfg.DB.Port)
+	assert.Equal(t, "gmf-secret", cfg.DB.SecretName)
+	assert.Equal(t, secrets.TTLPorDefecto, cfg.DB.SecretsCacheTTL)
+	assert.Equal(t, connection.SSLModeDisable, cfg.DB.SSLMode)
+	assert.Equal(t, connection.DefaultStartupTimeout, cfg.DBReintentos.Timeout)
+	assert.Equal(t, secrets.ProviderAWS, cfg.Secrets.Provider)
+	assert.False(t, cfg.Secrets.AWS.Local)
+	assert.Equal(t, "STRING", cfg.Log.Format)
+	assert.Equal(t, "INFO", cfg.Log.Level)
+	assert.Equal(t, service.ConcurrenciaPorDefecto, cfg.Concurrencia)
+	assert.Equal(t, service.UmbralLotePorDefecto, cfg.UmbralLote)
+	assert.Equal(t, idempotency.IntervaloDepuracionPorDefecto, cfg.IdempotencyPurgeInterval)
+	assert.Equal(t, jobs.ConcurrenciaPorDefecto, cfg.JobsConcurrencia)
+	assert.Equal(t, jobs.ColaPorDefecto, cfg.JobsCola)
+	assert.Equal(t, jobs.ReservaPorDefecto, cfg.JobsReserva)
+	assert.Equal(t, jobs.RetencionPorDefecto, cfg.JobsRetencion)
+	assert.Equal(t, jobs.IntervaloDepuracionPorDefecto, cfg.JobsPurgeInterval)
+	assert.Equal(t, queue.Config{
+		MaxReceiveCount:   queue.MaxReceiveCountPorDefecto,
+		VisibilityTimeout: queue.VisibilityTimeoutPorDefecto,
+		WaitTime:          queue.WaitTimePorDefecto,
+	}, cfg.Queue)
+}
+
+func TestCargar_Entorno(t *testing.T) {
+	entornoMinimo(t)
+	t.Setenv("APP_ENV", "local")
+	t.Setenv("PORT", "9090")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "90s")
+	t.Setenv("DB_MAX_OPEN_CONNS", "25")
+	t.Setenv("SECRETS_PROVIDER", "env")
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "postgres")
+	t.Setenv("LOG_FORMAT", "JSON")
+	t.Setenv("PROCESSING_CONCURRENCY", "4")
+	t.Setenv("BATCH_THRESHOLD", "0")
+	t.Setenv("JOBS_CONCURRENCY", "3")
+	t.Setenv("JOBS_QUEUE_SIZE", "0")
+	t.Setenv("JOBS_LEASE", "2m")
+	t.Setenv("JOBS_RETENTION", "72h")
+	t.Setenv("JOBS_PURGE_INTERVAL", "30m")
+	t.Setenv("QUEUE_URL", "http://localstack:4566/000000000000/gmf-transmission-response")
+	t.Setenv("QUEUE_DLQ_URL", "http://localstack:4566/000000000000/gmf-transmission-response-dlq")
+	t.Setenv("QUEUE_MAX_RECEIVE_COUNT", "3")
+	t.Setenv("QUEUE_VISIBILITY_TIMEOUT", "1m")
+	t.Setenv("AWS_ENDPOINT", "http://localstack:4566")
+	t.Setenv("REGION_ZONE", "us-east-2")
+	t.Setenv("AWS_PROFILE", "gmf")
+
+	cfg, err := cargar(fuente{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, 90*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, 25, cfg.DB.MaxOpenConns)
+	assert.Equal(t, secrets.ProviderEnv, cfg.Secrets.Provider)
+	assert.True(t, cfg.Secrets.AWS.Local)
+	assert.Equal(t, "http://localstack:4566", cfg.Secrets.AWS.Endpoint)
+	assert.Equal(t, "us-east-2", cfg.Secrets.AWS.Region)
+	assert.Equal(t, "gmf", cfg.Secrets.AWS.Profile)
+	assert.Equal(t, "JSON", cfg.Log.Format)
+	assert.Equal(t, 4, cfg.Concurrencia)
+	assert.Equal(t, 0, cfg.UmbralLote)
+	assert.Equal(t, 3, cfg.JobsConcurrencia)
+	assert.Equal(t, 0, cfg.JobsCola)
+	assert.Equal(t, 2*time.Minute, cfg.JobsReserva)
+	assert.Equal(t, 72*time.Hour, cfg.JobsRetencion)
+	assert.Equal(t, 30*time.Minute, cfg.JobsPurgeInterval)
+	assert.Equal(t, queue.Config{
+		URL:               "http://localstack:4566/000000000000/gmf-transmission-response",
+		DeadLetterURL:     "http://localstack:4566/000000000000/gmf-transmission-response-dlq",
+		MaxReceiveCount:   3,
+		VisibilityTimeout: time.Minute,
+		WaitTime:          queue.WaitTimePorDefecto,
+	}, cfg.Queue)
+}
+
+func TestCargar_YAML(t *testing.T) {
+	limpiarEntorno(t)
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "postgres")
+	// Las variables de entorno tienen prioridad sobre el YAML
+	t.Setenv("DB_NAME", "gmf")
+
+	f := yamlDesde(t, `
+port: 8081
+db:
+  host: db.interno
+  port: 5433
+  name: postgres
+  conn_max_lifetime: 30m
+secrets:
+  provider: chain
+  chain: [env, file]
+  file_path: /run/secrets
+log:
+  level: DEBUG
+`)
+
+	cfg, err := cargar(f)
+
+	require.NoError(t, err)
+	assert.Equal(t, "8081", cfg.Server.Port)
+	assert.Equal(t, "db.interno", cfg.DB.Host)
+	assert.Equal(t, "5433", cfg.DB.Port)
+	assert.Equal(t, "gmf", cfg.DB.Name)
+	assert.Equal(t, 30*time.Minute, cfg.DB.ConnMaxLifetime)
+	assert.Equal(t, secrets.ProviderChain, cfg.Secrets.Provider)
+	assert.Equal(t, []string{secrets.ProviderEnv, secrets.ProviderFile}, cfg.Secrets.Chain)
+	assert.Equal(t, "/run/secrets", cfg.Secrets.FilePath)
+	assert.Equal(t, "DEBUG", cfg.Log.Level)
+}
+
+func TestCargar_ErroresNombranCadaCampo(t *testing.T) {
+	limpiarEntorno(t)
+	t.Setenv("PORT", "http")
+	t.Setenv("DB_PORT", "70000")
+	t.Setenv("DB_SSLMODE", "verify-full")
+	t.Setenv("SERVER_READ_TIMEOUT", "-1s")
+	t.Setenv("PROCESSING_CONCURRENCY", "0")
+	t.Setenv("BATCH_THRESHOLD", "muchos")
+	t.Setenv("LOG_FORMAT", "XML")
+	t.Setenv("SECRETS_PROVIDER", "chain")
+	t.Setenv("SECRETS_CHAIN", "env,file,vault")
+	t.Setenv("AWS_ENDPOINT", "localhost:4566")
+	t.Setenv("QUEUE_MAX_RECEIVE_COUNT", "0")
+
+	cfg, err := cargar(fuente{})
+
+	assert.Nil(t, cfg)
+	require.Error(t, err)
+	for _, esperado := range []string{
+		`PORT (port): puerto "http" inválido`,
+		`DB_PORT (db.port): puerto "70000" inválido`,
+		"DB_HOST (db.host): es obligatorio",
+		"DB_NAME (db.name): es obligatorio",
+		"SERVER_READ_TIMEOUT (server.read_timeout)",
+		"PROCESSING_CONCURRENCY (processing.concurrency): debe ser mayor o igual a 1",
+		`BATCH_THRESHOLD (batch.threshold): valor "muchos" inválido`,
+		`LOG_FORMAT (log.format): valor "XML" no permitido`,
+		"DB_PASSWORD: es obligatorio con el proveedor de secretos env",
+		"DB_USER: es obligatorio con el proveedor de secretos env",
+		"SECRETS_FILE_PATH (secrets.file_path): es obligatorio con el proveedor de secretos file",
+		`SECRETS_CHAIN (secrets.chain): proveedor "vault" no permitido`,
+		`AWS_ENDPOINT (aws.endpoint): URL "localhost:4566" inválida`,
+		"QUEUE_MAX_RECEIVE_COUNT (queue.max_receive_count): debe ser mayor o igual a 1",
+		"configuración de la base de datos: sslmode verify-full requiere el certificado raíz (sslrootcert)",
+	} {
+		assert.Contains(t, err.Error(), esperado)
+	}
+}
+
+func TestCargar_SecretoAWSObligatorio(t *testing.T) {
+	entornoMinimo(t)
+	os.Unsetenv("SECRETS_DB")
+
+	_, err := cargar(fuente{})
+
+	assert.ErrorContains(t, err, "SECRETS_DB (secrets.db): es obligatorio con el proveedor de secretos aws")
+}
+
+func TestCargar_PrioridadEntornoYAMLDotenv(t *testing.T) {
+	entornoMinimo(t)
+	t.Setenv("PORT", "9000")
+	f := yamlDesde(t, `
+port: 8081
+db:
+  name: gmf
+`)
+	f.dotenv = map[string]string{"DB_NAME": "dotenv", "LOG_LEVEL": "DEBUG"}
+	// cargarDotenv agrega al entorno las variables del .env que no estaban definidas
+	t.Setenv("DB_NAME", "dotenv")
+	t.Setenv("LOG_LEVEL", "DEBUG")
+
+	cfg, err := cargar(f)
+
+	require.NoError(t, err)
+	assert.Equal(t, "9000", cfg.Server.Port, "el entorno tiene prioridad sobre el YAML y .env")
+	assert.Equal(t, "gmf", cfg.DB.Name, "el YAML tiene prioridad sobre .env")
+	assert.Equal(t, "DEBUG", cfg.Log.Level, ".env se usa si la clave no está en el entorno ni en el YAML")
+}
+
+func TestCargarDotenv_NoReemplazaElEntorno(t *testing.T) {
+	limpiarEntorno(t)
+	directorio, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(directorio) })
+	require.NoError(t, os.WriteFile(".env", []byte("DB_HOST=dotenv\nDB_NAME=dotenv\n"), 0o600))
+	t.Setenv("DB_HOST", "entorno")
+
+	dotenv := cargarDotenv()
+
+	assert.Equal(t, map[string]string{"DB_NAME": "dotenv"}, dotenv)
+	assert.Equal(t, "entorno", os.Getenv("DB_HOST"))
+	assert.Equal(t, "dotenv", os.Getenv("DB_NAME"))
+}
+
+func TestCargar_CadenaConUnProveedorDisponible(t *testing.T) {
+	entornoMinimo(t)
+	os.Unsetenv("SECRETS_DB")
+	t.Setenv("SECRETS_PROVIDER", "chain")
+	t.Setenv("SECRETS_CHAIN", "env,file,aws")
+	t.Setenv("SECRETS_FILE_PATH", "/run/secrets")
+
+	cfg, err := cargar(fuente{})
+
+	// Las credenciales de env y el secreto de aws faltan, pero file tiene sus datos
+	require.NoError(t, err)
+	assert.Equal(t, []string{"env", "file", "aws"}, cfg.Secrets.Chain)
+}
+
+func TestCargar_CadenaSinProveedoresDisponibles(t *testing.T) {
+	entornoMinimo(t)
+	os.Unsetenv("SECRETS_DB")
+	t.Setenv("SECRETS_PROVIDER", "chain")
+	t.Setenv("SECRETS_CHAIN", "file,aws")
+
+	_, err := cargar(fuente{})
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err,
+		"SECRETS_CHAIN (secrets.chain): ningún proveedor de la cadena tiene los datos que requiere")
+	assert.ErrorContains(t, err, "SECRETS_FILE_PATH (secrets.file_path): es obligatorio con el proveedor de secretos file")
+	assert.ErrorContains(t, err, "SECRETS_DB (secrets.db): es obligatorio con el proveedor de secretos aws")
+}
+
+func TestCargar_DuracionesEnCero(t *testing.T) {
+	entornoMinimo(t)
+	for _, variable := range []string{"DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "DB_STARTUP_TIMEOUT",
+		"DB_RETRY_INITIAL_BACKOFF", "DB_RETRY_MAX_BACKOFF", "QUEUE_WAIT_TIME"} {
+		t.Setenv(variable, "0")
+	}
+
+	cfg, err := cargar(fuente{})
+
+	require.NoError(t, err)
+	assert.Zero(t, cfg.DB.ConnMaxLifetime)
+	assert.Zero(t, cfg.DB.ConnMaxIdleTime)
+	assert.Equal(t, connection.ReintentoConfig{}, cfg.DBReintentos)
+	assert.Zero(t, cfg.Queue.WaitTime)
+}
+
+func TestCargar_DuracionNegativa(t *testing.T) {
+	entornoMinimo(t)
+	t.Setenv("DB_STARTUP_TIMEOUT", "-1s")
+	t.Setenv("SERVER_READ_TIMEOUT", "0")
+
+	_, err := cargar(fuente{})
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `DB_STARTUP_TIMEOUT (db.startup_timeout): duración "-1s" inválida, se esperaba cero`)
+	assert.ErrorContains(t, err, `SERVER_READ_TIMEOUT (server.read_timeout): duración "0" inválida`)
+}
+
+func TestInitConsumer_SinURLDeLaCola(t *testing.T) {
+	entornoMinimo(t)
+	cfg, err := cargar(fuente{})
+	require.NoError(t, err)
+
+	consumer, dbManager, err := InitConsumer(cfg)
+
+	assert.Nil(t, consumer)
+	assert.Nil(t, dbManager)
+	assert.EqualError(t, err, "QUEUE_URL (queue.url): es obligatorio para el consumidor")
+}
@@ -1,23 +1,49 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 
 	"gmf_transmission_response/connection"
+	awsinternal "gmf_transmission_response/internal/aws"
 	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/jobs"
 	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/queue"
 	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/secrets"
 	"gmf_transmission_response/internal/service"
 )
 
-// InitApplication inicializa todos los componentes necesarios para la aplicación.
-func InitApplication() (*handler.ArchivoHandler, *connection.DBManager) {
-	// Inicializar el ConfigManager y cargar la configuración
-	configManager := NewConfigManager()
-	configManager.InitConfig()
+// componentes contiene los componentes que comparten todos los entrypoints de la aplicación.
+type componentes struct {
+	dbManager         *connection.DBManager
+	archivoService    *service.ArchivoService
+	idempotenciaStore *idempotency.Store
+}
+
+// iniciarComponentes configura los logs, abre la conexión a la base de datos e inicializa el servicio de
+// archivos y el store de idempotencia.
+func iniciarComponentes(cfg *Config) componentes {
+	// Aplicar el formato y el nivel de los logs
+	logs.Configure(cfg.Log)
+
+	// Inicializar el proveedor de secretos configurado con las credenciales de la base de datos
+	secretProvider, err := secrets.NewProvider(cfg.Secrets)
+	if err != nil {
+		logs.Logger.LogError("Error inicializando el proveedor de secretos", err, "APP_INIT")
+		log.Fatalf("Error inicializando el proveedor de secretos: %v", err)
+	}
 
 	// Inicializar el DBManager y abrir la conexión a la base de datos
-	dbManager := connection.NewDBManager()
+	dbManager := connection.NewDBManager(
+		connection.WithDBConfig(cfg.DB),
+		connection.WithReintentos(cfg.DBReintentos),
+		connection.WithSecretProvider(secretProvider))
 	if err := dbManager.InitDB(); err != nil {
 		logs.Logger.LogError("Error inicializando la base de datos", err, "APP_INIT")
 		log.Fatalf("Error inicializando la base de datos: %v", err)
@@ -26,19 +52,128 @@ func InitApplication() (*handler.ArchivoHandler, *connection.DBManager) {
 	// Inicializar el repositorio con la conexión a la base de datos
 	repo := repository.NewArchivoRepository(dbManager.GetDB())
 
-	// Inicializar el servicio de archivos con el repositorio
-	archivoService := service.NewArchivoService(repo)
+	// Inicializar el servicio de archivos con el repositorio, la cantidad de archivos a procesar en paralelo
+	// y el tamaño a partir del cual una solicitud se procesa como un lote
+	archivoService := service.NewArchivoService(repo,
+		service.WithConcurrencia(cfg.Concurrencia),
+		service.WithUmbralLote(cfg.UmbralLote))
+
+	// Inicializar el store de idempotencia con la ventana de retención configurada
+	idempotenciaStore := idempotency.NewStore(
+		repository.NewIdempotenciaRepository(dbManager.GetDB()),
+		cfg.IdempotencyRetention,
+	)
+
+	return componentes{
+		dbManager:         dbManager,
+		archivoService:    archivoService,
+		idempotenciaStore: idempotenciaStore,
+	}
+}
+
+// InitApplication inicializa todos los componentes del servidor HTTP con la configuración indicada.
+// La función retornada detiene las tareas en segundo plano y espera los jobs en curso; el servidor la llama
+// antes de cerrar la base de datos.
+func InitApplication(cfg *Config) (*handler.ArchivoHandler, *handler.HealthHandler, *connection.DBManager,
+	func(ctx context.Context) error) {
+	c := iniciarComponentes(cfg)
+
+	// Inicializar el manager de jobs asíncronos y reanudar los que quedaron pendientes antes del reinicio
+	jobManager := jobs.NewManager(repository.NewJobRepository(c.dbManager.GetDB()), c.archivoService,
+		jobs.WithConcurrencia(cfg.JobsConcurrencia),
+		jobs.WithCola(cfg.JobsCola),
+		jobs.WithReserva(cfg.JobsReserva),
+		jobs.WithRetencion(cfg.JobsRetencion))
+	if err := jobManager.Reanudar(); err != nil {
+		logs.Logger.LogError("Error al reanudar los jobs pendientes", err, "APP_INIT")
+	}
+
+	// Eliminar periódicamente las claves de idempotencia expiradas y los jobs terminados
+	depuracionCtx, detenerDepuracion := context.WithCancel(context.Background())
+	var depuraciones sync.WaitGroup
+	depuraciones.Add(2)
+	go func() {
+		defer depuraciones.Done()
+		c.idempotenciaStore.Depurar(depuracionCtx, cfg.IdempotencyPurgeInterval)
+	}()
+	go func() {
+		defer depuraciones.Done()
+		jobManager.Depurar(depuracionCtx, cfg.JobsPurgeInterval)
+	}()
+	depuracionTerminada := make(chan struct{})
+	go func() {
+		depuraciones.Wait()
+		close(depuracionTerminada)
+	}()
 
 	// Inicializar el handler de archivos
-	archivoHandler := handler.NewArchivoHandler(archivoService)
+	archivoHandler := handler.NewArchivoHandler(c.archivoService,
+		handler.WithIdempotencia(c.idempotenciaStore),
+		handler.WithJobs(jobManager))
+
+	// Inicializar el handler de salud con las dependencias que se verifican en readiness
+	healthHandler := handler.NewHealthHandler(
+		cfg.HealthCheckTimeout,
+		handler.DatabaseCheck(c.dbManager),
+		handler.SecretsCheck(c.dbManager.Secretos(), cfg.DB.SecretName),
+	)
+
+	esperarTareas := func(ctx context.Context) error {
+		detenerDepuracion()
+		select {
+		case <-depuracionTerminada:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return jobManager.Esperar(ctx)
+	}
+
+	logs.Logger.LogInfo("Aplicación inicializada correctamente ✅ ", "APP_INIT")
+
+	return archivoHandler, healthHandler, c.dbManager, esperarTareas
+}
+
+// InitLambda inicializa los componentes del entrypoint de Lambda con la configuración indicada.
+// Lambda congela el proceso al terminar cada invocación, por lo que no se crea el manager de jobs: no se
+// reanudan los jobs pendientes y las solicitudes con Prefer: respond-async se procesan de forma síncrona.
+func InitLambda(cfg *Config) (*handler.ArchivoHandler, *connection.DBManager) {
+	c := iniciarComponentes(cfg)
+
+	archivoHandler := handler.NewArchivoHandler(c.archivoService,
+		handler.WithIdempotencia(c.idempotenciaStore))
 
 	logs.Logger.LogInfo("Aplicación inicializada correctamente ✅ ", "APP_INIT")
 
-	return archivoHandler, dbManager
+	return archivoHandler, c.dbManager
 }
 
-// CleanupApplication maneja la limpieza de recursos, como cerrar conexiones a la base de datos.
-func CleanupApplication(dbManager connection.DBManagerInterface) {
-	dbManager.CloseDB()
-	logs.Logger.LogInfo("Recursos limpiados correctamente 🧹", "APP_CLEANUP")
+// InitConsumer inicializa el consumidor de la cola configurada en QUEUE_URL. Con QUEUE_DLQ_URL los mensajes
+// que superan QUEUE_MAX_RECEIVE_COUNT recepciones y los mensajes inválidos se envían a la dead-letter queue.
+func InitConsumer(cfg *Config) (*queue.Consumer, *connection.DBManager, error) {
+	// Validar la cola antes de abrir la conexión a la base de datos
+	if cfg.Queue.URL == "" {
+		return nil, nil, errors.New("QUEUE_URL (queue.url): es obligatorio para el consumidor")
+	}
+	cola, err := awsinternal.NewSQS(cfg.Secrets.AWS, cfg.Queue.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error inicializando la cola: %w", err)
+	}
+	opciones := []queue.ConsumerOption{
+		queue.WithVisibilityTimeout(cfg.Queue.VisibilityTimeout),
+		queue.WithWaitTime(cfg.Queue.WaitTime),
+	}
+	if cfg.Queue.DeadLetterURL != "" {
+		dlq, err := awsinternal.NewSQS(cfg.Secrets.AWS, cfg.Queue.DeadLetterURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error inicializando la dead-letter queue: %w", err)
+		}
+		opciones = append(opciones, queue.WithDeadLetterQueue(dlq, cfg.Queue.MaxReceiveCount))
+	}
+
+	c := iniciarComponentes(cfg)
+	consumer := queue.NewConsumer(cola, c.archivoService, opciones...)
+
+	logs.Logger.LogInfo("Consumidor inicializado correctamente ✅ ", "APP_INIT")
+
+	return consumer, c.dbManager, nil
 }
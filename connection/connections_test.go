@@ -1,12 +1,15 @@
 package connection
 
 import (
+	"context"
 	"errors"
-	"os"
+	"regexp"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -15,9 +18,10 @@ type MockAWSSecretsManager struct {
 	mock.Mock
 }
 
-func (m *MockAWSSecretsManager) GetSecret(secretName string) (map[string]string, error) {
+func (m *MockAWSSecretsManager) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
 	args := m.Called(secretName)
-	return args.Get(0).(map[string]string), args.Error(1)
+	secret, _ := args.Get(0).(map[string]string)
+	return secret, args.Error(1)
 }
 
 func TestNewDBManager(t *testing.T) {
@@ -33,11 +37,52 @@ func TestDBManager_InitDB_Error(t *testing.T) {
 		"test_secret").Return(
 		nil, errors.New("error getting secret"))
 
-	os.Setenv("SECRETS_DB", "test_secret")
+	dbManager := NewDBManager(WithDBConfig(DBConfig{SecretName: "test_secret"}), WithSecretProvider(mockSecretsManager))
+	err := dbManager.InitDB()
+	assert.ErrorContains(t, err, "error obteniendo el secreto: error getting secret")
+	assert.Nil(t, dbManager.DB)
+	mockSecretsManager.AssertExpectations(t)
+}
+
+func TestDBManager_InitDB_SecretoMalformado(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(map[string]string{"USERNAME": "user"}, nil)
+
+	dsnConstruido := false
+	dbManager := NewDBManager(WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager), WithDSNBuilder(func(usuario, password string) string {
+			dsnConstruido = true
+			return ""
+		}))
+	err := dbManager.InitDB()
+	assert.ErrorContains(t, err, "no contiene USERNAME y PASSWORD")
+	assert.False(t, dsnConstruido)
+	assert.Nil(t, dbManager.DB)
+}
+
+func TestDBManager_InitDB_ErrorAlAbrir(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(
+		map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, nil)
+
+	var credenciales []string
+	dbManager := NewDBManager(WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager), WithDSNBuilder(func(usuario, password string) string {
+			credenciales = append(credenciales, usuario, password)
+			// Un DSN inválido falla al abrir la conexión sin acceder a la red
+			return "host=localhost port=no-es-un-puerto"
+		}))
+	err := dbManager.InitDB()
+	assert.ErrorContains(t, err, "error al abrir la conexión a la base de datos")
+	assert.Equal(t, []string{"user", "pass"}, credenciales)
+	assert.Nil(t, dbManager.DB)
+	mockSecretsManager.AssertNumberOfCalls(t, "GetSecret", 1)
+}
 
-	dbManager := &DBManager{}
+func TestDBManager_InitDB_SinProveedor(t *testing.T) {
+	dbManager := NewDBManager(WithDBConfig(DBConfig{SecretName: "test_secret"}))
 	err := dbManager.InitDB()
-	assert.Error(t, err)
+	assert.ErrorContains(t, err, "no se configuró el proveedor de secretos")
 	assert.Nil(t, dbManager.DB)
 }
 
@@ -48,3 +93,58 @@ func TestDBManager_GetDB(t *testing.T) {
 	db := dbManager.GetDB()
 	assert.NotNil(t, db)
 }
+
+// nuevaDBDePrueba retorna una conexión de GORM sobre sqlmock
+func nuevaDBDePrueba(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	assert.NoError(t, err)
+	return gormDB, mock
+}
+
+func TestVerificarTablas(t *testing.T) {
+	db, mock := nuevaDBDePrueba(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT table_name FROM information_schema.tables")).
+		WithArgs("cgd_idempotencia", "cgd_jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("cgd_idempotencia").AddRow("cgd_jobs"))
+
+	assert.NoError(t, verificarTablas(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerificarTablas_TablasFaltantes(t *testing.T) {
+	db, mock := nuevaDBDePrueba(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT table_name FROM information_schema.tables")).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("cgd_jobs"))
+
+	err := verificarTablas(db)
+
+	assert.EqualError(t, err, "faltan las tablas [cgd_idempotencia], aplique las migraciones de migrations/")
+	// Las tablas no se crean al iniciar
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerificarHistorialEstados(t *testing.T) {
+	db, mock := nuevaDBDePrueba(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT column_name FROM information_schema.columns")).
+		WithArgs("cgd_archivo_estados", "gaw_rta_trans_estado", "gaw_rta_trans_codigo").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).
+			AddRow("gaw_rta_trans_estado").AddRow("gaw_rta_trans_codigo"))
+
+	assert.NoError(t, verificarHistorialEstados(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerificarHistorialEstados_ColumnasFaltantes(t *testing.T) {
+	db, mock := nuevaDBDePrueba(t)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT column_name FROM information_schema.columns")).
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).AddRow("gaw_rta_trans_estado"))
+
+	err := verificarHistorialEstados(db)
+
+	assert.EqualError(t, err, "faltan las columnas [gaw_rta_trans_codigo], aplique las migraciones de migrations/")
+	// La tabla es compartida, por lo que no se ejecuta ningún ALTER TABLE
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,71 @@
+package connection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gmf_transmission_response/internal/logs"
+)
+
+// codigosErrorAutenticacion son los códigos SQLSTATE con los que Postgres rechaza las credenciales.
+var codigosErrorAutenticacion = map[string]bool{
+	"28P01": true, // invalid_password
+	"28000": true, // invalid_authorization_specification
+}
+
+// esErrorAutenticacion indica si err es un rechazo de credenciales de Postgres.
+func esErrorAutenticacion(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && codigosErrorAutenticacion[pgErr.Code]
+}
+
+// credencialesFunc obtiene el usuario y la contraseña de la base de datos.
+// Con refrescar en true se ignoran las credenciales en caché.
+type credencialesFunc func(ctx context.Context, refrescar bool) (usuario, password string, err error)
+
+// connectorConCredenciales abre cada conexión nueva con las credenciales vigentes. Si Postgres
+// rechaza las credenciales, por ejemplo tras una rotación del secreto, las refresca y reintenta una vez.
+type connectorConCredenciales struct {
+	credenciales credencialesFunc
+	dsn          func(usuario, password string) string
+	abrir        func(ctx context.Context, dsn string) (driver.Conn, error)
+}
+
+// Connect abre una conexión nueva a la base de datos.
+func (c *connectorConCredenciales) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.conectar(ctx, false)
+	if !esErrorAutenticacion(err) {
+		return conn, err
+	}
+
+	logs.Logger.LogWarn("Postgres rechazó las credenciales, se reintenta con el secreto actualizado", "DB_CONNECTION",
+		"detalle", err.Error())
+	return c.conectar(ctx, true)
+}
+
+// Driver retorna el driver de pgx.
+func (c *connectorConCredenciales) Driver() driver.Driver {
+	return stdlib.GetDefaultDriver()
+}
+
+// conectar abre una conexión con las credenciales obtenidas.
+func (c *connectorConCredenciales) conectar(ctx context.Context, refrescar bool) (driver.Conn, error) {
+	usuario, password, err := c.credenciales(ctx, refrescar)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo las credenciales de la base de datos: %w", err)
+	}
+	return c.abrir(ctx, c.dsn(usuario, password))
+}
+
+// abrirConexion abre una conexión con el driver de pgx.
+func abrirConexion(ctx context.Context, dsn string) (driver.Conn, error) {
+	connector, err := stdlib.GetDefaultDriver().(driver.DriverContext).OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
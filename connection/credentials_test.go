@@ -0,0 +1,81 @@
+package connection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn es una conexión vacía para las pruebas del connector
+type fakeConn struct{ dsn string }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("no implementado") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("no implementado") }
+
+// nuevoConnectorDePrueba retorna un connector que rechaza la contraseña "vieja"
+func nuevoConnectorDePrueba(refrescos *[]bool, errCredenciales error) *connectorConCredenciales {
+	return &connectorConCredenciales{
+		credenciales: func(ctx context.Context, refrescar bool) (string, string, error) {
+			*refrescos = append(*refrescos, refrescar)
+			if errCredenciales != nil {
+				return "", "", errCredenciales
+			}
+			if refrescar {
+				return "user", "nueva", nil
+			}
+			return "user", "vieja", nil
+		},
+		dsn: func(usuario, password string) string { return usuario + ":" + password },
+		abrir: func(ctx context.Context, dsn string) (driver.Conn, error) {
+			if dsn == "user:vieja" {
+				return nil, &pgconn.PgError{Code: "28P01", Message: "password authentication failed"}
+			}
+			return &fakeConn{dsn: dsn}, nil
+		},
+	}
+}
+
+func TestConnector_ReintentaConCredencialesRefrescadas(t *testing.T) {
+	var refrescos []bool
+	connector := nuevoConnectorDePrueba(&refrescos, nil)
+
+	conn, err := connector.Connect(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user:nueva", conn.(*fakeConn).dsn)
+	assert.Equal(t, []bool{false, true}, refrescos)
+}
+
+func TestConnector_ErrorDistintoDeAutenticacion(t *testing.T) {
+	var refrescos []bool
+	connector := nuevoConnectorDePrueba(&refrescos, nil)
+	connector.abrir = func(ctx context.Context, dsn string) (driver.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	_, err := connector.Connect(context.Background())
+
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, []bool{false}, refrescos)
+}
+
+func TestConnector_ErrorCredenciales(t *testing.T) {
+	var refrescos []bool
+	connector := nuevoConnectorDePrueba(&refrescos, errors.New("secreto no encontrado"))
+
+	_, err := connector.Connect(context.Background())
+
+	assert.ErrorContains(t, err, "secreto no encontrado")
+}
+
+func TestEsErrorAutenticacion(t *testing.T) {
+	assert.True(t, esErrorAutenticacion(&pgconn.PgError{Code: "28P01"}))
+	assert.True(t, esErrorAutenticacion(errors.Join(errors.New("ctx"), &pgconn.PgError{Code: "28000"})))
+	assert.False(t, esErrorAutenticacion(&pgconn.PgError{Code: "57P01"}))
+	assert.False(t, esErrorAutenticacion(nil))
+}
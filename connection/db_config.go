@@ -0,0 +1,159 @@
+package connection
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Valores permitidos de sslmode, según la documentación de libpq.
+const (
+	SSLModeDisable    = "disable"
+	SSLModeAllow      = "allow"
+	SSLModePrefer     = "prefer"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// sslModesPermitidos contiene los valores de sslmode aceptados.
+var sslModesPermitidos = map[string]bool{
+	SSLModeDisable:    true,
+	SSLModeAllow:      true,
+	SSLModePrefer:     true,
+	SSLModeRequire:    true,
+	SSLModeVerifyCA:   true,
+	SSLModeVerifyFull: true,
+}
+
+// DBConfig contiene la configuración de conexión a Postgres y del pool de conexiones.
+// Los valores del pool en cero conservan los valores por defecto de database/sql.
+type DBConfig struct {
+	Host string
+	Port string
+	Name string
+
+	// SecretName es el nombre del secreto con el usuario y la contraseña.
+	SecretName string
+	// SecretsCacheTTL es el tiempo que se conserva el secreto en caché; con cero se usa el valor por defecto.
+	SecretsCacheTTL time.Duration
+
+	// SSLMode es el sslmode de libpq; si está vacío se usa disable.
+	SSLMode string
+	// SSLRootCert es el bundle de CA con el que se verifica el certificado del servidor.
+	SSLRootCert string
+	// SSLCert y SSLKey son el certificado y la llave del cliente, se configuran juntos.
+	SSLCert string
+	SSLKey  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Validate verifica la configuración y retorna todos los errores encontrados.
+func (c DBConfig) Validate() error {
+	var errs []error
+
+	sslMode := c.sslMode()
+	if !sslModesPermitidos[sslMode] {
+		errs = append(errs, fmt.Errorf("sslmode %q no permitido", c.SSLMode))
+	}
+	if (sslMode == SSLModeVerifyCA || sslMode == SSLModeVerifyFull) && c.SSLRootCert == "" {
+		errs = append(errs, fmt.Errorf("sslmode %s requiere el certificado raíz (sslrootcert)", sslMode))
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		errs = append(errs, errors.New("el certificado (sslcert) y la llave (sslkey) del cliente deben configurarse juntos"))
+	}
+	for _, certificado := range c.certificados() {
+		if _, err := os.Stat(certificado.archivo); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", certificado.parametro, err))
+		}
+	}
+
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("el número máximo de conexiones no puede ser negativo"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("el máximo de conexiones inactivas (%d) supera el máximo de conexiones abiertas (%d)",
+			c.MaxIdleConns, c.MaxOpenConns))
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("la duración de las conexiones no puede ser negativa"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// DSN construye el Data Source Name con las credenciales indicadas.
+func (c DBConfig) DSN(usuario, password string) string {
+	parametros := []string{
+		"host=" + valorDSN(c.Host),
+		"port=" + valorDSN(c.Port),
+		"user=" + valorDSN(usuario),
+		"password=" + valorDSN(password),
+		"dbname=" + valorDSN(c.Name),
+		"sslmode=" + valorDSN(c.sslMode()),
+	}
+	for _, certificado := range c.certificados() {
+		parametros = append(parametros, certificado.parametro+"="+valorDSN(certificado.archivo))
+	}
+	return strings.Join(parametros, " ")
+}
+
+// aplicarPool configura el pool de conexiones de sqlDB con los valores distintos de cero.
+func (c DBConfig) aplicarPool(sqlDB *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
+// certificado es un archivo de certificado configurado y el parámetro del DSN que lo recibe.
+type certificado struct {
+	parametro string
+	archivo   string
+}
+
+// certificados retorna los archivos de certificado configurados.
+func (c DBConfig) certificados() []certificado {
+	var certificados []certificado
+	for _, cert := range []certificado{
+		{parametro: "sslrootcert", archivo: c.SSLRootCert},
+		{parametro: "sslcert", archivo: c.SSLCert},
+		{parametro: "sslkey", archivo: c.SSLKey},
+	} {
+		if cert.archivo != "" {
+			certificados = append(certificados, cert)
+		}
+	}
+	return certificados
+}
+
+// sslMode retorna el sslmode configurado o disable si no se configuró.
+func (c DBConfig) sslMode() string {
+	if c.SSLMode == "" {
+		return SSLModeDisable
+	}
+	return c.SSLMode
+}
+
+// valorDSN escapa un valor del DSN, agregando comillas si está vacío o contiene espacios o comillas.
+func valorDSN(valor string) string {
+	if valor != "" && !strings.ContainsAny(valor, ` '\`) {
+		return valor
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(valor) + "'"
+}
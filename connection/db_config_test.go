@@ -0,0 +1,91 @@
+package connection
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBConfig_DSN(t *testing.T) {
+	cfg := DBConfig{Host: "db.example.com", Port: "5432", Name: "gmf"}
+	assert.Equal(t,
+		"host=db.example.com port=5432 user=user password='p\\'a ss' dbname=gmf sslmode=disable",
+		cfg.DSN("user", "p'a ss"))
+
+	cfg.SSLMode = SSLModeVerifyFull
+	cfg.SSLRootCert = "/certs/rds-ca.pem"
+	cfg.SSLCert = "/certs/client.crt"
+	cfg.SSLKey = "/certs/client.key"
+	assert.Equal(t,
+		"host=db.example.com port=5432 user=user password=pass dbname=gmf sslmode=verify-full "+
+			"sslrootcert=/certs/rds-ca.pem sslcert=/certs/client.crt sslkey=/certs/client.key",
+		cfg.DSN("user", "pass"))
+}
+
+func TestDBConfig_DSN_Parseable(t *testing.T) {
+	cfg := DBConfig{Host: "localhost", Port: "5432", Name: "gmf", SSLMode: SSLModeRequire}
+
+	parsed, err := pgconn.ParseConfig(cfg.DSN("user", `con 'comillas' y \\`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user", parsed.User)
+	assert.Equal(t, `con 'comillas' y \\`, parsed.Password)
+	assert.NotNil(t, parsed.TLSConfig)
+}
+
+func TestDBConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(ca, []byte("cert"), 0o600))
+
+	assert.NoError(t, DBConfig{}.Validate())
+	assert.NoError(t, DBConfig{SSLMode: SSLModeVerifyFull, SSLRootCert: ca, MaxOpenConns: 10, MaxIdleConns: 10}.Validate())
+
+	casos := map[string]struct {
+		cfg     DBConfig
+		mensaje string
+	}{
+		"sslmode desconocido":        {DBConfig{SSLMode: "on"}, `sslmode "on" no permitido`},
+		"verify-full sin CA":         {DBConfig{SSLMode: SSLModeVerifyFull}, "requiere el certificado raíz"},
+		"certificado sin llave":      {DBConfig{SSLMode: SSLModeRequire, SSLCert: ca}, "deben configurarse juntos"},
+		"CA inexistente":             {DBConfig{SSLMode: SSLModeVerifyCA, SSLRootCert: filepath.Join(dir, "no.pem")}, "sslrootcert"},
+		"conexiones negativas":       {DBConfig{MaxOpenConns: -1}, "no puede ser negativo"},
+		"inactivas superan abiertas": {DBConfig{MaxOpenConns: 5, MaxIdleConns: 10}, "supera el máximo de conexiones abiertas"},
+		"duración negativa":          {DBConfig{ConnMaxLifetime: -time.Second}, "no puede ser negativa"},
+	}
+	for nombre, caso := range casos {
+		t.Run(nombre, func(t *testing.T) {
+			assert.ErrorContains(t, caso.cfg.Validate(), caso.mensaje)
+		})
+	}
+}
+
+func TestDBConfig_AplicarPool(t *testing.T) {
+	var refrescos []bool
+	sqlDB := sql.OpenDB(nuevoConnectorDePrueba(&refrescos, nil))
+	defer sqlDB.Close()
+
+	DBConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute}.aplicarPool(sqlDB)
+
+	assert.Equal(t, 7, sqlDB.Stats().MaxOpenConnections)
+	// Configurar el pool no abre conexiones ni consulta las credenciales
+	assert.Empty(t, refrescos)
+}
+
+func TestDBManager_InitDB_ConfiguracionInvalida(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	dbManager := NewDBManager(
+		WithDBConfig(DBConfig{SSLMode: SSLModeVerifyFull}),
+		WithSecretProvider(mockSecretsManager))
+
+	err := dbManager.InitDB()
+
+	assert.ErrorContains(t, err, "configuración de la base de datos inválida")
+	assert.Nil(t, dbManager.DB)
+	mockSecretsManager.AssertNumberOfCalls(t, "GetSecret", 0)
+}
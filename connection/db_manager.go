@@ -1,13 +1,19 @@
 package connection
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"time"
 
-	"gmf_transmission_response/internal/aws"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/secrets"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -20,41 +26,97 @@ type DBManagerInterface interface {
 	GetDB() *gorm.DB
 }
 
+// errSecretoIncompleto indica que el secreto de la base de datos no tiene las claves requeridas.
+var errSecretoIncompleto = errors.New("el secreto no contiene USERNAME y PASSWORD")
+
+// DSNBuilder construye el Data Source Name de la base de datos con las credenciales del secreto.
+type DSNBuilder func(usuario, password string) string
+
 // DBManager maneja la conexión y migración de la base de datos.
 type DBManager struct {
 	DB *gorm.DB
+
+	config     DBConfig
+	reintentos ReintentoConfig
+	provider   secrets.SecretProvider
+	dsnBuilder DSNBuilder
+	secretos   *secrets.CachedProvider
+	secretName string
+}
+
+// DBManagerOption configura un DBManager.
+type DBManagerOption func(*DBManager)
+
+// WithDBConfig define la configuración de conexión, del secreto y del pool.
+func WithDBConfig(config DBConfig) DBManagerOption {
+	return func(dbm *DBManager) {
+		dbm.config = config
+	}
+}
+
+// WithSecretProvider define el proveedor de las credenciales de la base de datos.
+func WithSecretProvider(provider secrets.SecretProvider) DBManagerOption {
+	return func(dbm *DBManager) {
+		dbm.provider = provider
+	}
+}
+
+// WithDSNBuilder define cómo se construye el DSN. Sin esta opción se usa DBConfig.DSN.
+func WithDSNBuilder(builder DSNBuilder) DBManagerOption {
+	return func(dbm *DBManager) {
+		if builder != nil {
+			dbm.dsnBuilder = builder
+		}
+	}
 }
 
 // NewDBManager crea una nueva instancia de DBManager.
-func NewDBManager() *DBManager {
-	return &DBManager{}
+func NewDBManager(opts ...DBManagerOption) *DBManager {
+	dbm := &DBManager{}
+	for _, opt := range opts {
+		opt(dbm)
+	}
+	return dbm
 }
 
 // InitDB inicializa la conexión a la base de datos y realiza migraciones.
 func (dbm *DBManager) InitDB() error {
-	// Obtener credenciales desde AWS Secrets Manager
-	secretsManager, err := aws.NewSecretsManager()
-	if err != nil {
-		logs.Logger.LogError("Error al inicializar SecretsManager", err, "AWS_INIT")
-		return fmt.Errorf("error inicializando SecretsManager: %w", err)
+	// Validar la configuración antes de obtener las credenciales
+	if err := dbm.config.Validate(); err != nil {
+		logs.Logger.LogError("Configuración de la base de datos inválida", err, "DB_CONFIG")
+		return fmt.Errorf("configuración de la base de datos inválida: %w", err)
+	}
+
+	// Obtener credenciales del proveedor de secretos configurado
+	if dbm.provider == nil {
+		logs.Logger.LogError("No se configuró el proveedor de secretos", nil, "SECRETS_INIT")
+		return errors.New("no se configuró el proveedor de secretos de la base de datos")
 	}
 
-	secretName := os.Getenv("SECRETS_DB")
-	secret, err := secretsManager.GetSecret(secretName)
+	// La obtención del secreto y la apertura de la conexión comparten el plazo de DB_STARTUP_TIMEOUT
+	ctx, cancel := contextoReintentos(dbm.reintentos)
+	defer cancel()
+
+	// Los secretos se guardan en caché para que las conexiones nuevas del pool no consulten el proveedor
+	dbm.secretos = secrets.NewCachedProvider(dbm.provider, dbm.config.SecretsCacheTTL)
+	dbm.secretName = dbm.config.SecretName
+	err := reintentar(ctx, dbm.reintentos, "la obtención del secreto", "SECRETS_INIT", func(ctx context.Context) error {
+		_, _, err := dbm.credenciales(ctx, false)
+		// Un secreto inexistente o incompleto no se corrige reintentando
+		if errors.Is(err, errSecretoIncompleto) || errors.Is(err, secrets.ErrSecretoNoEncontrado) {
+			return permanente(err)
+		}
+		return err
+	})
 	if err != nil {
-		logs.Logger.LogError("Error al obtener el secreto", err, "AWS_SECRETS")
+		logs.Logger.LogError("Error al obtener el secreto", err, "SECRETS_INIT")
 		return fmt.Errorf("error obteniendo el secreto: %w", err)
 	}
 
-	// Construir el Data Source Name (DSN)
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		secret["USERNAME"],
-		secret["PASSWORD"],
-		os.Getenv("DB_NAME"),
-	)
+	dsnBuilder := dbm.dsnBuilder
+	if dsnBuilder == nil {
+		dsnBuilder = dbm.config.DSN
+	}
 
 	// Configurar el logger de GORM
 	newLogger := logger.New(
@@ -67,20 +129,133 @@ func (dbm *DBManager) InitDB() error {
 		},
 	)
 
-	// Abrir la conexión a la base de datos usando GORM
-	dbm.DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger,
-	})
+	// Abrir la conexión a la base de datos usando GORM, reintentando mientras Postgres no esté disponible
+	err = reintentar(ctx, dbm.reintentos, "la conexión a la base de datos", "DB_CONNECTION",
+		func(ctx context.Context) error {
+			// Cada conexión nueva del pool usa las credenciales vigentes del secreto
+			sqlDB := sql.OpenDB(&connectorConCredenciales{
+				credenciales: dbm.credenciales,
+				dsn:          dsnBuilder,
+				abrir:        abrirConexion,
+			})
+			dbm.config.aplicarPool(sqlDB)
+
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+				Logger:               newLogger,
+				DisableAutomaticPing: true,
+			})
+			if err == nil {
+				// La primera conexión se abre dentro del plazo compartido con la obtención del secreto
+				err = sqlDB.PingContext(ctx)
+			}
+			if err != nil {
+				sqlDB.Close()
+				var parseErr *pgconn.ParseConfigError
+				if errors.As(err, &parseErr) {
+					return permanente(err)
+				}
+				return err
+			}
+			dbm.DB = db
+			return nil
+		})
 	if err != nil {
 		logs.Logger.LogError("Error al abrir la conexión a la base de datos", err, "DB_CONNECTION")
 		return fmt.Errorf("error al abrir la conexión a la base de datos: %w", err)
 	}
 
+	// Las tablas del servicio se crean con las migraciones de migrations/, al iniciar solo se verifican
+	if err := verificarTablas(dbm.DB); err != nil {
+		logs.Logger.LogError("Faltan tablas del servicio en la base de datos", err, "DB_MIGRATION")
+		return fmt.Errorf("error al verificar las tablas del servicio: %w", err)
+	}
+
+	// CGD_ARCHIVO_ESTADO no es propia del servicio: sus columnas se agregan con las migraciones de migrations/
+	if err := verificarHistorialEstados(dbm.DB); err != nil {
+		logs.Logger.LogError("La tabla CGD_ARCHIVO_ESTADO no tiene las columnas requeridas", err, "DB_MIGRATION")
+		return fmt.Errorf("error al verificar la tabla cgd_archivo_estados: %w", err)
+	}
+
 	logs.Logger.LogInfo("Conexión a la base de datos establecida correctamente 🐘", "DB_CONNECTION")
 
 	return nil
 }
 
+// credenciales obtiene el usuario y la contraseña del secreto de la base de datos.
+func (dbm *DBManager) credenciales(ctx context.Context, refrescar bool) (string, string, error) {
+	obtener := dbm.secretos.GetSecret
+	if refrescar {
+		obtener = dbm.secretos.Refresh
+	}
+	secret, err := obtener(ctx, dbm.secretName)
+	if err != nil {
+		return "", "", err
+	}
+	if secret["USERNAME"] == "" || secret["PASSWORD"] == "" {
+		return "", "", fmt.Errorf("%w: %s", errSecretoIncompleto, dbm.secretName)
+	}
+	return secret["USERNAME"], secret["PASSWORD"], nil
+}
+
+// tablasServicio son las tablas propias del servicio que crean las migraciones de migrations/.
+var tablasServicio = []string{models.CGDIdempotencia{}.TableName(), models.CGDJob{}.TableName()}
+
+// verificarTablas verifica que existan las tablas propias del servicio.
+func verificarTablas(db *gorm.DB) error {
+	var existentes []string
+	err := db.Raw("SELECT table_name FROM information_schema.tables "+
+		"WHERE table_schema = CURRENT_SCHEMA() AND table_name IN ?", tablasServicio).Scan(&existentes).Error
+	if err != nil {
+		return err
+	}
+
+	if faltan := faltantes(tablasServicio, existentes); len(faltan) > 0 {
+		return fmt.Errorf("faltan las tablas %v, aplique las migraciones de migrations/", faltan)
+	}
+	return nil
+}
+
+// columnasHistorialEstados son las columnas de CGD_ARCHIVO_ESTADO que agrega la migración
+// 000001_respuesta_gateway_historial_estados.
+var columnasHistorialEstados = []string{"gaw_rta_trans_estado", "gaw_rta_trans_codigo"}
+
+// verificarHistorialEstados verifica que CGD_ARCHIVO_ESTADO tenga las columnas con la respuesta del gateway.
+// La tabla es compartida con otros servicios, por lo que no se modifica al iniciar.
+func verificarHistorialEstados(db *gorm.DB) error {
+	var existentes []string
+	err := db.Raw("SELECT column_name FROM information_schema.columns "+
+		"WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name IN ?",
+		models.CGDArchivoEstados{}.TableName(), columnasHistorialEstados).Scan(&existentes).Error
+	if err != nil {
+		return err
+	}
+
+	if faltan := faltantes(columnasHistorialEstados, existentes); len(faltan) > 0 {
+		return fmt.Errorf("faltan las columnas %v, aplique las migraciones de migrations/", faltan)
+	}
+	return nil
+}
+
+// faltantes retorna los elementos de requeridos que no están en existentes.
+func faltantes(requeridos, existentes []string) []string {
+	var resultado []string
+	for _, requerido := range requeridos {
+		if !slices.Contains(existentes, requerido) {
+			resultado = append(resultado, requerido)
+		}
+	}
+	return resultado
+}
+
+// Secretos retorna la caché de secretos de la base de datos, por ejemplo para verificar el acceso al proveedor
+// sin consultarlo en cada verificación. Es nil hasta que se llama a InitDB.
+func (dbm *DBManager) Secretos() secrets.SecretProvider {
+	if dbm.secretos == nil {
+		return nil
+	}
+	return dbm.secretos
+}
+
 // GetDB obtiene la conexión a la base de datos.
 func (dbm *DBManager) GetDB() *gorm.DB {
 	return dbm.DB
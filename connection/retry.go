@@ -0,0 +1,116 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"gmf_transmission_response/internal/logs"
+)
+
+// Valores por defecto de los reintentos al inicializar la base de datos.
+const (
+	DefaultStartupTimeout = 60 * time.Second
+	DefaultBackoffInicial = 500 * time.Millisecond
+	DefaultBackoffMaximo  = 10 * time.Second
+)
+
+// ReintentoConfig contiene la configuración de los reintentos al obtener el secreto y abrir la conexión.
+type ReintentoConfig struct {
+	// Timeout es el tiempo máximo para obtener el secreto y abrir la conexión, compartido por ambas operaciones;
+	// con cero se hace un solo intento de cada una.
+	Timeout time.Duration
+	// BackoffInicial es la espera antes del segundo intento, se duplica en cada intento hasta BackoffMaximo.
+	// Con cero se usan DefaultBackoffInicial y DefaultBackoffMaximo.
+	BackoffInicial time.Duration
+	BackoffMaximo  time.Duration
+}
+
+// WithReintentos habilita los reintentos con backoff exponencial al inicializar la base de datos.
+// Sin esta opción se hace un solo intento.
+func WithReintentos(config ReintentoConfig) DBManagerOption {
+	return func(dbm *DBManager) {
+		dbm.reintentos = config
+	}
+}
+
+// errorPermanente marca un error que no se corrige reintentando, por ejemplo un DSN inválido.
+type errorPermanente struct {
+	err error
+}
+
+func (e *errorPermanente) Error() string { return e.err.Error() }
+func (e *errorPermanente) Unwrap() error { return e.err }
+
+// permanente marca err para que no se reintente.
+func permanente(err error) error {
+	return &errorPermanente{err: err}
+}
+
+// contextoReintentos retorna el contexto cuyo plazo comparten todas las operaciones que se reintentan al
+// inicializar la base de datos. Sin Timeout el contexto no tiene plazo y cada operación se intenta una vez.
+func contextoReintentos(config ReintentoConfig) (context.Context, context.CancelFunc) {
+	if config.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), config.Timeout)
+}
+
+// reintentar ejecuta fn hasta que no retorne error, retorne un error permanente o venza el plazo de ctx,
+// que se pasa a fn para que cada intento tampoco lo supere. Sin plazo se hace un solo intento.
+// Entre intentos espera un backoff exponencial con jitter y registra cada intento fallido.
+func reintentar(ctx context.Context, config ReintentoConfig, operacion, etiqueta string,
+	fn func(ctx context.Context) error) error {
+	espera := valorOPorDefecto(config.BackoffInicial, DefaultBackoffInicial)
+	maximo := valorOPorDefecto(config.BackoffMaximo, DefaultBackoffMaximo)
+
+	for intento := 1; ; intento++ {
+		err := fn(ctx)
+		if err == nil {
+			if intento > 1 {
+				logs.Logger.LogInfo(fmt.Sprintf("Intento %d de %s exitoso", intento, operacion), etiqueta)
+			}
+			return nil
+		}
+
+		var errPermanente *errorPermanente
+		if errors.As(err, &errPermanente) {
+			return errPermanente.err
+		}
+
+		limite, conPlazo := ctx.Deadline()
+		if !conPlazo {
+			return err
+		}
+		restante := time.Until(limite)
+		if restante <= 0 || ctx.Err() != nil {
+			return fmt.Errorf("%s falló después de %d intentos: %w", operacion, intento, err)
+		}
+
+		pausa := min(conJitter(espera), restante)
+		logs.Logger.LogWarn(fmt.Sprintf("Intento %d de %s fallido, se reintenta en %s",
+			intento, operacion, pausa.Round(time.Millisecond)), etiqueta, "detalle", err.Error())
+		select {
+		case <-time.After(pausa):
+		case <-ctx.Done():
+		}
+		espera = min(espera*2, maximo)
+	}
+}
+
+// conJitter retorna una espera aleatoria entre la mitad y el total de espera, para que varias
+// réplicas que inician al mismo tiempo no reintenten a la vez.
+func conJitter(espera time.Duration) time.Duration {
+	mitad := espera / 2
+	return mitad + rand.N(espera-mitad+1)
+}
+
+// valorOPorDefecto retorna valor si es positivo o el valor por defecto en otro caso.
+func valorOPorDefecto(valor, porDefecto time.Duration) time.Duration {
+	if valor > 0 {
+		return valor
+	}
+	return porDefecto
+}
@@ -0,0 +1,186 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/secrets"
+)
+
+// reintentosDePrueba usa esperas cortas para que las pruebas no tarden
+var reintentosDePrueba = ReintentoConfig{
+	Timeout:        200 * time.Millisecond,
+	BackoffInicial: time.Millisecond,
+	BackoffMaximo:  4 * time.Millisecond,
+}
+
+// reintentarDePrueba ejecuta reintentar con el plazo de la configuración indicada
+func reintentarDePrueba(config ReintentoConfig, fn func(ctx context.Context) error) error {
+	ctx, cancel := contextoReintentos(config)
+	defer cancel()
+	return reintentar(ctx, config, "la prueba", "TEST", fn)
+}
+
+func TestReintentar_ExitoTrasFallos(t *testing.T) {
+	intentos := 0
+	err := reintentarDePrueba(reintentosDePrueba, func(context.Context) error {
+		intentos++
+		if intentos < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, intentos)
+}
+
+func TestReintentar_ErrorPermanente(t *testing.T) {
+	intentos := 0
+	errDSN := errors.New("dsn inválido")
+	err := reintentarDePrueba(reintentosDePrueba, func(context.Context) error {
+		intentos++
+		return permanente(errDSN)
+	})
+
+	assert.Same(t, errDSN, err)
+	assert.Equal(t, 1, intentos)
+}
+
+func TestReintentar_TimeoutAgotado(t *testing.T) {
+	config := reintentosDePrueba
+	config.Timeout = 20 * time.Millisecond
+	intentos := 0
+	inicio := time.Now()
+	err := reintentarDePrueba(config, func(context.Context) error {
+		intentos++
+		return errors.New("connection refused")
+	})
+
+	assert.ErrorContains(t, err, "la prueba falló después de")
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Greater(t, intentos, 2)
+	assert.Less(t, time.Since(inicio), 200*time.Millisecond)
+}
+
+func TestReintentar_SinTimeoutUnSoloIntento(t *testing.T) {
+	intentos := 0
+	errConexion := errors.New("connection refused")
+	err := reintentarDePrueba(ReintentoConfig{}, func(context.Context) error {
+		intentos++
+		return errConexion
+	})
+
+	assert.Same(t, errConexion, err)
+	assert.Equal(t, 1, intentos)
+}
+
+func TestReintentar_IntentoRecibeElPlazo(t *testing.T) {
+	config := reintentosDePrueba
+	config.Timeout = 20 * time.Millisecond
+	inicio := time.Now()
+	err := reintentarDePrueba(config, func(ctx context.Context) error {
+		// Un intento que bloquea termina al vencer el plazo compartido
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "la prueba falló después de 1 intentos")
+	assert.Less(t, time.Since(inicio), 200*time.Millisecond)
+}
+
+func TestConJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pausa := conJitter(100 * time.Millisecond)
+		assert.GreaterOrEqual(t, pausa, 50*time.Millisecond)
+		assert.LessOrEqual(t, pausa, 100*time.Millisecond)
+	}
+}
+
+func TestDBManager_InitDB_ReintentaSecreto(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(nil, errors.New("LocalStack no disponible")).Twice()
+	mockSecretsManager.On("GetSecret", "test_secret").Return(
+		map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, nil)
+
+	dsnConstruidos := 0
+	dbManager := NewDBManager(
+		WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager),
+		WithReintentos(reintentosDePrueba),
+		WithDSNBuilder(func(usuario, password string) string {
+			dsnConstruidos++
+			return "host=localhost port=no-es-un-puerto"
+		}))
+	err := dbManager.InitDB()
+
+	// El secreto se obtiene en el tercer intento y el DSN inválido no se reintenta
+	assert.ErrorContains(t, err, "error al abrir la conexión a la base de datos")
+	mockSecretsManager.AssertNumberOfCalls(t, "GetSecret", 3)
+	assert.Equal(t, 1, dsnConstruidos)
+}
+
+func TestDBManager_InitDB_ReintentaConexion(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(
+		map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, nil)
+
+	config := reintentosDePrueba
+	config.Timeout = 30 * time.Millisecond
+	intentos := 0
+	dbManager := NewDBManager(
+		WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager),
+		WithReintentos(config),
+		WithDSNBuilder(func(usuario, password string) string {
+			intentos++
+			// Ningún servidor escucha en el puerto 1 de loopback, la conexión se rechaza de inmediato
+			return "host=127.0.0.1 port=1 connect_timeout=1"
+		}))
+	err := dbManager.InitDB()
+
+	assert.ErrorContains(t, err, "la conexión a la base de datos falló después de")
+	assert.Greater(t, intentos, 1)
+	assert.Nil(t, dbManager.DB)
+}
+
+func TestDBManager_InitDB_SecretoNoEncontradoNoSeReintenta(t *testing.T) {
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(nil, secrets.ErrSecretoNoEncontrado)
+
+	dbManager := NewDBManager(
+		WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager),
+		WithReintentos(reintentosDePrueba))
+	err := dbManager.InitDB()
+
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+	mockSecretsManager.AssertNumberOfCalls(t, "GetSecret", 1)
+}
+
+func TestDBManager_InitDB_PlazoCompartido(t *testing.T) {
+	// El secreto tarda 70 ms del plazo de 100 ms, por lo que la conexión solo dispone del resto
+	mockSecretsManager := new(MockAWSSecretsManager)
+	mockSecretsManager.On("GetSecret", "test_secret").Return(
+		map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, nil).After(70 * time.Millisecond)
+
+	config := reintentosDePrueba
+	config.Timeout = 100 * time.Millisecond
+	inicio := time.Now()
+	dbManager := NewDBManager(
+		WithDBConfig(DBConfig{SecretName: "test_secret"}),
+		WithSecretProvider(mockSecretsManager),
+		WithReintentos(config),
+		WithDSNBuilder(func(usuario, password string) string {
+			return "host=127.0.0.1 port=1 connect_timeout=1"
+		}))
+	err := dbManager.InitDB()
+
+	assert.ErrorContains(t, err, "la conexión a la base de datos falló después de")
+	// Con un plazo por operación la inicialización tardaría al menos 170 ms
+	assert.Less(t, time.Since(inicio), 150*time.Millisecond)
+}
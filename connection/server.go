@@ -1,32 +1,160 @@
 package connection
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"gmf_transmission_response/internal/logs"
 )
 
-// StartServer inicia el servidor HTTP en el host y puerto proporcionados.
-func StartServer(host string, portStr string) {
+// Valores por defecto de los tiempos de espera del servidor HTTP.
+const (
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 60 * time.Second
+	DefaultIdleTimeout     = 120 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// ServerConfig contiene la configuración del servidor HTTP.
+type ServerConfig struct {
+	Host            string
+	Port            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Server envuelve el servidor HTTP y cierra la base de datos al detenerse.
+type Server struct {
+	httpServer      *http.Server
+	dbManager       DBManagerInterface
+	shutdownTimeout time.Duration
+	tareas          []func(ctx context.Context) error
+}
+
+// ServerOption configura un Server.
+type ServerOption func(*Server)
+
+// WithTareasEnSegundoPlano registra una función que espera a que terminen las tareas en segundo plano
+// que usan la base de datos. Shutdown la llama antes de cerrar la conexión.
+func WithTareasEnSegundoPlano(esperar func(ctx context.Context) error) ServerOption {
+	return func(s *Server) {
+		if esperar != nil {
+			s.tareas = append(s.tareas, esperar)
+		}
+	}
+}
+
+// NewServer crea un servidor HTTP con la configuración indicada.
+// Si handler es nil se usa http.DefaultServeMux, donde se registran las rutas de la aplicación.
+func NewServer(cfg ServerConfig, handler http.Handler, dbManager DBManagerInterface,
+	opts ...ServerOption) (*Server, error) {
 	// Convertir el puerto a int
-	port, err := strconv.Atoi(portStr)
+	port, err := strconv.Atoi(cfg.Port)
 	if err != nil {
 		logs.Logger.LogError("Error al convertir el puerto", err, "SERVER_START")
-		log.Fatalf("Error al convertir el puerto: %v", err)
+		return nil, fmt.Errorf("error al convertir el puerto: %w", err)
+	}
+
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
 	}
 
-	address := fmt.Sprintf("%s:%d", host, port)
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+			Handler:      handler,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		},
+		dbManager:       dbManager,
+		shutdownTimeout: shutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
 
+// Start inicia el servidor HTTP y bloquea hasta que el servidor se detenga.
+// Retorna nil cuando el servidor se detiene mediante Shutdown.
+func (s *Server) Start() error {
 	// Mostrar el mensaje de que el servidor está iniciando
 	logs.Logger.LogInfo(fmt.Sprintf(
-		"Servidor iniciado exitosamente en http://%s 🚀", address), "SERVER_START")
+		"Servidor iniciado exitosamente en http://%s 🚀", s.httpServer.Addr), "SERVER_START")
 
-	// Iniciar el servidor HTTP
-	if err := http.ListenAndServe(address, nil); err != nil {
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logs.Logger.LogError("Error al iniciar el servidor", err, "SERVER_START")
-		log.Fatalf("Error al iniciar el servidor: %v", err)
+		return fmt.Errorf("error al iniciar el servidor: %w", err)
+	}
+	return nil
+}
+
+// Shutdown deja de aceptar solicitudes, espera a que terminen las solicitudes en curso y las tareas en
+// segundo plano hasta que expire ctx y luego cierra la conexión a la base de datos.
+func (s *Server) Shutdown(ctx context.Context) error {
+	logs.Logger.LogInfo("Deteniendo el servidor, esperando las solicitudes en curso ⏳", "SERVER_SHUTDOWN")
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		logs.Logger.LogError("No todas las solicitudes terminaron antes del tiempo límite", err, "SERVER_SHUTDOWN")
+	} else {
+		logs.Logger.LogInfo("Servidor detenido correctamente 🛑", "SERVER_SHUTDOWN")
+	}
+
+	for _, esperar := range s.tareas {
+		if errTareas := esperar(ctx); errTareas != nil {
+			logs.Logger.LogError("No todas las tareas en segundo plano terminaron antes del tiempo límite",
+				errTareas, "SERVER_SHUTDOWN")
+			err = errors.Join(err, errTareas)
+		}
 	}
+
+	if s.dbManager != nil {
+		s.dbManager.CloseDB()
+	}
+
+	return err
+}
+
+// Run inicia el servidor y lo detiene ordenadamente al recibir SIGTERM o SIGINT.
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	return s.run(ctx)
+}
+
+// run inicia el servidor y lo detiene cuando ctx es cancelado.
+func (s *Server) run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+
+	select {
+	case err := <-errCh:
+		// El servidor no pudo iniciar, se liberan los recursos antes de retornar
+		if s.dbManager != nil {
+			s.dbManager.CloseDB()
+		}
+		return err
+	case <-ctx.Done():
+		logs.Logger.LogInfo("Señal de terminación recibida", "SERVER_SHUTDOWN")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	return s.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,190 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockDBManager es un mock de DBManagerInterface.
+type MockDBManager struct {
+	mock.Mock
+}
+
+func (m *MockDBManager) InitDB() error {
+	return m.Called().Error(0)
+}
+
+func (m *MockDBManager) CloseDB() {
+	m.Called()
+}
+
+func (m *MockDBManager) GetDB() *gorm.DB {
+	return nil
+}
+
+// freePort obtiene un puerto libre para iniciar el servidor de prueba.
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// waitForServer espera a que el servidor acepte conexiones.
+func waitForServer(t *testing.T, address string) {
+	for i := 0; i < 50; i++ {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("el servidor no inició en %s", address)
+}
+
+func TestNewServer_InvalidPort(t *testing.T) {
+	server, err := NewServer(ServerConfig{Host: "localhost", Port: "abc"}, nil, nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, server)
+}
+
+func TestNewServer_Timeouts(t *testing.T) {
+	server, err := NewServer(ServerConfig{
+		Host:         "localhost",
+		Port:         "8080",
+		ReadTimeout:  time.Second,
+		WriteTimeout: 2 * time.Second,
+		IdleTimeout:  3 * time.Second,
+	}, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:8080", server.httpServer.Addr)
+	assert.Equal(t, time.Second, server.httpServer.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.httpServer.WriteTimeout)
+	assert.Equal(t, 3*time.Second, server.httpServer.IdleTimeout)
+	assert.Equal(t, DefaultShutdownTimeout, server.shutdownTimeout)
+}
+
+func TestServer_Run_DrainsInFlightRequests(t *testing.T) {
+	mockDB := new(MockDBManager)
+	mockDB.On("CloseDB").Return()
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	port := freePort(t)
+	server, err := NewServer(ServerConfig{Host: "127.0.0.1", Port: port, ShutdownTimeout: 5 * time.Second}, handler, mockDB)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- server.run(ctx)
+	}()
+	waitForServer(t, "127.0.0.1:"+port)
+
+	// Enviar una solicitud lenta y simular la señal de terminación mientras está en curso
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://127.0.0.1:" + port + "/transmission")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	// La solicitud en curso debe completarse y luego cerrarse la base de datos
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.NoError(t, <-runErr)
+	mockDB.AssertCalled(t, "CloseDB")
+}
+
+func TestServer_Shutdown_Timeout(t *testing.T) {
+	mockDB := new(MockDBManager)
+	mockDB.On("CloseDB").Return()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	defer close(release)
+
+	port := freePort(t)
+	server, err := NewServer(ServerConfig{Host: "127.0.0.1", Port: port}, handler, mockDB)
+	assert.NoError(t, err)
+
+	go server.Start()
+	waitForServer(t, "127.0.0.1:"+port)
+
+	go http.Get("http://127.0.0.1:" + port + "/transmission")
+	<-started
+
+	// La solicitud no termina antes del tiempo límite, pero la base de datos se cierra igualmente
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = server.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	mockDB.AssertCalled(t, "CloseDB")
+}
+
+func TestServer_Shutdown_EsperaTareasAntesDeCerrarDB(t *testing.T) {
+	var orden []string
+	mockDB := new(MockDBManager)
+	mockDB.On("CloseDB").Run(func(mock.Arguments) { orden = append(orden, "CloseDB") }).Return()
+
+	tareasErr := errors.New("jobs en curso")
+	server, err := NewServer(ServerConfig{Host: "127.0.0.1", Port: freePort(t)}, http.NotFoundHandler(), mockDB,
+		WithTareasEnSegundoPlano(func(ctx context.Context) error {
+			assert.NoError(t, ctx.Err())
+			orden = append(orden, "tareas")
+			return tareasErr
+		}))
+	assert.NoError(t, err)
+
+	err = server.Shutdown(context.Background())
+
+	// El error de las tareas se retorna, pero la base de datos se cierra después de esperarlas
+	assert.ErrorIs(t, err, tareasErr)
+	assert.Equal(t, []string{"tareas", "CloseDB"}, orden)
+}
+
+func TestServer_Run_StartError(t *testing.T) {
+	mockDB := new(MockDBManager)
+	mockDB.On("CloseDB").Return()
+
+	// Ocupar el puerto para que el servidor no pueda iniciar
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	server, err := NewServer(ServerConfig{Host: "127.0.0.1", Port: port}, http.NotFoundHandler(), mockDB)
+	assert.NoError(t, err)
+
+	err = server.run(context.Background())
+
+	assert.Error(t, err)
+	mockDB.AssertCalled(t, "CloseDB")
+}
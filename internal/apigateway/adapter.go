@@ -0,0 +1,161 @@
+package apigateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"gmf_transmission_response/internal/handler"
+)
+
+// HeaderRequestID es el encabezado con el que se propaga el ID de la solicitud de API Gateway al handler.
+const HeaderRequestID = handler.HeaderRequestID
+
+// Adapter convierte los eventos de API Gateway en solicitudes HTTP para un http.Handler.
+type Adapter struct {
+	handler http.Handler
+}
+
+// NewAdapter crea un adaptador para el handler indicado.
+func NewAdapter(handler http.Handler) *Adapter {
+	return &Adapter{handler: handler}
+}
+
+// Invocar ejecuta el handler con la solicitud del evento y construye la respuesta proxy con su resultado.
+// Solo retorna error si el evento no puede convertirse en una solicitud HTTP. Es el handler que recibe
+// lambda.Start.
+func (a *Adapter) Invocar(
+	ctx context.Context, evento events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	r, err := NuevaSolicitud(ctx, evento)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	w := newResponseWriter()
+	a.handler.ServeHTTP(w, r)
+	return w.proxyResponse(), nil
+}
+
+// NuevaSolicitud construye la solicitud HTTP equivalente al evento de API Gateway.
+func NuevaSolicitud(ctx context.Context, evento events.APIGatewayProxyRequest) (*http.Request, error) {
+	body := []byte(evento.Body)
+	if evento.IsBase64Encoded {
+		decodificado, err := base64.StdEncoding.DecodeString(evento.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error al decodificar el cuerpo en base64: %w", err)
+		}
+		body = decodificado
+	}
+
+	method := evento.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+	target := &url.URL{Path: evento.Path, RawQuery: consulta(evento).Encode()}
+
+	r, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error al construir la solicitud: %w", err)
+	}
+
+	// MultiValueHeaders contiene todos los valores, Headers solo el último de cada encabezado
+	for nombre, valores := range evento.MultiValueHeaders {
+		for _, valor := range valores {
+			r.Header.Add(nombre, valor)
+		}
+	}
+	for nombre, valor := range evento.Headers {
+		if r.Header.Get(nombre) == "" {
+			r.Header.Set(nombre, valor)
+		}
+	}
+	if evento.RequestContext.RequestID != "" && r.Header.Get(HeaderRequestID) == "" {
+		r.Header.Set(HeaderRequestID, evento.RequestContext.RequestID)
+	}
+
+	for nombre, valor := range evento.PathParameters {
+		r.SetPathValue(nombre, valor)
+	}
+	r.Host = r.Header.Get("Host")
+	if evento.RequestContext.Identity.SourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(evento.RequestContext.Identity.SourceIP, "0")
+	}
+	return r, nil
+}
+
+// consulta combina los parámetros de consulta simples y de múltiples valores del evento.
+func consulta(evento events.APIGatewayProxyRequest) url.Values {
+	valores := url.Values{}
+	for nombre, lista := range evento.MultiValueQueryStringParameters {
+		for _, valor := range lista {
+			valores.Add(nombre, valor)
+		}
+	}
+	for nombre, valor := range evento.QueryStringParameters {
+		if !valores.Has(nombre) {
+			valores.Set(nombre, valor)
+		}
+	}
+	return valores
+}
+
+// responseWriter acumula la respuesta del handler para construir la respuesta proxy.
+type responseWriter struct {
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: http.Header{}}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// proxyResponse construye la respuesta proxy. Los cuerpos que no son texto UTF-8 se codifican en base64.
+func (w *responseWriter) proxyResponse() events.APIGatewayProxyResponse {
+	statusCode := w.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode:        statusCode,
+		Headers:           make(map[string]string, len(w.header)),
+		MultiValueHeaders: make(map[string][]string, len(w.header)),
+	}
+	for nombre, valores := range w.header {
+		response.Headers[nombre] = strings.Join(valores, ",")
+		response.MultiValueHeaders[nombre] = valores
+	}
+
+	if utf8.Valid(w.body.Bytes()) {
+		response.Body = w.body.String()
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		response.IsBase64Encoded = true
+	}
+	return response
+}
@@ -0,0 +1,170 @@
+package apigateway_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/apigateway"
+	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+)
+
+// MockArchivoService simula el servicio, solo implementa ProcesarTransmisiones
+type MockArchivoService struct {
+	service.ArchivoServiceInterface
+	Recibidos []models.TransmittedFile
+}
+
+func (m *MockArchivoService) ProcesarTransmisiones(
+	_ context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult {
+	m.Recibidos = append(m.Recibidos, transmittedFiles...)
+	resultados := make([]models.FileResult, 0, len(transmittedFiles))
+	for _, transmittedFile := range transmittedFiles {
+		var err error
+		if transmittedFile.TransmissionResult.Status == models.StatusError {
+			err = errors.New("mock error")
+		}
+		resultados = append(resultados, service.NuevoFileResult(transmittedFile.FileName, "ENVIADO", err))
+	}
+	return resultados
+}
+
+func nuevoAdapter(mockService *MockArchivoService) *apigateway.Adapter {
+	archivoHandler := handler.NewArchivoHandler(mockService)
+	return apigateway.NewAdapter(http.HandlerFunc(archivoHandler.HandleTransmisionResponses))
+}
+
+func leerEvento(t *testing.T, archivo string) events.APIGatewayProxyRequest {
+	data, err := os.ReadFile("testdata/" + archivo)
+	assert.NoError(t, err)
+	var evento events.APIGatewayProxyRequest
+	assert.NoError(t, json.Unmarshal(data, &evento))
+	return evento
+}
+
+func TestInvocar_Transmision(t *testing.T) {
+	mockService := &MockArchivoService{}
+	adapter := nuevoAdapter(mockService)
+
+	response, err := adapter.Invocar(context.Background(), leerEvento(t, "transmission.json"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, response.StatusCode)
+	assert.False(t, response.IsBase64Encoded)
+	assert.Len(t, mockService.Recibidos, 2)
+
+	var body models.Response
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, 2, body.TotalFiles)
+	assert.Equal(t, 1, body.ErrorCount)
+}
+
+func TestInvocar_CuerpoBase64(t *testing.T) {
+	mockService := &MockArchivoService{}
+	adapter := nuevoAdapter(mockService)
+
+	response, err := adapter.Invocar(context.Background(), leerEvento(t, "transmission_base64.json"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []models.TransmittedFile{{
+		FileName:           "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{Status: "SUCCESSFUL", Code: "0000"},
+	}}, mockService.Recibidos)
+}
+
+func TestInvocar_SolicitudInvalida(t *testing.T) {
+	mockService := &MockArchivoService{}
+	adapter := nuevoAdapter(mockService)
+
+	response, err := adapter.Invocar(context.Background(), leerEvento(t, "transmission_invalid.json"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.Empty(t, mockService.Recibidos)
+
+	var body models.ValidationResponse
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Len(t, body.Violations, 2)
+}
+
+func TestInvocar_Base64Invalido(t *testing.T) {
+	adapter := nuevoAdapter(&MockArchivoService{})
+
+	_, err := adapter.Invocar(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      http.MethodPost,
+		Path:            "/transmission",
+		Body:            "no es base64",
+		IsBase64Encoded: true,
+	})
+
+	assert.ErrorContains(t, err, "base64")
+}
+
+func TestNuevaSolicitud(t *testing.T) {
+	r, err := apigateway.NuevaSolicitud(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:                      http.MethodGet,
+		Path:                            "/files/TUTGMF0001000120240312-0001/history",
+		Headers:                         map[string]string{"Prefer": "respond-async"},
+		MultiValueHeaders:               map[string][]string{"Accept": {"application/json", "text/plain"}},
+		QueryStringParameters:           map[string]string{"page": "2"},
+		MultiValueQueryStringParameters: map[string][]string{"from": {"2024-03-01"}},
+		PathParameters:                  map[string]string{"acgNombreArchivo": "TUTGMF0001000120240312-0001"},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "req-1",
+			Identity:  events.APIGatewayRequestIdentity{SourceIP: "10.0.0.1"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, r.Method)
+	assert.Equal(t, "/files/TUTGMF0001000120240312-0001/history", r.URL.Path)
+	assert.Equal(t, "2", r.URL.Query().Get("page"))
+	assert.Equal(t, "2024-03-01", r.URL.Query().Get("from"))
+	assert.Equal(t, "respond-async", r.Header.Get("Prefer"))
+	assert.Equal(t, []string{"application/json", "text/plain"}, r.Header.Values("Accept"))
+	assert.Equal(t, "req-1", r.Header.Get(apigateway.HeaderRequestID))
+	assert.Equal(t, "TUTGMF0001000120240312-0001", r.PathValue("acgNombreArchivo"))
+	assert.Equal(t, "10.0.0.1:0", r.RemoteAddr)
+}
+
+func TestEjecutarLocal(t *testing.T) {
+	// Se concatenan los eventos de ejemplo igual que al ejecutar cat testdata/*.json | go run ./cmd/lambda
+	var eventos bytes.Buffer
+	for _, archivo := range []string{"transmission.json", "transmission_base64.json", "transmission_invalid.json"} {
+		data, err := os.ReadFile("testdata/" + archivo)
+		assert.NoError(t, err)
+		eventos.Write(data)
+	}
+
+	var salida bytes.Buffer
+	err := apigateway.EjecutarLocal(context.Background(), &eventos, &salida, nuevoAdapter(&MockArchivoService{}))
+	assert.NoError(t, err)
+
+	var codigos []int
+	decoder := json.NewDecoder(&salida)
+	for decoder.More() {
+		var response events.APIGatewayProxyResponse
+		assert.NoError(t, decoder.Decode(&response))
+		codigos = append(codigos, response.StatusCode)
+	}
+	assert.Equal(t, []int{http.StatusPartialContent, http.StatusOK, http.StatusBadRequest}, codigos)
+}
+
+func TestEjecutarLocal_EventoInvalido(t *testing.T) {
+	var salida bytes.Buffer
+	err := apigateway.EjecutarLocal(context.Background(), bytes.NewBufferString("{no es json"), &salida,
+		nuevoAdapter(&MockArchivoService{}))
+
+	assert.ErrorContains(t, err, "error al leer el evento")
+	assert.Empty(t, salida.String())
+}
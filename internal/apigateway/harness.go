@@ -0,0 +1,42 @@
+package apigateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// EnvRuntimeAPI es la variable de entorno que Lambda define con la dirección del Runtime API. Sin ella el
+// entrypoint de Lambda procesa los eventos con EjecutarLocal.
+const EnvRuntimeAPI = "AWS_LAMBDA_RUNTIME_API"
+
+// EjecutarLocal lee de r uno o varios eventos de API Gateway en JSON, los procesa con el adaptador
+// y escribe en w la respuesta proxy de cada uno. Permite probar el entrypoint de Lambda sin AWS,
+// por ejemplo: go run ./cmd/lambda < internal/apigateway/testdata/transmission.json
+func EjecutarLocal(ctx context.Context, r io.Reader, w io.Writer, adapter *Adapter) error {
+	decoder := json.NewDecoder(r)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	for {
+		var evento events.APIGatewayProxyRequest
+		if err := decoder.Decode(&evento); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("error al leer el evento: %w", err)
+		}
+
+		response, err := adapter.Invocar(ctx, evento)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(response); err != nil {
+			return fmt.Errorf("error al escribir la respuesta: %w", err)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package apperrors declara los errores que comparten paquetes que no pueden importarse entre sí, por ejemplo
+// secrets, que usa el cliente de internal/aws, y el cliente, que reporta los secretos inexistentes.
+package apperrors
+
+import "errors"
+
+// ErrSecretoNoEncontrado indica que el proveedor no tiene el secreto solicitado.
+var ErrSecretoNoEncontrado = errors.New("secreto no encontrado")
@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"gmf_transmission_response/internal/apperrors"
 )
 
 // SecretsManagerClient define los métodos que el cliente de Secrets Manager debe implementar.
@@ -22,53 +22,91 @@ type SecretsManagerClient interface {
 	) (*secretsmanager.GetSecretValueOutput, error)
 }
 
+// Valores por defecto cuando se usa LocalStack.
+const (
+	LocalStackEndpointPorDefecto = "http://localhost:4566"
+	LocalStackRegionPorDefecto   = "us-east-1"
+)
+
+// Config contiene la configuración del cliente de AWS.
+type Config struct {
+	// Local indica que se usa LocalStack en lugar de AWS.
+	Local bool
+	// Endpoint reemplaza el endpoint de Secrets Manager y SQS; con Local vacío se usa LocalStackEndpointPorDefecto.
+	Endpoint string
+	// Region es la región de AWS; vacía se toma de la configuración por defecto del SDK.
+	Region string
+	// Profile es el perfil de credenciales compartidas; vacío se usa la cadena de credenciales por defecto.
+	Profile string
+}
+
 // SecretsManager implementa SecretsManagerInterface.
 type SecretsManager struct {
 	Client SecretsManagerClient
 }
 
 // NewSecretsManager crea una nueva instancia de SecretsManager.
-func NewSecretsManager() (*SecretsManager, error) {
-	var cfg aws.Config
-	var err error
+func NewSecretsManager(awsConfig Config) (*SecretsManager, error) {
+	cfg, endpoint, err := cargarConfig(awsConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	if os.Getenv("APP_ENV") == "local" {
-		endpoint := "http://localhost:4566"
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion("us-east-1"),
-			config.WithEndpointResolver(aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
-				return aws.Endpoint{
-					URL:           endpoint,
-					SigningRegion: "us-east-1",
-				}, nil
-			})),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error configurando LocalStack: %w", err)
+	client := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
 		}
-	} else {
-		// Cargar configuración de AWS real.
-		cfg, err = config.LoadDefaultConfig(context.TODO())
-		if err != nil {
-			return nil, fmt.Errorf("error cargando la configuración de AWS: %w", err)
+	})
+	return &SecretsManager{Client: client}, nil
+}
+
+// cargarConfig carga la configuración del SDK con la región, el perfil y, con LocalStack, las credenciales
+// fijas. Retorna también el endpoint que reemplaza al de AWS, vacío si se usa el endpoint por defecto.
+func cargarConfig(awsConfig Config) (aws.Config, string, error) {
+	endpoint := awsConfig.Endpoint
+	region := awsConfig.Region
+	var opciones []func(*config.LoadOptions) error
+
+	if awsConfig.Local {
+		if endpoint == "" {
+			endpoint = LocalStackEndpointPorDefecto
+		}
+		if region == "" {
+			region = LocalStackRegionPorDefecto
 		}
+		// LocalStack acepta cualquier credencial, se usan unas fijas si no se indica un perfil
+		if awsConfig.Profile == "" {
+			opciones = append(opciones, config.WithCredentialsProvider(
+				aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test", Source: "LocalStack"}, nil
+				})))
+		}
+	}
+	if region != "" {
+		opciones = append(opciones, config.WithRegion(region))
+	}
+	if awsConfig.Profile != "" {
+		opciones = append(opciones, config.WithSharedConfigProfile(awsConfig.Profile))
 	}
 
-	client := secretsmanager.NewFromConfig(cfg)
-	return &SecretsManager{Client: client}, nil
+	cfg, err := config.LoadDefaultConfig(context.TODO(), opciones...)
+	if err != nil {
+		return aws.Config{}, "", fmt.Errorf("error cargando la configuración de AWS: %w", err)
+	}
+	return cfg, endpoint, nil
 }
 
-// GetSecret obtiene y deserializa un secreto desde AWS Secrets Manager o LocalStack.
-func (sm *SecretsManager) GetSecret(secretName string) (map[string]string, error) {
+// GetSecret obtiene y deserializa un secreto desde AWS Secrets Manager o LocalStack. La consulta se cancela con ctx.
+func (sm *SecretsManager) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: &secretName,
 	}
 
-	result, err := sm.Client.GetSecretValue(context.TODO(), input)
+	result, err := sm.Client.GetSecretValue(ctx, input)
 	if err != nil {
 		var notFoundErr *types.ResourceNotFoundException
 		if errors.As(err, &notFoundErr) {
-			return nil, fmt.Errorf("secreto no encontrado: %s", secretName)
+			return nil, fmt.Errorf("%w: %s", apperrors.ErrSecretoNoEncontrado, secretName)
 		}
 		return nil, fmt.Errorf("error obteniendo el secreto: %w", err)
 	}
@@ -8,7 +8,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gmf_transmission_response/internal/apperrors"
 	awsinternal "gmf_transmission_response/internal/aws"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -54,7 +58,7 @@ func TestSecretsManager_GetSecret_Success(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar prueba
-	result, err := sm.GetSecret("test-secret")
+	result, err := sm.GetSecret(context.Background(), "test-secret")
 	assert.NoError(t, err)
 	assert.Equal(t, "test-user", result["USERNAME"])
 	assert.Equal(t, "test-pass", result["PASSWORD"])
@@ -77,9 +81,28 @@ func TestSecretsManager_GetSecret_ResourceNotFound(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar prueba
-	_, err := sm.GetSecret("non-existent-secret")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "secreto no encontrado")
+	_, err := sm.GetSecret(context.Background(), "non-existent-secret")
+	assert.ErrorIs(t, err, apperrors.ErrSecretoNoEncontrado)
+	assert.EqualError(t, err, "secreto no encontrado: non-existent-secret")
+}
+
+func TestSecretsManager_GetSecret_ContextoCancelado(t *testing.T) {
+	// El cliente recibe el contexto de la consulta
+	mockClient := &MockSecretsManagerClient{
+		GetSecretValueFunc: func(
+			ctx context.Context,
+			params *secretsmanager.GetSecretValueInput,
+			optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+			return nil, ctx.Err()
+		},
+	}
+
+	sm := &awsinternal.SecretsManager{Client: mockClient}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := sm.GetSecret(ctx, "test-secret")
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 func TestSecretsManager_GetSecret_DeserializationError(t *testing.T) {
@@ -101,7 +124,7 @@ func TestSecretsManager_GetSecret_DeserializationError(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar prueba
-	_, err := sm.GetSecret("malformed-secret")
+	_, err := sm.GetSecret(context.Background(), "malformed-secret")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error deserializando el secreto")
 }
@@ -125,7 +148,7 @@ func TestSecretsManager_GetSecret_EmptySecret(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar prueba
-	_, err := sm.GetSecret("empty-secret")
+	_, err := sm.GetSecret(context.Background(), "empty-secret")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "secreto vacío o no válido")
 }
@@ -155,7 +178,7 @@ func TestSecretsManager_NewSecretsManager_LocalStack(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar la prueba del secreto
-	result, err := sm.GetSecret("test-secret")
+	result, err := sm.GetSecret(context.Background(), "test-secret")
 	assert.NoError(t, err)
 	assert.Equal(t, "test-user", result["USERNAME"])
 	assert.Equal(t, "test-pass", result["PASSWORD"])
@@ -189,7 +212,7 @@ func TestSecretsManager_NewSecretsManager_AWS(t *testing.T) {
 	sm := &awsinternal.SecretsManager{Client: mockClient}
 
 	// Ejecutar la prueba del secreto
-	result, err := sm.GetSecret("test-secret")
+	result, err := sm.GetSecret(context.Background(), "test-secret")
 	assert.NoError(t, err)
 	assert.Equal(t, "test-user", result["USERNAME"])
 	assert.Equal(t, "test-pass", result["PASSWORD"])
@@ -199,12 +222,8 @@ func TestSecretsManager_NewSecretsManager_AWS(t *testing.T) {
 }
 
 func TestNewSecretsManager_LocalStack(t *testing.T) {
-	// Establecer la variable de entorno para simular el uso de LocalStack.
-	os.Setenv("APP_ENV", "local")
-	defer os.Unsetenv("APP_ENV")
-
-	// Crear SecretsManager.
-	sm, err := awsinternal.NewSecretsManager()
+	// Crear SecretsManager apuntando a LocalStack.
+	sm, err := awsinternal.NewSecretsManager(awsinternal.Config{Local: true})
 
 	// Verificar que no haya errores.
 	assert.NoError(t, err)
@@ -213,15 +232,102 @@ func TestNewSecretsManager_LocalStack(t *testing.T) {
 }
 
 func TestNewSecretsManager_AWS(t *testing.T) {
-	// Establecer la variable de entorno para simular el uso de AWS real.
-	os.Setenv("APP_ENV", "aws")
-	defer os.Unsetenv("APP_ENV")
-
-	// Crear SecretsManager.
-	sm, err := awsinternal.NewSecretsManager()
+	// Crear SecretsManager apuntando a AWS real.
+	sm, err := awsinternal.NewSecretsManager(awsinternal.Config{})
 
 	// Verificar que no haya errores.
 	assert.NoError(t, err)
 	assert.NotNil(t, sm)
 	assert.NotNil(t, sm.Client)
 }
+
+// secretsManagerFalso emula el API GetSecretValue de Secrets Manager con los secretos indicados
+// y registra el encabezado Authorization de la última solicitud.
+func secretsManagerFalso(t *testing.T, secretos map[string]string) (*httptest.Server, *string) {
+	t.Helper()
+	var autorizacion string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		autorizacion = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "InvalidAction", "message": "acción no soportada"})
+			return
+		}
+
+		var input struct{ SecretId string }
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		secreto, ok := secretos[input.SecretId]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"__type":  "ResourceNotFoundException",
+				"message": "Secrets Manager can't find the specified secret.",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"Name": input.SecretId, "SecretString": secreto})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &autorizacion
+}
+
+func TestNewSecretsManager_EndpointConfigurable(t *testing.T) {
+	server, autorizacion := secretsManagerFalso(t, map[string]string{
+		"gmf-secret": `{"USERNAME": "test-user", "PASSWORD": "test-pass"}`,
+	})
+
+	sm, err := awsinternal.NewSecretsManager(awsinternal.Config{
+		Local:    true,
+		Endpoint: server.URL,
+		Region:   "us-east-2",
+	})
+	require.NoError(t, err)
+
+	result, err := sm.GetSecret(context.Background(), "gmf-secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USERNAME": "test-user", "PASSWORD": "test-pass"}, result)
+	// La solicitud se firma con las credenciales de LocalStack y la región configurada
+	assert.Contains(t, *autorizacion, "Credential=test/")
+	assert.Contains(t, *autorizacion, "/us-east-2/secretsmanager/")
+}
+
+func TestNewSecretsManager_EndpointConfigurable_RegionPorDefecto(t *testing.T) {
+	server, autorizacion := secretsManagerFalso(t, map[string]string{"gmf-secret": `{"USERNAME": "u"}`})
+
+	sm, err := awsinternal.NewSecretsManager(awsinternal.Config{Local: true, Endpoint: server.URL})
+	require.NoError(t, err)
+
+	_, err = sm.GetSecret(context.Background(), "gmf-secret")
+
+	assert.NoError(t, err)
+	assert.Contains(t, *autorizacion, "/"+awsinternal.LocalStackRegionPorDefecto+"/")
+}
+
+func TestNewSecretsManager_EndpointConfigurable_SecretoNoEncontrado(t *testing.T) {
+	server, _ := secretsManagerFalso(t, map[string]string{})
+
+	sm, err := awsinternal.NewSecretsManager(awsinternal.Config{Local: true, Endpoint: server.URL})
+	require.NoError(t, err)
+
+	_, err = sm.GetSecret(context.Background(), "gmf-secret")
+
+	assert.EqualError(t, err, "secreto no encontrado: gmf-secret")
+}
+
+func TestNewSecretsManager_PerfilInexistente(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+
+	_, err := awsinternal.NewSecretsManager(awsinternal.Config{Profile: "no-existe"})
+
+	assert.ErrorContains(t, err, "error cargando la configuración de AWS")
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"gmf_transmission_response/internal/queue"
+)
+
+// SQSClient define los métodos que el cliente de SQS debe implementar.
+type SQSClient interface {
+	ReceiveMessage(
+		ctx context.Context,
+		params *sqs.ReceiveMessageInput,
+		optFns ...func(*sqs.Options),
+	) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(
+		ctx context.Context,
+		params *sqs.DeleteMessageInput,
+		optFns ...func(*sqs.Options),
+	) (*sqs.DeleteMessageOutput, error)
+	SendMessage(
+		ctx context.Context,
+		params *sqs.SendMessageInput,
+		optFns ...func(*sqs.Options),
+	) (*sqs.SendMessageOutput, error)
+}
+
+// SQS implementa queue.QueueInterface sobre una cola de Amazon SQS o LocalStack.
+type SQS struct {
+	Client   SQSClient
+	QueueURL string
+}
+
+// NewSQS crea un cliente de la cola indicada.
+func NewSQS(awsConfig Config, queueURL string) (*SQS, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("URL de la cola %q inválida", queueURL)
+	}
+
+	cfg, endpoint, err := cargarConfig(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client := sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+	return &SQS{Client: client, QueueURL: queueURL}, nil
+}
+
+// ReceiveMessage recibe hasta MaxNumberOfMessages mensajes con long polling de WaitTime.
+func (q *SQS) ReceiveMessage(ctx context.Context, input *queue.ReceiveMessageInput) (*queue.ReceiveMessageOutput, error) {
+	result, err := q.Client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.QueueURL),
+		MaxNumberOfMessages: int32(input.MaxNumberOfMessages),
+		VisibilityTimeout:   segundos(input.VisibilityTimeout),
+		WaitTimeSeconds:     segundos(input.WaitTime),
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error en ReceiveMessage de SQS: %w", err)
+	}
+
+	output := &queue.ReceiveMessageOutput{Messages: make([]queue.Message, 0, len(result.Messages))}
+	for _, mensaje := range result.Messages {
+		receiveCount, _ := strconv.Atoi(
+			mensaje.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+		output.Messages = append(output.Messages, queue.Message{
+			MessageID:     aws.ToString(mensaje.MessageId),
+			ReceiptHandle: aws.ToString(mensaje.ReceiptHandle),
+			Body:          aws.ToString(mensaje.Body),
+			ReceiveCount:  receiveCount,
+		})
+	}
+	return output, nil
+}
+
+// DeleteMessage elimina el mensaje de la recepción indicada.
+func (q *SQS) DeleteMessage(ctx context.Context, input *queue.DeleteMessageInput) error {
+	_, err := q.Client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.QueueURL),
+		ReceiptHandle: aws.String(input.ReceiptHandle),
+	})
+	if err != nil {
+		return fmt.Errorf("error en DeleteMessage de SQS: %w", err)
+	}
+	return nil
+}
+
+// SendMessage envía un mensaje a la cola.
+func (q *SQS) SendMessage(ctx context.Context, input *queue.SendMessageInput) (*queue.SendMessageOutput, error) {
+	result, err := q.Client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.QueueURL),
+		MessageBody: aws.String(input.MessageBody),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error en SendMessage de SQS: %w", err)
+	}
+	return &queue.SendMessageOutput{MessageID: aws.ToString(result.MessageId)}, nil
+}
+
+// segundos convierte la duración en segundos enteros, redondeando hacia arriba como espera SQS.
+func segundos(d time.Duration) int32 {
+	return int32(math.Ceil(d.Seconds()))
+}
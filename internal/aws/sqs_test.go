@@ -0,0 +1,210 @@
+package aws_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	awsinternal "gmf_transmission_response/internal/aws"
+	"gmf_transmission_response/internal/queue"
+)
+
+// md5Hex es la suma MD5 con la que SQS permite validar el cuerpo de los mensajes.
+func md5Hex(body string) string {
+	suma := md5.Sum([]byte(body))
+	return hex.EncodeToString(suma[:])
+}
+
+// sqsFalso emula el protocolo JSON de SQS sobre una cola en memoria y registra el encabezado
+// Authorization de la última solicitud. Las solicitudes a otra cola retornan QueueDoesNotExist.
+func sqsFalso(t *testing.T, queueURL *string) (*httptest.Server, *string) {
+	t.Helper()
+	cola := queue.NewMemoryQueue()
+	var autorizacion string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		autorizacion = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+		var input struct {
+			QueueUrl            string
+			MaxNumberOfMessages int
+			VisibilityTimeout   int
+			WaitTimeSeconds     int
+			ReceiptHandle       string
+			MessageBody         string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		if input.QueueUrl != *queueURL {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"__type":  "com.amazonaws.sqs#QueueDoesNotExist",
+				"message": "The specified queue does not exist.",
+			})
+			return
+		}
+
+		ctx := r.Context()
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSQS.SendMessage":
+			output, err := cola.SendMessage(ctx, &queue.SendMessageInput{MessageBody: input.MessageBody})
+			require.NoError(t, err)
+			json.NewEncoder(w).Encode(map[string]string{
+				"MessageId":        output.MessageID,
+				"MD5OfMessageBody": md5Hex(input.MessageBody),
+			})
+		case "AmazonSQS.ReceiveMessage":
+			output, err := cola.ReceiveMessage(ctx, &queue.ReceiveMessageInput{
+				MaxNumberOfMessages: input.MaxNumberOfMessages,
+				VisibilityTimeout:   time.Duration(input.VisibilityTimeout) * time.Second,
+				WaitTime:            time.Duration(input.WaitTimeSeconds) * time.Second,
+			})
+			require.NoError(t, err)
+			mensajes := make([]map[string]any, 0, len(output.Messages))
+			for _, mensaje := range output.Messages {
+				mensajes = append(mensajes, map[string]any{
+					"MessageId":     mensaje.MessageID,
+					"ReceiptHandle": mensaje.ReceiptHandle,
+					"Body":          mensaje.Body,
+					"MD5OfBody":     md5Hex(mensaje.Body),
+					"Attributes":    map[string]string{"ApproximateReceiveCount": strconv.Itoa(mensaje.ReceiveCount)},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"Messages": mensajes})
+		case "AmazonSQS.DeleteMessage":
+			require.NoError(t, cola.DeleteMessage(ctx, &queue.DeleteMessageInput{ReceiptHandle: input.ReceiptHandle}))
+			json.NewEncoder(w).Encode(map[string]string{})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &autorizacion
+}
+
+func TestSQS_EnviarRecibirEliminar(t *testing.T) {
+	var queueURL string
+	server, autorizacion := sqsFalso(t, &queueURL)
+	queueURL = server.URL + "/000000000000/gmf-transmission-response"
+
+	sqs, err := awsinternal.NewSQS(awsinternal.Config{Local: true, Endpoint: server.URL}, queueURL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	enviado, err := sqs.SendMessage(ctx, &queue.SendMessageInput{MessageBody: `{"transmittedFiles":[]}`})
+	require.NoError(t, err)
+
+	recibido, err := sqs.ReceiveMessage(ctx, &queue.ReceiveMessageInput{
+		MaxNumberOfMessages: queue.MaxNumberOfMessages,
+		VisibilityTimeout:   time.Minute,
+	})
+	require.NoError(t, err)
+	require.Len(t, recibido.Messages, 1)
+	assert.Equal(t, enviado.MessageID, recibido.Messages[0].MessageID)
+	assert.Equal(t, `{"transmittedFiles":[]}`, recibido.Messages[0].Body)
+	assert.Equal(t, 1, recibido.Messages[0].ReceiveCount)
+
+	require.NoError(t, sqs.DeleteMessage(ctx, &queue.DeleteMessageInput{
+		ReceiptHandle: recibido.Messages[0].ReceiptHandle,
+	}))
+	vacio, err := sqs.ReceiveMessage(ctx, &queue.ReceiveMessageInput{})
+	require.NoError(t, err)
+	assert.Empty(t, vacio.Messages)
+
+	// Las solicitudes se firman con las credenciales de LocalStack para el servicio sqs
+	assert.Contains(t, *autorizacion, "Credential=test/")
+	assert.Contains(t, *autorizacion, "/"+awsinternal.LocalStackRegionPorDefecto+"/sqs/")
+}
+
+func TestSQS_EndpointConfigurable(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secreto")
+	var queueURL string
+	server, autorizacion := sqsFalso(t, &queueURL)
+	queueURL = "https://sqs.us-east-2.amazonaws.com/123456789012/gmf-transmission-response"
+
+	sqs, err := awsinternal.NewSQS(awsinternal.Config{Endpoint: server.URL, Region: "us-east-2"}, queueURL)
+	require.NoError(t, err)
+
+	_, err = sqs.SendMessage(context.Background(), &queue.SendMessageInput{MessageBody: "{}"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, *autorizacion, "Credential=AKIDEXAMPLE/")
+	assert.Contains(t, *autorizacion, "/us-east-2/sqs/")
+}
+
+func TestSQS_ColaNoExiste(t *testing.T) {
+	queueURL := "http://localhost/000000000000/otra-cola"
+	server, _ := sqsFalso(t, &queueURL)
+
+	sqs, err := awsinternal.NewSQS(awsinternal.Config{Local: true, Endpoint: server.URL},
+		server.URL+"/000000000000/gmf-transmission-response")
+	require.NoError(t, err)
+
+	_, err = sqs.ReceiveMessage(context.Background(), &queue.ReceiveMessageInput{})
+
+	var noExiste *types.QueueDoesNotExist
+	assert.True(t, errors.As(err, &noExiste))
+	assert.ErrorContains(t, err, "error en ReceiveMessage de SQS")
+}
+
+// MockSQSClient registra la última solicitud de ReceiveMessage y retorna los mensajes indicados
+type MockSQSClient struct {
+	awsinternal.SQSClient
+	Recibido *sqs.ReceiveMessageInput
+	Mensajes []types.Message
+}
+
+func (m *MockSQSClient) ReceiveMessage(
+	_ context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	m.Recibido = params
+	return &sqs.ReceiveMessageOutput{Messages: m.Mensajes}, nil
+}
+
+func TestSQS_ReceiveMessage_Parametros(t *testing.T) {
+	client := &MockSQSClient{Mensajes: []types.Message{{
+		MessageId:     aws.String("mensaje-1"),
+		ReceiptHandle: aws.String("recepcion-1"),
+		Body:          aws.String("{}"),
+		Attributes:    map[string]string{"ApproximateReceiveCount": "3"},
+	}}}
+	sqs := &awsinternal.SQS{Client: client, QueueURL: "https://sqs.us-east-2.amazonaws.com/123456789012/cola"}
+
+	output, err := sqs.ReceiveMessage(context.Background(), &queue.ReceiveMessageInput{
+		MaxNumberOfMessages: 10,
+		VisibilityTimeout:   1500 * time.Millisecond,
+		WaitTime:            20 * time.Second,
+	})
+
+	require.NoError(t, err)
+	// Las duraciones se redondean hacia arriba a segundos y se pide la cantidad de recepciones
+	assert.Equal(t, "https://sqs.us-east-2.amazonaws.com/123456789012/cola", aws.ToString(client.Recibido.QueueUrl))
+	assert.Equal(t, int32(10), client.Recibido.MaxNumberOfMessages)
+	assert.Equal(t, int32(2), client.Recibido.VisibilityTimeout)
+	assert.Equal(t, int32(20), client.Recibido.WaitTimeSeconds)
+	assert.Equal(t, []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+		client.Recibido.MessageSystemAttributeNames)
+	assert.Equal(t, []queue.Message{{
+		MessageID: "mensaje-1", ReceiptHandle: "recepcion-1", Body: "{}", ReceiveCount: 3,
+	}}, output.Messages)
+}
+
+func TestNewSQS_URLInvalida(t *testing.T) {
+	sqs, err := awsinternal.NewSQS(awsinternal.Config{Local: true}, "gmf-transmission-response")
+
+	assert.Nil(t, sqs)
+	assert.EqualError(t, err, `URL de la cola "gmf-transmission-response" inválida`)
+}
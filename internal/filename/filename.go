@@ -0,0 +1,194 @@
+package filename
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gmf_transmission_response/internal/models"
+)
+
+// Estructura del nombre de un archivo GMF transmitido:
+//
+//	TUTGMF0001 00 01 20240312 -0001 [-A] [.txt]
+//	|prefijo  |pl|ti|fecha   |consec|anul|extensión
+//
+// El prefijo está compuesto por "TUTGMF" y el código de entidad de 4 dígitos.
+const (
+	prefijoFijo          = "TUTGMF"
+	longitudPrefijo      = 10
+	longitudPlataforma   = 2
+	longitudTipoArchivo  = 2
+	longitudFecha        = 8
+	longitudConsecutivo  = 4
+	longitudBase         = longitudPrefijo + longitudPlataforma + longitudTipoArchivo + longitudFecha
+	marcaAnulacion       = "-A"
+	extensionPermitida   = ".txt"
+	formatoFechaArchivo  = "20060102"
+	separadorConsecutivo = "-"
+)
+
+// ErrNombreInvalido es el error base para los nombres de archivo mal formados.
+var ErrNombreInvalido = errors.New("nombre de archivo inválido")
+
+// ErrNoCoincide es el error base cuando el nombre no coincide con el archivo registrado.
+var ErrNoCoincide = errors.New("el nombre de archivo no coincide con el archivo registrado")
+
+// CampoError describe el campo del nombre de archivo que no pudo validarse.
+type CampoError struct {
+	Nombre string
+	Campo  string
+	Valor  string
+	Motivo string
+	base   error
+}
+
+func (e *CampoError) Error() string {
+	return fmt.Sprintf("%s %q: %s %q %s", e.base.Error(), e.Nombre, e.Campo, e.Valor, e.Motivo)
+}
+
+// Is permite comparar el error con ErrNombreInvalido o ErrNoCoincide usando errors.Is.
+func (e *CampoError) Is(target error) bool {
+	return target == e.base
+}
+
+// NombreArchivo contiene las partes tipadas de un nombre de archivo GMF.
+type NombreArchivo struct {
+	Original         string
+	Prefijo          string
+	PlataformaOrigen string
+	TipoArchivo      string
+	Fecha            time.Time
+	Consecutivo      int64
+	EsAnulacion      bool
+	Extension        string
+}
+
+// FechaNombreArchivo retorna la fecha del nombre en el formato de la columna fecha_nombre_archivo.
+func (n *NombreArchivo) FechaNombreArchivo() string {
+	return n.Fecha.Format(formatoFechaArchivo)
+}
+
+// Parse interpreta un nombre de archivo GMF y valida cada una de sus partes.
+func Parse(nombre string) (*NombreArchivo, error) {
+	invalido := func(campo, valor, motivo string) error {
+		return &CampoError{Nombre: nombre, Campo: campo, Valor: valor, Motivo: motivo, base: ErrNombreInvalido}
+	}
+
+	resultado := &NombreArchivo{Original: nombre}
+	sinExtension := nombre
+
+	if ext := filepath.Ext(nombre); ext != "" {
+		if !strings.EqualFold(ext, extensionPermitida) {
+			return nil, invalido("extension", ext, "no es una extensión permitida")
+		}
+		resultado.Extension = ext
+		sinExtension = strings.TrimSuffix(nombre, ext)
+	}
+
+	if strings.HasSuffix(sinExtension, marcaAnulacion) {
+		resultado.EsAnulacion = true
+		sinExtension = strings.TrimSuffix(sinExtension, marcaAnulacion)
+	}
+
+	base, consecutivo, ok := strings.Cut(sinExtension, separadorConsecutivo)
+	if !ok {
+		return nil, invalido("consecutivo", "", "no está presente")
+	}
+
+	if len(base) != longitudBase {
+		return nil, invalido("nombre", base, fmt.Sprintf("debe tener %d caracteres antes del consecutivo", longitudBase))
+	}
+
+	prefijo := base[:longitudPrefijo]
+	if !strings.HasPrefix(prefijo, prefijoFijo) || !esNumerico(prefijo[len(prefijoFijo):]) {
+		return nil, invalido("prefijo", prefijo, "debe ser "+prefijoFijo+" seguido de 4 dígitos")
+	}
+	resultado.Prefijo = prefijo
+
+	posicion := longitudPrefijo
+	plataforma := base[posicion : posicion+longitudPlataforma]
+	if !esAlfanumerico(plataforma) {
+		return nil, invalido("plataforma_origen", plataforma, "debe ser alfanumérica")
+	}
+	resultado.PlataformaOrigen = plataforma
+
+	posicion += longitudPlataforma
+	tipoArchivo := base[posicion : posicion+longitudTipoArchivo]
+	if !esAlfanumerico(tipoArchivo) {
+		return nil, invalido("tipo_archivo", tipoArchivo, "debe ser alfanumérico")
+	}
+	resultado.TipoArchivo = tipoArchivo
+
+	posicion += longitudTipoArchivo
+	fechaTexto := base[posicion : posicion+longitudFecha]
+	fecha, err := time.Parse(formatoFechaArchivo, fechaTexto)
+	if err != nil {
+		return nil, invalido("fecha", fechaTexto, "no es una fecha válida (AAAAMMDD)")
+	}
+	resultado.Fecha = fecha
+
+	if len(consecutivo) != longitudConsecutivo || !esNumerico(consecutivo) {
+		return nil, invalido("consecutivo", consecutivo, fmt.Sprintf("debe tener %d dígitos", longitudConsecutivo))
+	}
+	resultado.Consecutivo, _ = strconv.ParseInt(consecutivo, 10, 64)
+
+	return resultado, nil
+}
+
+// ValidarContra verifica que las partes del nombre coincidan con las columnas del archivo registrado.
+// Retorna un error por cada columna que no coincide.
+func (n *NombreArchivo) ValidarContra(archivo *models.CGDArchivos) error {
+	noCoincide := func(campo, valor, registrado string) error {
+		return &CampoError{
+			Nombre: n.Original,
+			Campo:  campo,
+			Valor:  valor,
+			Motivo: fmt.Sprintf("no coincide con el valor registrado %q", registrado),
+			base:   ErrNoCoincide,
+		}
+	}
+
+	var errs []error
+	if n.PlataformaOrigen != archivo.PlataformaOrigen {
+		errs = append(errs, noCoincide("plataforma_origen", n.PlataformaOrigen, archivo.PlataformaOrigen))
+	}
+	if n.FechaNombreArchivo() != archivo.FechaNombreArchivo {
+		errs = append(errs, noCoincide("fecha_nombre_archivo", n.FechaNombreArchivo(), archivo.FechaNombreArchivo))
+	}
+	if n.Consecutivo != archivo.ACGConsecutivo {
+		errs = append(errs, noCoincide("acg_consecutivo",
+			strconv.FormatInt(n.Consecutivo, 10), strconv.FormatInt(archivo.ACGConsecutivo, 10)))
+	}
+
+	return errors.Join(errs...)
+}
+
+// esNumerico indica si el texto contiene solo dígitos.
+func esNumerico(texto string) bool {
+	if texto == "" {
+		return false
+	}
+	for _, c := range texto {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// esAlfanumerico indica si el texto contiene solo dígitos o letras mayúsculas.
+func esAlfanumerico(texto string) bool {
+	if texto == "" {
+		return false
+	}
+	for _, c := range texto {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package filename_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/filename"
+	"gmf_transmission_response/internal/models"
+)
+
+func TestParse_Movimiento(t *testing.T) {
+	nombre, err := filename.Parse("TUTGMF0001000120240312-0001.txt")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TUTGMF0001", nombre.Prefijo)
+	assert.Equal(t, "00", nombre.PlataformaOrigen)
+	assert.Equal(t, "01", nombre.TipoArchivo)
+	assert.Equal(t, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), nombre.Fecha)
+	assert.Equal(t, "20240312", nombre.FechaNombreArchivo())
+	assert.Equal(t, int64(1), nombre.Consecutivo)
+	assert.False(t, nombre.EsAnulacion)
+	assert.Equal(t, ".txt", nombre.Extension)
+}
+
+func TestParse_Anulacion(t *testing.T) {
+	nombre, err := filename.Parse("TUTGMF0001000120240312-0002-A")
+
+	assert.NoError(t, err)
+	assert.True(t, nombre.EsAnulacion)
+	assert.Equal(t, int64(2), nombre.Consecutivo)
+	assert.Empty(t, nombre.Extension)
+}
+
+func TestParse_NombresInvalidos(t *testing.T) {
+	casos := []struct {
+		nombre string
+		campo  string
+	}{
+		{"TUTGMF0001000120240312-0001.csv", "extension"},
+		{"TUTGMF0001000120240312", "consecutivo"},
+		{"TUTGMF000100012024031-0001.txt", "nombre"},
+		{"XXXGMF0001000120240312-0001", "prefijo"},
+		{"TUTGMF00A1000120240312-0001", "prefijo"},
+		{"TUTGMF0001a00120240312-0001", "plataforma_origen"},
+		{"TUTGMF00010001202403a2-0001", "fecha"},
+		{"TUTGMF0001000120241312-0001", "fecha"},
+		{"TUTGMF0001000120240312-01", "consecutivo"},
+		{"TUTGMF0001000120240312-00X1", "consecutivo"},
+	}
+
+	for _, c := range casos {
+		nombre, err := filename.Parse(c.nombre)
+
+		assert.Nil(t, nombre, c.nombre)
+		assert.ErrorIs(t, err, filename.ErrNombreInvalido, c.nombre)
+
+		var campoErr *filename.CampoError
+		if assert.True(t, errors.As(err, &campoErr), c.nombre) {
+			assert.Equal(t, c.campo, campoErr.Campo, c.nombre)
+		}
+	}
+}
+
+func TestValidarContra_Coincide(t *testing.T) {
+	nombre, err := filename.Parse("TUTGMF0001000120240312-0001.txt")
+	assert.NoError(t, err)
+
+	archivo := &models.CGDArchivos{
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+	}
+
+	assert.NoError(t, nombre.ValidarContra(archivo))
+}
+
+func TestValidarContra_NoCoincide(t *testing.T) {
+	nombre, err := filename.Parse("TUTGMF0001000120240312-0001.txt")
+	assert.NoError(t, err)
+
+	archivo := &models.CGDArchivos{
+		PlataformaOrigen:   "01",
+		FechaNombreArchivo: "20240311",
+		ACGConsecutivo:     2,
+	}
+
+	err = nombre.ValidarContra(archivo)
+
+	// Se debe reportar cada columna que no coincide
+	assert.ErrorIs(t, err, filename.ErrNoCoincide)
+	assert.Contains(t, err.Error(), "plataforma_origen")
+	assert.Contains(t, err.Error(), "fecha_nombre_archivo")
+	assert.Contains(t, err.Error(), "acg_consecutivo")
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+)
+
+// HandleConsultarArchivo retorna el estado actual de transmisión del archivo indicado en la ruta.
+// Responde 404 si el archivo no existe.
+func (h *ArchivoHandler) HandleConsultarArchivo(w http.ResponseWriter, r *http.Request) {
+	acgNombreArchivo := r.PathValue("acgNombreArchivo")
+
+	consulta, err := h.ArchivoService.ConsultarArchivo(acgNombreArchivo)
+	if err != nil {
+		escribirErrorConsulta(w, err)
+		return
+	}
+
+	escribirJSON(w, http.StatusOK, consulta)
+}
+
+// HandleHistorialArchivo retorna las transiciones de estado del archivo indicado en la ruta, ordenadas por fecha.
+// Acepta los parámetros from y to (RFC 3339 o YYYY-MM-DD) para filtrar por fecha de cambio de estado,
+// y page y page_size para paginar. from es inclusivo y to es exclusivo, salvo cuando to es una fecha,
+// en cuyo caso incluye todo ese día. Responde 404 si el archivo no existe.
+func (h *ArchivoHandler) HandleHistorialArchivo(w http.ResponseWriter, r *http.Request) {
+	acgNombreArchivo := r.PathValue("acgNombreArchivo")
+
+	filtro, violaciones := filtroHistorial(r)
+	if len(violaciones) > 0 {
+		escribirJSON(w, http.StatusBadRequest, models.ValidationResponse{
+			Message:    "La consulta contiene errores de validación",
+			Violations: violaciones,
+		})
+		return
+	}
+
+	historial, err := h.ArchivoService.ConsultarHistorial(acgNombreArchivo, filtro)
+	if err != nil {
+		escribirErrorConsulta(w, err)
+		return
+	}
+
+	escribirJSON(w, http.StatusOK, historial)
+}
+
+// filtroHistorial construye el filtro del histórico a partir de los parámetros de la consulta.
+func filtroHistorial(r *http.Request) (models.FiltroHistorial, []models.Violation) {
+	var filtro models.FiltroHistorial
+	var violaciones []models.Violation
+	query := r.URL.Query()
+
+	if valor := query.Get("from"); valor != "" {
+		desde, _, err := parsearFecha(valor)
+		if err != nil {
+			violaciones = append(violaciones, models.Violation{Field: "from", Message: err.Error()})
+		}
+		filtro.Desde = desde
+	}
+
+	if valor := query.Get("to"); valor != "" {
+		hasta, esFecha, err := parsearFecha(valor)
+		if err != nil {
+			violaciones = append(violaciones, models.Violation{Field: "to", Message: err.Error()})
+		} else if esFecha {
+			hasta = hasta.AddDate(0, 0, 1)
+		}
+		filtro.Hasta = hasta
+	}
+
+	if !filtro.Desde.IsZero() && !filtro.Hasta.IsZero() && !filtro.Desde.Before(filtro.Hasta) {
+		violaciones = append(violaciones, models.Violation{Field: "to", Message: "debe ser posterior a from"})
+	}
+
+	if valor := query.Get("page"); valor != "" {
+		pagina, err := strconv.Atoi(valor)
+		if err != nil || pagina < 1 {
+			violaciones = append(violaciones, models.Violation{Field: "page", Message: "debe ser un entero mayor o igual a 1"})
+		}
+		filtro.Pagina = pagina
+	}
+
+	if valor := query.Get("page_size"); valor != "" {
+		tamano, err := strconv.Atoi(valor)
+		if err != nil || tamano < 1 || tamano > service.MaxTamanoPagina {
+			violaciones = append(violaciones, models.Violation{
+				Field:   "page_size",
+				Message: fmt.Sprintf("debe ser un entero entre 1 y %d", service.MaxTamanoPagina),
+			})
+		}
+		filtro.TamanoPagina = tamano
+	}
+
+	return filtro, violaciones
+}
+
+// parsearFecha interpreta valor en formato RFC 3339 o como una fecha YYYY-MM-DD en la zona horaria local.
+// Indica si el valor era solo una fecha.
+func parsearFecha(valor string) (time.Time, bool, error) {
+	if fecha, err := time.ParseInLocation(time.DateOnly, valor, time.Local); err == nil {
+		return fecha, true, nil
+	}
+	fecha, err := time.Parse(time.RFC3339, valor)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("valor %q inválido, se esperaba RFC 3339 o YYYY-MM-DD", valor)
+	}
+	return fecha, false, nil
+}
+
+// escribirErrorConsulta responde el error de una consulta con el código HTTP que corresponde a su código de error.
+func escribirErrorConsulta(w http.ResponseWriter, err error) {
+	codigo := service.CodigoError(err)
+
+	statusCode := http.StatusInternalServerError
+	mensaje := "Error al consultar la base de datos"
+	if codigo == service.CodigoNoEncontrado {
+		statusCode = http.StatusNotFound
+		mensaje = "El archivo no existe"
+	}
+
+	escribirJSON(w, statusCode, models.ErrorResponse{Code: codigo, Message: mensaje})
+}
+
+// escribirJSON responde v como JSON con el código HTTP indicado.
+func escribirJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
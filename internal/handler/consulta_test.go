@@ -0,0 +1,162 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+)
+
+// nuevaSolicitudConsulta crea una solicitud GET /files/{acgNombreArchivo} con el valor de ruta asignado.
+func nuevaSolicitudConsulta(acgNombreArchivo string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/files/"+acgNombreArchivo, nil)
+	req.SetPathValue("acgNombreArchivo", acgNombreArchivo)
+	return req
+}
+
+func TestHandleConsultarArchivo(t *testing.T) {
+	consulta := &models.ArchivoConsulta{
+		IDArchivo:              10001202403120001,
+		ACGNombreArchivo:       "TUTGMF0001000120240312-0001",
+		Estado:                 "ANULACION_ENVIADA",
+		GAWRtaTransEstado:      "SUCCESSFUL",
+		GAWRtaTransCodigo:      "0000",
+		GAWRtaTransDetalle:     "Transmisión exitosa",
+		ACGTotalTx:             10,
+		ACGMontoTotalTx:        1500.50,
+		AnulacionNombreArchivo: "TUTGMF0001000120240312-0001-A",
+	}
+	h := handler.NewArchivoHandler(&MockArchivoService{
+		Consultas: map[string]*models.ArchivoConsulta{consulta.ACGNombreArchivo: consulta},
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleConsultarArchivo(w, nuevaSolicitudConsulta(consulta.ACGNombreArchivo))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp models.ArchivoConsulta
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, *consulta, resp)
+}
+
+func TestHandleConsultarArchivo_NoEncontrado(t *testing.T) {
+	h := handler.NewArchivoHandler(&MockArchivoService{})
+
+	w := httptest.NewRecorder()
+	h.HandleConsultarArchivo(w, nuevaSolicitudConsulta("TUTGMF0001000120240312-9999"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var resp models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, service.CodigoNoEncontrado, resp.Code)
+	assert.Equal(t, "El archivo no existe", resp.Message)
+}
+
+func TestHandleConsultarArchivo_ErrorBaseDatos(t *testing.T) {
+	h := handler.NewArchivoHandler(&MockArchivoService{
+		ConsultaError: &service.ProcesamientoError{
+			Codigo: service.CodigoErrorBaseDatos,
+			Err:    errors.New("conexión perdida"),
+		},
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleConsultarArchivo(w, nuevaSolicitudConsulta("TUTGMF0001000120240312-0001"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var resp models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, service.CodigoErrorBaseDatos, resp.Code)
+	assert.NotContains(t, w.Body.String(), "conexión perdida")
+}
+
+func nuevaSolicitudHistorial(acgNombreArchivo, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/files/"+acgNombreArchivo+"/history?"+query, nil)
+	req.SetPathValue("acgNombreArchivo", acgNombreArchivo)
+	return req
+}
+
+func TestHandleHistorialArchivo(t *testing.T) {
+	nombre := "TUTGMF0001000120240312-0001"
+	estados := []models.CGDArchivoEstados{
+		{IDArchivo: 1, EstadoInicial: "EMPAQUETADO", EstadoFinal: "ENVIADO",
+			FechaCambioEstado: time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC)},
+	}
+	mockService := &MockArchivoService{
+		Consultas:   map[string]*models.ArchivoConsulta{nombre: {IDArchivo: 1, ACGNombreArchivo: nombre}},
+		Historiales: map[string][]models.CGDArchivoEstados{nombre: estados},
+	}
+	h := handler.NewArchivoHandler(mockService)
+
+	w := httptest.NewRecorder()
+	h.HandleHistorialArchivo(w, nuevaSolicitudHistorial(nombre,
+		"from=2024-03-01T00:00:00Z&to=2024-03-31&page=2&page_size=10"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// La fecha final sin hora incluye todo el día
+	assert.Equal(t, models.FiltroHistorial{
+		Desde:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Hasta:        time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		Pagina:       2,
+		TamanoPagina: 10,
+	}, mockService.FiltroRecibido)
+
+	var resp models.HistorialResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, nombre, resp.ACGNombreArchivo)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	assert.Equal(t, 10, resp.PageSize)
+	assert.Equal(t, estados, resp.Transitions)
+}
+
+func TestHandleHistorialArchivo_ParametrosInvalidos(t *testing.T) {
+	mockService := &MockArchivoService{}
+	h := handler.NewArchivoHandler(mockService)
+
+	w := httptest.NewRecorder()
+	h.HandleHistorialArchivo(w, nuevaSolicitudHistorial("TUTGMF0001000120240312-0001",
+		"from=ayer&to=2024-03-01&page=0&page_size=1000"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp models.ValidationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	campos := make([]string, 0, len(resp.Violations))
+	for _, v := range resp.Violations {
+		campos = append(campos, v.Field)
+	}
+	assert.Equal(t, []string{"from", "page", "page_size"}, campos)
+}
+
+func TestHandleHistorialArchivo_RangoInvertido(t *testing.T) {
+	h := handler.NewArchivoHandler(&MockArchivoService{})
+
+	w := httptest.NewRecorder()
+	h.HandleHistorialArchivo(w, nuevaSolicitudHistorial("TUTGMF0001000120240312-0001",
+		"from=2024-03-10&to=2024-03-01"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "debe ser posterior a from")
+}
+
+func TestHandleHistorialArchivo_NoEncontrado(t *testing.T) {
+	h := handler.NewArchivoHandler(&MockArchivoService{})
+
+	w := httptest.NewRecorder()
+	h.HandleHistorialArchivo(w, nuevaSolicitudHistorial("TUTGMF0001000120240312-9999", ""))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
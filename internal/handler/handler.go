@@ -1,71 +1,254 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/jobs"
 	"gmf_transmission_response/internal/logs"
 	"gmf_transmission_response/internal/models"
 	"gmf_transmission_response/internal/service"
+	"gmf_transmission_response/internal/validation"
+	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 )
 
+// HeaderRequestID es el encabezado con el ID de la solicitud que se agrega a los logs.
+const HeaderRequestID = "X-Request-Id"
+
+// MaxTamanoSolicitud es el tamaño máximo en bytes del cuerpo de una solicitud de transmisiones,
+// igual al límite de payload de API Gateway.
+const MaxTamanoSolicitud = 10 << 20
+
+// reintentarColaLlena son los segundos que se indican en Retry-After cuando la cola de jobs está llena.
+const reintentarColaLlena = "30"
+
 // ArchivoHandlerInterface define la interfaz para manejar transmisiones
 type ArchivoHandlerInterface interface {
 	HandleTransmisionResponses(w http.ResponseWriter, r *http.Request)
+	HandleConsultarArchivo(w http.ResponseWriter, r *http.Request)
+	HandleHistorialArchivo(w http.ResponseWriter, r *http.Request)
+	HandleConsultarJob(w http.ResponseWriter, r *http.Request)
 }
 
 // ArchivoHandler maneja las solicitudes relacionadas con archivos.
 type ArchivoHandler struct {
 	ArchivoService service.ArchivoServiceInterface
+	Idempotencia   idempotency.StoreInterface
+	Jobs           jobs.ManagerInterface
+}
+
+// HandlerOption configura un ArchivoHandler.
+type HandlerOption func(*ArchivoHandler)
+
+// WithIdempotencia habilita el manejo del encabezado Idempotency-Key usando el store indicado.
+func WithIdempotencia(store idempotency.StoreInterface) HandlerOption {
+	return func(h *ArchivoHandler) {
+		h.Idempotencia = store
+	}
+}
+
+// WithJobs habilita el procesamiento asíncrono con Prefer: respond-async usando el manager indicado.
+// Sin esta opción las solicitudes siempre se procesan de forma síncrona.
+func WithJobs(manager jobs.ManagerInterface) HandlerOption {
+	return func(h *ArchivoHandler) {
+		h.Jobs = manager
+	}
 }
 
 // NewArchivoHandler crea una nueva instancia de ArchivoHandler.
-func NewArchivoHandler(archivoService service.ArchivoServiceInterface) *ArchivoHandler { // Cambia esto a la interfaz
-	return &ArchivoHandler{
+func NewArchivoHandler(archivoService service.ArchivoServiceInterface, opts ...HandlerOption) *ArchivoHandler { // Cambia esto a la interfaz
+	h := &ArchivoHandler{
 		ArchivoService: archivoService,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // HandleTransmisionResponses es el controlador para procesar el array de respuestas de transmisión.
 // Recibe un array de transmisiones a través de API Gateway y procesa cada una de ellas.
 func (h *ArchivoHandler) HandleTransmisionResponses(w http.ResponseWriter, r *http.Request) {
-	var transmisionResponse models.TransmisionResponse
+	ctx := contextoSolicitud(r)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxTamanoSolicitud))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		logs.Warn(ctx, "Cuerpo de la solicitud demasiado grande", slog.Int64("limite", maxBytesErr.Limit))
+		http.Error(w, "Solicitud demasiado grande", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		logs.Error(ctx, "Error al leer el cuerpo de la solicitud", err)
+		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+		return
+	}
+
+	// Reservar la clave de idempotencia antes de procesar; si ya fue procesada, se retorna la respuesta
+	// original sin volver a procesar
+	idempotencyKey := r.Header.Get(idempotency.HeaderIdempotencyKey)
+	reservada := false
+	if idempotencyKey != "" && h.Idempotencia != nil {
+		ctx = logs.With(ctx, slog.String("idempotency_key", idempotencyKey))
+		if len(idempotencyKey) > idempotency.MaxLongitudClave {
+			http.Error(w, "Idempotency-Key inválido", http.StatusBadRequest)
+			return
+		}
+
+		respuesta, err := h.Idempotencia.Reservar(idempotencyKey, body)
+		switch {
+		case errors.Is(err, idempotency.ErrClaveReutilizada):
+			logs.Warn(ctx, "Idempotency-Key reutilizado con otra solicitud")
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, idempotency.ErrSolicitudEnProceso):
+			logs.Warn(ctx, "Solicitud repetida mientras la original está en proceso")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			logs.Error(ctx, "Error al reservar la clave de idempotencia", err)
+		case respuesta != nil:
+			logs.Info(ctx, "Solicitud repetida, se retorna la respuesta original")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(respuesta.StatusCode)
+			w.Write(respuesta.Body)
+			return
+		default:
+			reservada = true
+		}
+	}
+
+	// Las solicitudes que terminan sin una respuesta que deba repetirse liberan la clave para poder reintentarse
+	liberar := func() {
+		if !reservada {
+			return
+		}
+		if err := h.Idempotencia.Liberar(idempotencyKey, body); err != nil {
+			logs.Error(ctx, "Error al liberar la clave de idempotencia", err)
+		}
+	}
+
+	// Validar el esquema completo de la solicitud antes de procesar cualquier archivo
+	transmisionResponse, err := validation.DecodificarTransmisionResponse(body)
+	if err != nil {
+		var validacionErr *validation.ValidacionError
+		if errors.As(err, &validacionErr) {
+			logs.Warn(ctx, "La solicitud no cumple el esquema esperado", slog.String("detalle", err.Error()))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ValidationResponse{
+				Message:    "La solicitud contiene errores de validación",
+				Violations: validacionErr.Violations,
+			})
+			liberar()
+			return
+		}
 
-	if err := json.NewDecoder(r.Body).Decode(&transmisionResponse); err != nil {
-		logs.Logger.LogError("Error al decodificar el cuerpo de la solicitud", err, "N/A")
+		logs.Error(ctx, "Error al decodificar el cuerpo de la solicitud", err)
 		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+		liberar()
 		return
 	}
 
+	var statusCode int
+	var responseBody []byte
+	if h.Jobs != nil && prefiereAsincrono(r) {
+		job, err := h.Jobs.Encolar(ctx, r.Header.Get(HeaderRequestID), transmisionResponse.TransmittedFiles)
+		if errors.Is(err, jobs.ErrColaLlena) {
+			logs.Warn(ctx, "Solicitud asíncrona rechazada, la cola de jobs está llena")
+			w.Header().Set("Retry-After", reintentarColaLlena)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			liberar()
+			return
+		}
+		if err != nil {
+			logs.Error(ctx, "Error al registrar el job de procesamiento asíncrono", err)
+			http.Error(w, "Error al registrar el procesamiento asíncrono", http.StatusInternalServerError)
+			liberar()
+			return
+		}
+
+		statusCode = http.StatusAccepted
+		responseBody, _ = json.Marshal(nuevaJobResponse(job))
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.Header().Set("Preference-Applied", jobs.PreferenciaAsincrona)
+	} else {
+		// Los archivos se procesan aunque el cliente cierre la conexión, la respuesta queda registrada para las
+		// repeticiones con la misma clave de idempotencia
+		statusCode, responseBody = h.procesarTransmisiones(context.WithoutCancel(ctx),
+			transmisionResponse.TransmittedFiles)
+	}
+	responseBody = append(responseBody, '\n')
+
+	// Registrar la respuesta para las repeticiones de la misma clave
+	if reservada {
+		respuesta := idempotency.Respuesta{StatusCode: statusCode, Body: responseBody}
+		if err := h.Idempotencia.Guardar(idempotencyKey, body, respuesta); err != nil {
+			logs.Error(ctx, "Error al guardar la clave de idempotencia", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(responseBody)
+}
+
+// procesarTransmisiones procesa los archivos en la solicitud actual y retorna el código HTTP y el cuerpo de la respuesta.
+func (h *ArchivoHandler) procesarTransmisiones(ctx context.Context, transmittedFiles []models.TransmittedFile) (int, []byte) {
+	resultados := h.ArchivoService.ProcesarTransmisiones(ctx, transmittedFiles)
+
 	var errorCount, successCount int
 
-	for _, transmittedFile := range transmisionResponse.TransmittedFiles {
-		fileName := transmittedFile.FileName
-		if err := h.ArchivoService.ProcesarTransmision(transmittedFile); err != nil {
-			logs.Logger.LogError("Error al procesar archivo transmitido", err, fileName)
+	for _, resultado := range resultados {
+		if resultado.Outcome == models.OutcomeFailed {
+			logs.Error(ctx, "Error al procesar archivo transmitido",
+				fmt.Errorf("%s: %s", resultado.ErrorCode, resultado.Message), logs.FileName(resultado.FileName))
 			errorCount++
 			continue
 		}
-		logs.Logger.LogInfo("Archivo procesado exitosamente", fileName)
+		logs.Info(ctx, "Archivo procesado exitosamente", logs.FileName(resultado.FileName))
 		successCount++
 	}
 
-	logs.Logger.LogInfo(fmt.Sprintf("Archivos procesados correctamente: %d", successCount), "N/A")
-	logs.Logger.LogWarn(fmt.Sprintf("Archivos con errores: %d", errorCount), "N/A")
+	logs.Info(ctx, fmt.Sprintf("Archivos procesados correctamente: %d", successCount))
+	if errorCount > 0 {
+		logs.Warn(ctx, fmt.Sprintf("Archivos con errores: %d", errorCount))
+	}
+
+	response := service.NuevaRespuesta(resultados)
 
-	response := models.Response{
-		TotalFiles: len(transmisionResponse.TransmittedFiles),
-		ErrorCount: errorCount,
-		Success:    errorCount == 0,
+	statusCode := http.StatusOK
+	if !response.Success {
+		statusCode = http.StatusPartialContent
 	}
 
-	if errorCount > 0 {
-		response.Message = fmt.Sprintf("Se procesaron con errores %d de %d archivos", errorCount, len(transmisionResponse.TransmittedFiles))
-		w.WriteHeader(http.StatusPartialContent)
-	} else {
-		response.Message = "Todos los archivos fueron procesados correctamente"
-		w.WriteHeader(http.StatusOK)
+	responseBody, _ := json.Marshal(response)
+	return statusCode, responseBody
+}
+
+// contextoSolicitud retorna el contexto de la solicitud con un logger que agrega su ID a cada mensaje.
+func contextoSolicitud(r *http.Request) context.Context {
+	ctx := r.Context()
+	if requestID := r.Header.Get(HeaderRequestID); requestID != "" {
+		ctx = logs.With(ctx, logs.RequestID(requestID))
 	}
+	return ctx
+}
 
-	json.NewEncoder(w).Encode(response)
+// prefiereAsincrono indica si el encabezado Prefer de la solicitud incluye respond-async.
+func prefiereAsincrono(r *http.Request) bool {
+	for _, valor := range r.Header.Values(jobs.HeaderPrefer) {
+		for _, preferencia := range strings.Split(valor, ",") {
+			if strings.EqualFold(strings.TrimSpace(preferencia), jobs.PreferenciaAsincrona) {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -2,11 +2,15 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/idempotency"
 	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+	"gmf_transmission_response/internal/statemachine"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,12 +21,22 @@ type MockArchivoService struct {
 	ProcessError         bool  // Define si todas las llamadas fallan
 	ErrorInSpecificCalls []int // Define en qué llamadas específicas debe fallar
 	CallCount            int   // Cuenta cuántas veces se ha llamado al servicio
+	ErrorsByFile         map[string]error
+	Consultas            map[string]*models.ArchivoConsulta    // Archivos que retorna ConsultarArchivo
+	ConsultaError        error                                 // Error que retorna ConsultarArchivo
+	Historiales          map[string][]models.CGDArchivoEstados // Transiciones que retorna ConsultarHistorial
+	FiltroRecibido       models.FiltroHistorial                // Último filtro recibido por ConsultarHistorial
 }
 
 // ProcesarTransmision simula el procesamiento de transmisión y falla según lo indicado
-func (m *MockArchivoService) ProcesarTransmision(transmittedFile models.TransmittedFile) error {
+func (m *MockArchivoService) ProcesarTransmision(_ context.Context, transmittedFile models.TransmittedFile) error {
 	m.CallCount++
 
+	// Verificar si el archivo tiene un error específico configurado
+	if err, ok := m.ErrorsByFile[transmittedFile.FileName]; ok {
+		return err
+	}
+
 	// Verificar si la llamada actual debe fallar
 	for _, call := range m.ErrorInSpecificCalls {
 		if m.CallCount == call {
@@ -38,11 +52,90 @@ func (m *MockArchivoService) ProcesarTransmision(transmittedFile models.Transmit
 	return nil
 }
 
+// ProcesarTransmisiones simula el procesamiento de un lote construyendo un resultado por archivo
+func (m *MockArchivoService) ProcesarTransmisiones(
+	ctx context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult {
+	resultados := make([]models.FileResult, 0, len(transmittedFiles))
+	for _, transmittedFile := range transmittedFiles {
+		estado := "ENVIADO"
+		err := m.ProcesarTransmision(ctx, transmittedFile)
+		if err != nil {
+			estado = ""
+		}
+		resultados = append(resultados, service.NuevoFileResult(transmittedFile.FileName, estado, err))
+	}
+	return resultados
+}
+
+// ConsultarArchivo simula la consulta de un archivo, retornando NOT_FOUND si no está en Consultas
+func (m *MockArchivoService) ConsultarArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error) {
+	if m.ConsultaError != nil {
+		return nil, m.ConsultaError
+	}
+	consulta, ok := m.Consultas[acgNombreArchivo]
+	if !ok {
+		return nil, &service.ProcesamientoError{Codigo: service.CodigoNoEncontrado, Err: fmt.Errorf("record not found")}
+	}
+	return consulta, nil
+}
+
+// ConsultarHistorial simula la consulta del histórico de un archivo registrado en Consultas
+func (m *MockArchivoService) ConsultarHistorial(
+	acgNombreArchivo string, filtro models.FiltroHistorial) (*models.HistorialResponse, error) {
+	m.FiltroRecibido = filtro
+	consulta, err := m.ConsultarArchivo(acgNombreArchivo)
+	if err != nil {
+		return nil, err
+	}
+	estados := m.Historiales[acgNombreArchivo]
+	return &models.HistorialResponse{
+		IDArchivo:        consulta.IDArchivo,
+		ACGNombreArchivo: acgNombreArchivo,
+		Total:            int64(len(estados)),
+		Page:             filtro.Pagina,
+		PageSize:         filtro.TamanoPagina,
+		Transitions:      estados,
+	}, nil
+}
+
 // Otros métodos necesarios para cumplir con la interfaz
 func (m *MockArchivoService) RemoveExtension(fileName string) string { return fileName }
 func (m *MockArchivoService) IsAnulacion(fileName string) bool       { return false }
 func (m *MockArchivoService) ValidateIDLength(id string) error       { return nil }
 
+// MockIdempotencyStore es un store de idempotencia en memoria para las pruebas
+type MockIdempotencyStore struct {
+	Respuestas  map[string]idempotency.Respuesta
+	Solicitudes map[string]string
+}
+
+func (m *MockIdempotencyStore) Reservar(clave string, solicitud []byte) (*idempotency.Respuesta, error) {
+	registrada, ok := m.Solicitudes[clave]
+	if !ok {
+		m.Solicitudes[clave] = string(solicitud)
+		return nil, nil
+	}
+	if registrada != string(solicitud) {
+		return nil, idempotency.ErrClaveReutilizada
+	}
+	respuesta, ok := m.Respuestas[clave]
+	if !ok {
+		return nil, idempotency.ErrSolicitudEnProceso
+	}
+	return &respuesta, nil
+}
+
+func (m *MockIdempotencyStore) Guardar(clave string, solicitud []byte, respuesta idempotency.Respuesta) error {
+	m.Respuestas[clave] = respuesta
+	m.Solicitudes[clave] = string(solicitud)
+	return nil
+}
+
+func (m *MockIdempotencyStore) Liberar(clave string, _ []byte) error {
+	delete(m.Solicitudes, clave)
+	return nil
+}
+
 func TestHandleTransmisionResponses_Success(t *testing.T) {
 	// Caso de prueba exitoso, sin errores
 	mockService := &MockArchivoService{
@@ -74,6 +167,7 @@ func TestHandleTransmisionResponses_Success(t *testing.T) {
 
 	// Validar el resultado
 	assert.Equal(t, http.StatusOK, w.Code) // El código de estado debe ser 200
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 	var resp models.Response
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
@@ -81,6 +175,10 @@ func TestHandleTransmisionResponses_Success(t *testing.T) {
 	assert.Equal(t, 1, resp.TotalFiles)
 	assert.Equal(t, 0, resp.ErrorCount)
 	assert.Equal(t, "Todos los archivos fueron procesados correctamente", resp.Message)
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, models.OutcomeProcessed, resp.Results[0].Outcome)
+	assert.Equal(t, "ENVIADO", resp.Results[0].Estado)
+	assert.Empty(t, resp.Results[0].ErrorCode)
 }
 
 func TestHandleTransmisionResponses_PartialFailure(t *testing.T) {
@@ -122,6 +220,7 @@ func TestHandleTransmisionResponses_PartialFailure(t *testing.T) {
 
 	// Validar el resultado
 	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 	var resp models.Response
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
@@ -129,6 +228,72 @@ func TestHandleTransmisionResponses_PartialFailure(t *testing.T) {
 	assert.Equal(t, 2, resp.TotalFiles)                                        // Deben ser 2 archivos
 	assert.Equal(t, 1, resp.ErrorCount)                                        // Debe haber 1 error
 	assert.Equal(t, "Se procesaron con errores 1 de 2 archivos", resp.Message) // Mensaje esperado
+	assert.Len(t, resp.Results, 2)
+	assert.Equal(t, models.OutcomeFailed, resp.Results[0].Outcome)
+	assert.Equal(t, models.OutcomeProcessed, resp.Results[1].Outcome)
+}
+
+func TestHandleTransmisionResponses_ResultadosPorArchivo(t *testing.T) {
+	// Cada archivo falla por una causa distinta para validar los códigos de error
+	mockService := &MockArchivoService{
+		ErrorsByFile: map[string]error{
+			"TUTGMF0001000120240312-0001.txt": &service.ProcesamientoError{
+				Codigo: service.CodigoNoEncontrado, Err: fmt.Errorf("record not found")},
+			"TUTGMF0001000120240312-0002.txt": statemachine.ValidarTransicion("ANULACION_ENVIADA", "ENVIADO"),
+			"TUTGMF0001000120240312-0003.txt": fmt.Errorf("connection refused"),
+			"TUTGMF0001000120240312-0004.txt": &service.ProcesamientoError{
+				Codigo: service.CodigoErrorValidacion, Err: fmt.Errorf("nombre inválido")},
+		},
+	}
+
+	h := handler.NewArchivoHandler(mockService)
+
+	fileNames := []string{
+		"TUTGMF0001000120240312-0001.txt",
+		"TUTGMF0001000120240312-0002.txt",
+		"TUTGMF0001000120240312-0003.txt",
+		"TUTGMF0001000120240312-0004.txt",
+		"TUTGMF0001000120240312-0005.txt",
+	}
+	body := models.TransmisionResponse{}
+	for _, fileName := range fileNames {
+		body.TransmittedFiles = append(body.TransmittedFiles, models.TransmittedFile{
+			FileName: fileName,
+			TransmissionResult: models.TransmissionResult{
+				Status: "SUCCESSFUL",
+				Code:   "0000",
+				Detail: "Transmisión exitosa",
+			},
+		})
+	}
+
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader(bodyBytes))
+	w := httptest.NewRecorder()
+
+	// Ejecutar el handler
+	h.HandleTransmisionResponses(w, req)
+
+	// Validar el resultado
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	var resp models.Response
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.ErrorCount)
+	assert.Len(t, resp.Results, 5)
+
+	// Los resultados deben conservar el orden de la solicitud
+	for i, fileName := range fileNames {
+		assert.Equal(t, fileName, resp.Results[i].FileName)
+	}
+
+	assert.Equal(t, service.CodigoNoEncontrado, resp.Results[0].ErrorCode)
+	assert.Equal(t, service.CodigoTransicionInvalida, resp.Results[1].ErrorCode)
+	assert.Equal(t, service.CodigoErrorBaseDatos, resp.Results[2].ErrorCode)
+	assert.Equal(t, service.CodigoErrorValidacion, resp.Results[3].ErrorCode)
+	assert.Equal(t, "connection refused", resp.Results[2].Message)
+	assert.Equal(t, models.OutcomeProcessed, resp.Results[4].Outcome)
+	assert.Empty(t, resp.Results[4].ErrorCode)
 }
 
 func TestHandleTransmisionResponses_InvalidRequest(t *testing.T) {
@@ -149,3 +314,127 @@ func TestHandleTransmisionResponses_InvalidRequest(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, "Solicitud inválida\n", w.Body.String())
 }
+
+func TestHandleTransmisionResponses_SolicitudDemasiadoGrande(t *testing.T) {
+	mockService := &MockArchivoService{}
+	h := handler.NewArchivoHandler(mockService)
+
+	body := bytes.Repeat([]byte(" "), handler.MaxTamanoSolicitud+1)
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "Solicitud demasiado grande\n", w.Body.String())
+	assert.Equal(t, 0, mockService.CallCount)
+}
+
+func TestHandleTransmisionResponses_ValidationErrors(t *testing.T) {
+	mockService := &MockArchivoService{}
+
+	h := handler.NewArchivoHandler(mockService)
+
+	// Solicitud con varias violaciones del esquema
+	body := `{"transmittedFiles":[
+		{"fileName":"","transmissionResult":{"status":"UNKNOWN","code":"00001","detail":""}},
+		{"fileName":"TUTGMF0001000120240312-0001.txt","extra":"x",
+		 "transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":""}}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+
+	// Ejecutar el handler
+	h.HandleTransmisionResponses(w, req)
+
+	// Validar que se reporten todas las violaciones y no se procese ningún archivo
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp models.ValidationResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Violations, 4)
+	assert.Equal(t, 0, mockService.CallCount)
+}
+
+func TestHandleTransmisionResponses_IdempotencyKey(t *testing.T) {
+	mockService := &MockArchivoService{}
+	store := &MockIdempotencyStore{
+		Respuestas:  map[string]idempotency.Respuesta{},
+		Solicitudes: map[string]string{},
+	}
+
+	h := handler.NewArchivoHandler(mockService, handler.WithIdempotencia(store))
+
+	body := `{"transmittedFiles":[{"fileName":"TUTGMF0001000120240312-0001.txt",
+		"transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":"Transmisión exitosa"}}]}`
+
+	// Primera solicitud: se procesa y se guarda la respuesta
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(body)))
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-1")
+	w := httptest.NewRecorder()
+	h.HandleTransmisionResponses(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, mockService.CallCount)
+
+	// Segunda solicitud con la misma clave: se retorna la respuesta original sin procesar
+	req = httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(body)))
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-1")
+	replay := httptest.NewRecorder()
+	h.HandleTransmisionResponses(replay, req)
+
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, w.Body.String(), replay.Body.String())
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, mockService.CallCount)
+
+	// Misma clave con otro cuerpo: se rechaza
+	req = httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(`{"transmittedFiles":[]}`)))
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-1")
+	conflict := httptest.NewRecorder()
+	h.HandleTransmisionResponses(conflict, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, conflict.Code)
+	assert.Equal(t, 1, mockService.CallCount)
+}
+
+func TestHandleTransmisionResponses_IdempotencyKeyEnProceso(t *testing.T) {
+	mockService := &MockArchivoService{}
+	body := `{"transmittedFiles":[{"fileName":"TUTGMF0001000120240312-0001.txt",
+		"transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":"Transmisión exitosa"}}]}`
+	// La solicitud original reservó la clave y aún no registra su respuesta
+	store := &MockIdempotencyStore{
+		Respuestas:  map[string]idempotency.Respuesta{},
+		Solicitudes: map[string]string{"clave-1": body},
+	}
+
+	h := handler.NewArchivoHandler(mockService, handler.WithIdempotencia(store))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(body)))
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-1")
+	w := httptest.NewRecorder()
+	h.HandleTransmisionResponses(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 0, mockService.CallCount)
+}
+
+func TestHandleTransmisionResponses_IdempotencyKeyLiberadaSiLaSolicitudEsInvalida(t *testing.T) {
+	mockService := &MockArchivoService{}
+	store := &MockIdempotencyStore{
+		Respuestas:  map[string]idempotency.Respuesta{},
+		Solicitudes: map[string]string{},
+	}
+
+	h := handler.NewArchivoHandler(mockService, handler.WithIdempotencia(store))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmision", bytes.NewReader([]byte(`{"transmittedFiles":[]}`)))
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-1")
+	w := httptest.NewRecorder()
+	h.HandleTransmisionResponses(w, req)
+
+	// La respuesta de validación no se registra y la clave queda libre para reintentar la solicitud corregida
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, store.Solicitudes)
+	assert.Empty(t, store.Respuestas)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gmf_transmission_response/connection"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout es el tiempo máximo que se espera por cada dependencia.
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthHandlerInterface define la interfaz para los endpoints de salud.
+type HealthHandlerInterface interface {
+	HandleLive(w http.ResponseWriter, r *http.Request)
+	HandleReady(w http.ResponseWriter, r *http.Request)
+}
+
+// HealthCheck verifica la disponibilidad de una dependencia de la aplicación.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// SecretGetter define el método usado para verificar el acceso al proveedor de secretos.
+type SecretGetter interface {
+	GetSecret(ctx context.Context, secretName string) (map[string]string, error)
+}
+
+// EstadoSecretos lo implementan los proveedores de secretos con caché que reportan la última consulta fallida,
+// porque pueden seguir retornando el valor en caché cuando el proveedor no está disponible.
+type EstadoSecretos interface {
+	ErrorRefresco(secretName string) error
+}
+
+// HealthHandler maneja los endpoints de liveness y readiness.
+type HealthHandler struct {
+	Checks  []HealthCheck
+	Timeout time.Duration
+}
+
+// NewHealthHandler crea una nueva instancia de HealthHandler con las verificaciones indicadas.
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	return &HealthHandler{
+		Checks:  checks,
+		Timeout: timeout,
+	}
+}
+
+// DatabaseCheck verifica la conexión a la base de datos haciendo ping a través del DBManager.
+func DatabaseCheck(dbManager connection.DBManagerInterface) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			db := dbManager.GetDB()
+			if db == nil {
+				return errors.New("la conexión a la base de datos no está inicializada")
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// SecretsCheck verifica que el secreto indicado se pueda obtener. Se usa la caché de secretos del DBManager
+// para no consultar el proveedor en cada verificación; la consulta se cancela al agotarse el tiempo límite.
+// Si la caché implementa EstadoSecretos, la verificación falla mientras la última consulta al proveedor
+// haya fallado, aunque exista un valor en caché.
+func SecretsCheck(secrets SecretGetter, secretName string) HealthCheck {
+	return HealthCheck{
+		Name: "secrets",
+		Check: func(ctx context.Context) error {
+			if secrets == nil {
+				return errors.New("el proveedor de secretos no está inicializado")
+			}
+			if _, err := secrets.GetSecret(ctx, secretName); err != nil {
+				return err
+			}
+			if estado, ok := secrets.(EstadoSecretos); ok {
+				return estado.ErrorRefresco(secretName)
+			}
+			return nil
+		},
+	}
+}
+
+// HandleLive indica que el proceso está en ejecución, sin verificar dependencias.
+func (h *HealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
+	writeHealthResponse(w, http.StatusOK, models.HealthResponse{Status: models.HealthStatusUp})
+}
+
+// HandleReady verifica todas las dependencias y responde 503 si alguna no está disponible.
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	response := models.HealthResponse{
+		Status:       models.HealthStatusUp,
+		Dependencies: make(map[string]models.DependencyStatus, len(h.Checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.Checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			status := h.runCheck(r.Context(), check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Dependencies[check.Name] = status
+			if status.Status != models.HealthStatusUp {
+				response.Status = models.HealthStatusDown
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	statusCode := http.StatusOK
+	if response.Status != models.HealthStatusUp {
+		logs.Logger.LogWarn("La aplicación no está lista para recibir tráfico", "HEALTH_READY")
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	writeHealthResponse(w, statusCode, response)
+}
+
+// runCheck ejecuta una verificación con tiempo límite y mide su latencia.
+func (h *HealthHandler) runCheck(parent context.Context, check HealthCheck) models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(parent, h.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("tiempo de espera agotado después de %s", h.Timeout)
+	}
+
+	status := models.DependencyStatus{
+		Status:    models.HealthStatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		logs.Logger.LogError("Dependencia no disponible: "+check.Name, err, "HEALTH_READY")
+		status.Status = models.HealthStatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// writeHealthResponse escribe la respuesta de salud en formato JSON.
+func writeHealthResponse(w http.ResponseWriter, statusCode int, response models.HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
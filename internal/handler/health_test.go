@@ -0,0 +1,170 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/secrets"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// MockDBManager implementa DBManagerInterface sobre una conexión de sqlmock
+type MockDBManager struct {
+	DB *gorm.DB
+}
+
+func (m *MockDBManager) InitDB() error   { return nil }
+func (m *MockDBManager) CloseDB()        {}
+func (m *MockDBManager) GetDB() *gorm.DB { return m.DB }
+
+// MockSecretGetter simula el proveedor de secretos. Con Bloquear espera a que se cancele el contexto.
+type MockSecretGetter struct {
+	Err      error
+	Bloquear bool
+}
+
+func (m *MockSecretGetter) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	if m.Bloquear {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return map[string]string{}, m.Err
+}
+
+// newMockDBManager crea un DBManager cuya conexión responde a ping según pingErr
+func newMockDBManager(t *testing.T, pingErr error) *MockDBManager {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{DisableAutomaticPing: true})
+	assert.NoError(t, err)
+
+	mock.ExpectPing().WillReturnError(pingErr)
+	return &MockDBManager{DB: gormDB}
+}
+
+func TestHandleLive(t *testing.T) {
+	h := handler.NewHealthHandler(0)
+
+	w := httptest.NewRecorder()
+	h.HandleLive(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"UP"}`, w.Body.String())
+}
+
+func TestHandleReady_AllUp(t *testing.T) {
+	h := handler.NewHealthHandler(time.Second,
+		handler.DatabaseCheck(newMockDBManager(t, nil)),
+		handler.SecretsCheck(&MockSecretGetter{}, "gmf-secret"),
+	)
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.HealthStatusUp, resp.Status)
+	assert.Equal(t, models.HealthStatusUp, resp.Dependencies["database"].Status)
+	assert.Equal(t, models.HealthStatusUp, resp.Dependencies["secrets"].Status)
+}
+
+func TestHandleReady_DependencyDown(t *testing.T) {
+	h := handler.NewHealthHandler(time.Second,
+		handler.DatabaseCheck(newMockDBManager(t, errors.New("connection refused"))),
+		handler.SecretsCheck(&MockSecretGetter{}, "gmf-secret"),
+	)
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.HealthStatusDown, resp.Status)
+	assert.Equal(t, models.HealthStatusDown, resp.Dependencies["database"].Status)
+	assert.Equal(t, "connection refused", resp.Dependencies["database"].Error)
+	assert.Equal(t, models.HealthStatusUp, resp.Dependencies["secrets"].Status)
+}
+
+func TestHandleReady_SecretoEnCacheConProveedorCaido(t *testing.T) {
+	proveedor := &MockSecretGetter{}
+	cache := secrets.NewCachedProvider(proveedor, time.Millisecond)
+	_, err := cache.GetSecret(context.Background(), "gmf-secret")
+	assert.NoError(t, err)
+
+	// El proveedor deja de responder después de que el secreto quedó en caché
+	proveedor.Err = errors.New("secrets manager no disponible")
+	time.Sleep(2 * time.Millisecond)
+	h := handler.NewHealthHandler(time.Second, handler.SecretsCheck(cache, "gmf-secret"))
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.HealthStatusDown, resp.Dependencies["secrets"].Status)
+	assert.Equal(t, "secrets manager no disponible", resp.Dependencies["secrets"].Error)
+}
+
+func TestHandleReady_NotInitialized(t *testing.T) {
+	h := handler.NewHealthHandler(time.Second,
+		handler.DatabaseCheck(&MockDBManager{}),
+		handler.SecretsCheck(nil, "gmf-secret"),
+	)
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.HealthStatusDown, resp.Dependencies["database"].Status)
+	assert.Equal(t, models.HealthStatusDown, resp.Dependencies["secrets"].Status)
+}
+
+func TestHandleReady_SecretsCheckCancelaLaConsulta(t *testing.T) {
+	h := handler.NewHealthHandler(20*time.Millisecond,
+		handler.SecretsCheck(&MockSecretGetter{Bloquear: true}, "gmf-secret"),
+	)
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.HealthStatusDown, resp.Dependencies["secrets"].Status)
+}
+
+func TestHandleReady_Timeout(t *testing.T) {
+	slow := handler.HealthCheck{
+		Name: "slow",
+		Check: func(ctx context.Context) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		},
+	}
+	h := handler.NewHealthHandler(20*time.Millisecond, slow)
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp models.HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Dependencies["slow"].Error, "tiempo de espera agotado")
+	assert.Less(t, resp.Dependencies["slow"].LatencyMs, float64(200))
+}
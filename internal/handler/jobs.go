@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+	"gorm.io/gorm"
+)
+
+// HandleConsultarJob retorna el estado de un job de procesamiento asíncrono y, si ya terminó, su resultado.
+// Responde 404 si el job no existe.
+func (h *ArchivoHandler) HandleConsultarJob(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := r.PathValue("id")
+	job, err := h.Jobs.Consultar(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			escribirJSON(w, http.StatusNotFound, models.ErrorResponse{
+				Code:    service.CodigoNoEncontrado,
+				Message: "El job no existe",
+			})
+			return
+		}
+		logs.Logger.LogError("Error al consultar el job", err, id)
+		escribirJSON(w, http.StatusInternalServerError, models.ErrorResponse{
+			Code:    service.CodigoErrorBaseDatos,
+			Message: "Error al consultar la base de datos",
+		})
+		return
+	}
+
+	escribirJSON(w, http.StatusOK, nuevaJobResponse(job))
+}
+
+// nuevaJobResponse construye la respuesta de un job a partir del registro de CGD_JOBS.
+func nuevaJobResponse(job *models.CGDJob) models.JobResponse {
+	response := models.JobResponse{
+		JobID:      job.ID,
+		Status:     job.Estado,
+		TotalFiles: job.TotalArchivos,
+		CreatedAt:  job.FechaCreacion,
+		UpdatedAt:  job.FechaActualizacion,
+		Error:      job.Error,
+	}
+	if job.Resultado != "" {
+		response.Result = json.RawMessage(job.Resultado)
+	}
+	return response
+}
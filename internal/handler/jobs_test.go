@@ -0,0 +1,194 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/jobs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+	"gorm.io/gorm"
+)
+
+// MockJobManager registra los jobs en memoria sin procesarlos
+type MockJobManager struct {
+	Jobs       map[string]*models.CGDJob
+	Encolados  [][]models.TransmittedFile
+	RequestIDs []string
+	EncolarErr error
+}
+
+func (m *MockJobManager) Encolar(
+	_ context.Context, requestID string, transmittedFiles []models.TransmittedFile) (*models.CGDJob, error) {
+	if m.EncolarErr != nil {
+		return nil, m.EncolarErr
+	}
+	m.Encolados = append(m.Encolados, transmittedFiles)
+	m.RequestIDs = append(m.RequestIDs, requestID)
+	job := &models.CGDJob{
+		ID:            "6f1c2d3e-0000-4000-8000-000000000001",
+		Estado:        models.JobPendiente,
+		TotalArchivos: len(transmittedFiles),
+		FechaCreacion: time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC),
+	}
+	m.Jobs[job.ID] = job
+	return job, nil
+}
+
+func (m *MockJobManager) Consultar(id string) (*models.CGDJob, error) {
+	job, ok := m.Jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return job, nil
+}
+
+const cuerpoAsincrono = `{"transmittedFiles":[{"fileName":"TUTGMF0001000120240312-0001",
+	"transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":"Transmisión exitosa"}}]}`
+
+func TestHandleTransmisionResponses_RespondAsync(t *testing.T) {
+	mockService := &MockArchivoService{}
+	jobManager := &MockJobManager{Jobs: map[string]*models.CGDJob{}}
+	store := &MockIdempotencyStore{Respuestas: map[string]idempotency.Respuesta{}, Solicitudes: map[string]string{}}
+	h := handler.NewArchivoHandler(mockService, handler.WithJobs(jobManager), handler.WithIdempotencia(store))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmission", bytes.NewReader([]byte(cuerpoAsincrono)))
+	req.Header.Set("Prefer", "wait=10, Respond-Async")
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-async")
+	req.Header.Set(handler.HeaderRequestID, "req-async")
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	// La solicitud se acepta sin procesar los archivos en la misma solicitud
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "/jobs/6f1c2d3e-0000-4000-8000-000000000001", w.Header().Get("Location"))
+	assert.Equal(t, "respond-async", w.Header().Get("Preference-Applied"))
+	assert.Equal(t, 0, mockService.CallCount)
+	assert.Len(t, jobManager.Encolados, 1)
+	assert.Equal(t, []string{"req-async"}, jobManager.RequestIDs)
+
+	var resp models.JobResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "6f1c2d3e-0000-4000-8000-000000000001", resp.JobID)
+	assert.Equal(t, models.JobPendiente, resp.Status)
+	assert.Equal(t, 1, resp.TotalFiles)
+
+	// La respuesta 202 queda registrada para la clave de idempotencia
+	assert.Equal(t, http.StatusAccepted, store.Respuestas["clave-async"].StatusCode)
+}
+
+func TestHandleTransmisionResponses_RespondAsyncSinJobs(t *testing.T) {
+	mockService := &MockArchivoService{}
+	h := handler.NewArchivoHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodPost, "/transmission", bytes.NewReader([]byte(cuerpoAsincrono)))
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	// Sin manager de jobs se mantiene el procesamiento síncrono
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, mockService.CallCount)
+}
+
+func TestHandleTransmisionResponses_SincronoPorDefecto(t *testing.T) {
+	mockService := &MockArchivoService{}
+	jobManager := &MockJobManager{Jobs: map[string]*models.CGDJob{}}
+	h := handler.NewArchivoHandler(mockService, handler.WithJobs(jobManager))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmission", bytes.NewReader([]byte(cuerpoAsincrono)))
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, mockService.CallCount)
+	assert.Empty(t, jobManager.Encolados)
+}
+
+func TestHandleTransmisionResponses_RespondAsyncError(t *testing.T) {
+	jobManager := &MockJobManager{EncolarErr: errors.New("conexión perdida")}
+	h := handler.NewArchivoHandler(&MockArchivoService{}, handler.WithJobs(jobManager))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmission", bytes.NewReader([]byte(cuerpoAsincrono)))
+	req.Header.Set("Prefer", "respond-async")
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandleTransmisionResponses_RespondAsyncColaLlena(t *testing.T) {
+	jobManager := &MockJobManager{EncolarErr: jobs.ErrColaLlena}
+	store := &MockIdempotencyStore{Respuestas: map[string]idempotency.Respuesta{}, Solicitudes: map[string]string{}}
+	h := handler.NewArchivoHandler(&MockArchivoService{}, handler.WithJobs(jobManager), handler.WithIdempotencia(store))
+
+	req := httptest.NewRequest(http.MethodPost, "/transmission", bytes.NewReader([]byte(cuerpoAsincrono)))
+	req.Header.Set("Prefer", "respond-async")
+	req.Header.Set(idempotency.HeaderIdempotencyKey, "clave-cola-llena")
+	w := httptest.NewRecorder()
+
+	h.HandleTransmisionResponses(w, req)
+
+	// La solicitud se rechaza sin registrar la respuesta, para que pueda reintentarse con la misma clave
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.NotContains(t, store.Solicitudes, "clave-cola-llena")
+	assert.NotContains(t, store.Respuestas, "clave-cola-llena")
+}
+
+func TestHandleConsultarJob(t *testing.T) {
+	resultado, _ := json.Marshal(service.NuevaRespuesta([]models.FileResult{
+		service.NuevoFileResult("TUTGMF0001000120240312-0001", "ENVIADO", nil),
+	}))
+	jobManager := &MockJobManager{Jobs: map[string]*models.CGDJob{
+		"job-1": {ID: "job-1", Estado: models.JobCompletado, TotalArchivos: 1, Resultado: string(resultado)},
+	}}
+	h := handler.NewArchivoHandler(&MockArchivoService{}, handler.WithJobs(jobManager))
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/job-1", nil)
+	req.SetPathValue("id", "job-1")
+	w := httptest.NewRecorder()
+
+	h.HandleConsultarJob(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		models.JobResponse
+		Result models.Response `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.JobCompletado, resp.Status)
+	assert.True(t, resp.Result.Success)
+	assert.Equal(t, "ENVIADO", resp.Result.Results[0].Estado)
+}
+
+func TestHandleConsultarJob_NoEncontrado(t *testing.T) {
+	h := handler.NewArchivoHandler(&MockArchivoService{},
+		handler.WithJobs(&MockJobManager{Jobs: map[string]*models.CGDJob{}}))
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/no-existe", nil)
+	req.SetPathValue("id", "no-existe")
+	w := httptest.NewRecorder()
+
+	h.HandleConsultarJob(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var resp models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, service.CodigoNoEncontrado, resp.Code)
+}
@@ -0,0 +1,158 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gorm.io/gorm"
+)
+
+// HeaderIdempotencyKey es el encabezado HTTP con el que el gateway identifica una solicitud.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// MaxLongitudClave es la longitud máxima de la columna clave de CGD_IDEMPOTENCIA.
+const MaxLongitudClave = 255
+
+// RetencionPorDefecto es el tiempo que se conservan las claves si no se configura otro valor.
+const RetencionPorDefecto = 24 * time.Hour
+
+// ReservaPorDefecto es el tiempo que una solicitud en proceso reserva su clave. Si la instancia se detiene
+// sin responder, la clave se libera al vencer la reserva en lugar de esperar la ventana de retención.
+const ReservaPorDefecto = 5 * time.Minute
+
+// IntervaloDepuracionPorDefecto es cada cuánto se eliminan las claves expiradas si no se configura otro valor.
+const IntervaloDepuracionPorDefecto = time.Hour
+
+// ErrClaveReutilizada indica que la clave ya fue usada con un cuerpo de solicitud distinto.
+var ErrClaveReutilizada = errors.New("la Idempotency-Key ya fue usada con una solicitud diferente")
+
+// ErrSolicitudEnProceso indica que otra solicitud con la misma clave aún se está procesando.
+var ErrSolicitudEnProceso = errors.New("una solicitud con la misma Idempotency-Key está en proceso")
+
+// Respuesta contiene la respuesta original enviada para una clave de idempotencia.
+type Respuesta struct {
+	StatusCode int
+	Body       []byte
+}
+
+// StoreInterface define los métodos para reservar una clave y registrar su respuesta.
+type StoreInterface interface {
+	Reservar(clave string, solicitud []byte) (*Respuesta, error)
+	Guardar(clave string, solicitud []byte, respuesta Respuesta) error
+	Liberar(clave string, solicitud []byte) error
+}
+
+// Store implementa StoreInterface sobre el repositorio de idempotencia.
+type Store struct {
+	repo      repository.IdempotenciaRepositoryInterface
+	retencion time.Duration
+	reserva   time.Duration
+}
+
+// NewStore crea una nueva instancia de Store con la ventana de retención indicada.
+func NewStore(repo repository.IdempotenciaRepositoryInterface, retencion time.Duration) *Store {
+	if retencion <= 0 {
+		retencion = RetencionPorDefecto
+	}
+	return &Store{
+		repo:      repo,
+		retencion: retencion,
+		reserva:   ReservaPorDefecto,
+	}
+}
+
+// Reservar registra la clave como en proceso antes de procesar la solicitud. Retorna nil si la solicitud
+// reservó la clave y debe procesarse, o la respuesta original si la clave ya se completó. Retorna
+// ErrSolicitudEnProceso si otra solicitud con la clave está en proceso y ErrClaveReutilizada si la clave
+// se registró para una solicitud diferente.
+func (s *Store) Reservar(clave string, solicitud []byte) (*Respuesta, error) {
+	hash := hashSolicitud(solicitud)
+
+	// Si la clave expira o se libera entre la reserva y la consulta, se intenta reservar de nuevo
+	for intento := 0; intento < 2; intento++ {
+		ahora := time.Now()
+		reservada, err := s.repo.ReservarIdempotencia(&models.CGDIdempotencia{
+			Clave:           clave,
+			HashSolicitud:   hash,
+			Estado:          models.IdempotenciaEnProceso,
+			FechaCreacion:   ahora,
+			FechaExpiracion: ahora.Add(s.reserva),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if reservada {
+			return nil, nil
+		}
+
+		registro, err := s.repo.GetIdempotencia(clave, ahora)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if registro.HashSolicitud != hash {
+			return nil, ErrClaveReutilizada
+		}
+		if registro.Estado == models.IdempotenciaEnProceso {
+			return nil, ErrSolicitudEnProceso
+		}
+		return &Respuesta{
+			StatusCode: registro.CodigoRespuesta,
+			Body:       []byte(registro.CuerpoRespuesta),
+		}, nil
+	}
+	return nil, ErrSolicitudEnProceso
+}
+
+// Guardar registra la respuesta de la clave reservada durante la ventana de retención.
+func (s *Store) Guardar(clave string, solicitud []byte, respuesta Respuesta) error {
+	return s.repo.CompletarIdempotencia(&models.CGDIdempotencia{
+		Clave:           clave,
+		HashSolicitud:   hashSolicitud(solicitud),
+		CodigoRespuesta: respuesta.StatusCode,
+		CuerpoRespuesta: string(respuesta.Body),
+		FechaExpiracion: time.Now().Add(s.retencion),
+	})
+}
+
+// Liberar elimina la reserva de una solicitud que terminó sin una respuesta que deba repetirse, para que
+// el cliente pueda reintentarla con la misma clave.
+func (s *Store) Liberar(clave string, solicitud []byte) error {
+	return s.repo.LiberarIdempotencia(clave, hashSolicitud(solicitud))
+}
+
+// Depurar elimina cada intervalo las claves expiradas hasta que ctx sea cancelado.
+func (s *Store) Depurar(ctx context.Context, intervalo time.Duration) {
+	if intervalo <= 0 {
+		intervalo = IntervaloDepuracionPorDefecto
+	}
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ahora := <-ticker.C:
+			if err := s.repo.DeleteIdempotenciaExpirada(ahora); err != nil {
+				logs.Logger.LogWarn("No fue posible eliminar las claves de idempotencia expiradas", "IDEMPOTENCY_PURGE",
+					"detalle", err.Error())
+			}
+		}
+	}
+}
+
+// hashSolicitud calcula el SHA-256 del cuerpo de la solicitud.
+func hashSolicitud(solicitud []byte) string {
+	hash := sha256.Sum256(solicitud)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,180 @@
+package idempotency_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gmf_transmission_response/internal/idempotency"
+	"gmf_transmission_response/internal/models"
+	"gorm.io/gorm"
+)
+
+// MockIdempotenciaRepository es un mock del repositorio de idempotencia
+type MockIdempotenciaRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotenciaRepository) GetIdempotencia(clave string, ahora time.Time) (*models.CGDIdempotencia, error) {
+	args := m.Called(clave, ahora)
+	if registro, ok := args.Get(0).(*models.CGDIdempotencia); ok {
+		return registro, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockIdempotenciaRepository) ReservarIdempotencia(registro *models.CGDIdempotencia) (bool, error) {
+	args := m.Called(registro)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotenciaRepository) CompletarIdempotencia(registro *models.CGDIdempotencia) error {
+	return m.Called(registro).Error(0)
+}
+
+func (m *MockIdempotenciaRepository) LiberarIdempotencia(clave, hashSolicitud string) error {
+	return m.Called(clave, hashSolicitud).Error(0)
+}
+
+func (m *MockIdempotenciaRepository) DeleteIdempotenciaExpirada(ahora time.Time) error {
+	return m.Called(ahora).Error(0)
+}
+
+func TestStore_Reservar_ClaveNueva(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+
+	var reservado *models.CGDIdempotencia
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Run(func(args mock.Arguments) {
+		reservado = args.Get(0).(*models.CGDIdempotencia)
+	}).Return(true, nil)
+
+	respuesta, err := store.Reservar("clave-1", []byte(`{}`))
+
+	assert.NoError(t, err)
+	assert.Nil(t, respuesta)
+	assert.Equal(t, models.IdempotenciaEnProceso, reservado.Estado)
+	// La reserva vence antes que la ventana de retención por si la solicitud no termina
+	assert.Equal(t, idempotency.ReservaPorDefecto, reservado.FechaExpiracion.Sub(reservado.FechaCreacion))
+	mockRepo.AssertNotCalled(t, "GetIdempotencia", mock.Anything, mock.Anything)
+}
+
+func TestStore_GuardarYReservar(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, 2*time.Hour)
+	solicitud := []byte(`{"transmittedFiles":[]}`)
+
+	var guardado *models.CGDIdempotencia
+	mockRepo.On("CompletarIdempotencia", mock.Anything).Run(func(args mock.Arguments) {
+		guardado = args.Get(0).(*models.CGDIdempotencia)
+	}).Return(nil)
+
+	antes := time.Now()
+	err := store.Guardar("clave-1", solicitud, idempotency.Respuesta{StatusCode: 200, Body: []byte(`{"success":true}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "clave-1", guardado.Clave)
+	assert.Equal(t, 200, guardado.CodigoRespuesta)
+	assert.WithinDuration(t, antes.Add(2*time.Hour), guardado.FechaExpiracion, time.Second)
+
+	// La misma solicitud debe retornar la respuesta original
+	guardado.Estado = models.IdempotenciaCompletada
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Return(false, nil)
+	mockRepo.On("GetIdempotencia", "clave-1", mock.Anything).Return(guardado, nil)
+
+	respuesta, err := store.Reservar("clave-1", solicitud)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, respuesta.StatusCode)
+	assert.Equal(t, `{"success":true}`, string(respuesta.Body))
+
+	// Una solicitud distinta con la misma clave debe ser rechazada
+	_, err = store.Reservar("clave-1", []byte(`{"otro":true}`))
+
+	assert.ErrorIs(t, err, idempotency.ErrClaveReutilizada)
+}
+
+func TestStore_Reservar_SolicitudEnProceso(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+	solicitud := []byte(`{}`)
+	hash := sha256.Sum256(solicitud)
+
+	// Otra solicitud con el mismo cuerpo reservó la clave y aún no registra su respuesta
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Return(false, nil)
+	mockRepo.On("GetIdempotencia", "clave-1", mock.Anything).Return(&models.CGDIdempotencia{
+		Clave: "clave-1", HashSolicitud: hex.EncodeToString(hash[:]), Estado: models.IdempotenciaEnProceso,
+	}, nil)
+
+	respuesta, err := store.Reservar("clave-1", solicitud)
+
+	assert.ErrorIs(t, err, idempotency.ErrSolicitudEnProceso)
+	assert.Nil(t, respuesta)
+}
+
+func TestStore_Reservar_ReintentaSiLaClaveExpira(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+
+	// La clave expira entre la reserva y la consulta, por lo que la reserva se intenta de nuevo
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Return(false, nil).Once()
+	mockRepo.On("GetIdempotencia", "clave-1", mock.Anything).Return(nil, gorm.ErrRecordNotFound).Once()
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Return(true, nil).Once()
+
+	respuesta, err := store.Reservar("clave-1", []byte(`{}`))
+
+	assert.NoError(t, err)
+	assert.Nil(t, respuesta)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStore_Reservar_ErrorBaseDatos(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+
+	mockRepo.On("ReservarIdempotencia", mock.Anything).Return(false, errors.New("connection refused"))
+
+	respuesta, err := store.Reservar("clave-1", []byte(`{}`))
+
+	assert.Error(t, err)
+	assert.Nil(t, respuesta)
+}
+
+func TestStore_Liberar(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+
+	mockRepo.On("LiberarIdempotencia", "clave-1", mock.Anything).Return(nil)
+
+	assert.NoError(t, store.Liberar("clave-1", []byte(`{}`)))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStore_Depurar(t *testing.T) {
+	mockRepo := new(MockIdempotenciaRepository)
+	store := idempotency.NewStore(mockRepo, time.Hour)
+
+	// Un error al eliminar las claves expiradas no detiene la depuración
+	eliminaciones := make(chan struct{}, 10)
+	mockRepo.On("DeleteIdempotenciaExpirada", mock.Anything).Return(errors.New("delete error")).Once()
+	mockRepo.On("DeleteIdempotenciaExpirada", mock.Anything).Run(func(mock.Arguments) {
+		eliminaciones <- struct{}{}
+	}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	terminado := make(chan struct{})
+	go func() {
+		store.Depurar(ctx, time.Millisecond)
+		close(terminado)
+	}()
+
+	<-eliminaciones
+	cancel()
+	<-terminado
+	mockRepo.AssertNumberOfCalls(t, "DeleteIdempotenciaExpirada", 2)
+}
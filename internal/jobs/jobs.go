@@ -0,0 +1,475 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/service"
+)
+
+// HeaderPrefer es el encabezado con el que el cliente solicita el procesamiento asíncrono.
+const HeaderPrefer = "Prefer"
+
+// PreferenciaAsincrona es el valor de Prefer que activa el procesamiento asíncrono.
+const PreferenciaAsincrona = "respond-async"
+
+// Valores por defecto de la cantidad de jobs que se procesan en paralelo, de los que esperan un cupo y de
+// la duración de su reserva.
+const (
+	ConcurrenciaPorDefecto = 2
+	ColaPorDefecto         = 100
+	ReservaPorDefecto      = 5 * time.Minute
+)
+
+// Valores por defecto del tiempo que se conservan los jobs terminados y de cada cuánto se eliminan.
+const (
+	RetencionPorDefecto           = 7 * 24 * time.Hour
+	IntervaloDepuracionPorDefecto = time.Hour
+)
+
+// PlazoCancelacion es el tiempo que Esperar espera, después de cancelar los jobs en proceso, a que los
+// liberen para que otra instancia los retome.
+const PlazoCancelacion = 2 * time.Second
+
+// maxLongitudRequestID es la longitud de la columna request_id de CGD_JOBS.
+const maxLongitudRequestID = 100
+
+// ErrColaLlena indica que la instancia ya tiene en proceso o en espera la cantidad máxima de jobs.
+var ErrColaLlena = errors.New("la cola de jobs está llena, intente más tarde")
+
+// errEnCola indica que el job ya está en proceso o en espera en esta instancia.
+var errEnCola = errors.New("el job ya está en la cola")
+
+// ManagerInterface define los métodos para registrar y consultar jobs de procesamiento asíncrono.
+type ManagerInterface interface {
+	Encolar(ctx context.Context, requestID string, transmittedFiles []models.TransmittedFile) (*models.CGDJob, error)
+	Consultar(id string) (*models.CGDJob, error)
+}
+
+// Manager registra las solicitudes asíncronas en CGD_JOBS y las procesa en segundo plano con ArchivoService.
+// Antes de procesar un job lo reserva en CGD_JOBS, por lo que varias instancias pueden reanudar los mismos
+// jobs sin procesarlos dos veces.
+type Manager struct {
+	repo         repository.JobRepositoryInterface
+	service      service.ArchivoServiceInterface
+	owner        string
+	reserva      time.Duration
+	concurrencia int
+	cola         int
+	retencion    time.Duration
+
+	// ctx es el contexto de los jobs en proceso; se cancela cuando Esperar agota su plazo.
+	ctx      context.Context
+	cancelar context.CancelFunc
+	cupos    chan struct{}
+	detener  chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+
+	// plazas limita los jobs en proceso y en espera de un cupo a concurrencia + cola.
+	plazas chan struct{}
+	// mu protege encolados y pendientesSinPlaza.
+	mu sync.Mutex
+	// encolados son los IDs de los jobs que tienen una plaza en esta instancia.
+	encolados map[string]struct{}
+	// pendientesSinPlaza indica que Reanudar dejó jobs pendientes porque la cola estaba llena; se reanudan
+	// cuando se libera una plaza.
+	pendientesSinPlaza bool
+}
+
+// ManagerOption configura un Manager.
+type ManagerOption func(*Manager)
+
+// WithConcurrencia define la cantidad de jobs que se procesan en paralelo; los demás esperan un cupo.
+func WithConcurrencia(concurrencia int) ManagerOption {
+	return func(m *Manager) {
+		if concurrencia > 0 {
+			m.concurrencia = concurrencia
+		}
+	}
+}
+
+// WithCola define cuántos jobs pueden esperar un cupo además de los que están en proceso. Con la cola
+// llena Encolar retorna ErrColaLlena.
+func WithCola(cola int) ManagerOption {
+	return func(m *Manager) {
+		if cola >= 0 {
+			m.cola = cola
+		}
+	}
+}
+
+// WithRetencion define por cuánto tiempo se conservan los jobs terminados antes de que Depurar los elimine.
+func WithRetencion(retencion time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if retencion > 0 {
+			m.retencion = retencion
+		}
+	}
+}
+
+// WithReserva define por cuánto tiempo se reserva un job; la reserva se renueva mientras se procesa.
+func WithReserva(reserva time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if reserva > 0 {
+			m.reserva = reserva
+		}
+	}
+}
+
+// WithOwner define el identificador de la instancia con el que se reservan los jobs.
+func WithOwner(owner string) ManagerOption {
+	return func(m *Manager) {
+		if owner != "" {
+			m.owner = owner
+		}
+	}
+}
+
+// WithContexto define el contexto del que derivan los jobs en proceso. Sus mensajes se registran con el
+// logger de ctx y se cancelan si ctx se cancela.
+func WithContexto(ctx context.Context) ManagerOption {
+	return func(m *Manager) {
+		if ctx != nil {
+			m.ctx = ctx
+		}
+	}
+}
+
+// NewManager crea una nueva instancia de Manager.
+func NewManager(repo repository.JobRepositoryInterface, archivoService service.ArchivoServiceInterface,
+	opts ...ManagerOption) *Manager {
+	m := &Manager{
+		repo:         repo,
+		service:      archivoService,
+		owner:        nuevoOwner(),
+		reserva:      ReservaPorDefecto,
+		concurrencia: ConcurrenciaPorDefecto,
+		cola:         ColaPorDefecto,
+		retencion:    RetencionPorDefecto,
+		ctx:          context.Background(),
+		detener:      make(chan struct{}),
+		encolados:    make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.cupos = make(chan struct{}, m.concurrencia)
+	m.plazas = make(chan struct{}, m.concurrencia+m.cola)
+	m.ctx, m.cancelar = context.WithCancel(m.ctx)
+	return m
+}
+
+// Encolar registra un job PENDING con los archivos recibidos y empieza a procesarlo en segundo plano.
+// El ID de la solicitud se guarda en el job para agregarlo a los logs de su procesamiento. Si la cola está
+// llena retorna ErrColaLlena sin registrar el job.
+func (m *Manager) Encolar(ctx context.Context, requestID string,
+	transmittedFiles []models.TransmittedFile) (*models.CGDJob, error) {
+	solicitud, err := json.Marshal(transmittedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error al serializar la solicitud: %w", err)
+	}
+
+	id, err := nuevoID()
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el ID del job: %w", err)
+	}
+	if err := m.reservar(id, false); err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	job := &models.CGDJob{
+		ID:                 id,
+		Estado:             models.JobPendiente,
+		TotalArchivos:      len(transmittedFiles),
+		Solicitud:          string(solicitud),
+		RequestID:          requestID[:min(len(requestID), maxLongitudRequestID)],
+		FechaCreacion:      ahora,
+		FechaActualizacion: ahora,
+	}
+	if err := m.repo.CreateJob(job); err != nil {
+		m.liberar(id)
+		return nil, err
+	}
+
+	logs.Info(contextoJob(ctx, job.ID), fmt.Sprintf("Job registrado con %d archivos", job.TotalArchivos))
+	m.iniciar(*job)
+	return job, nil
+}
+
+// Consultar obtiene el estado de un job.
+func (m *Manager) Consultar(id string) (*models.CGDJob, error) {
+	return m.repo.GetJob(id)
+}
+
+// Reanudar vuelve a procesar los jobs pendientes y los que quedaron en proceso con la reserva vencida, por
+// ejemplo por un reinicio del servicio. Reprocesar un archivo es seguro porque las respuestas ya
+// registradas no se escriben de nuevo. Si la cola se llena, los demás jobs se reanudan cuando termine
+// alguno de los que están en la cola.
+func (m *Manager) Reanudar() error {
+	pendientes, err := m.repo.GetJobsPendientes(time.Now())
+	if err != nil {
+		return err
+	}
+	for i, job := range pendientes {
+		err := m.reservar(job.ID, true)
+		if errors.Is(err, ErrColaLlena) {
+			logs.Logger.LogInfo(fmt.Sprintf("La cola de jobs está llena, %d jobs pendientes se reanudarán después",
+				len(pendientes)-i), "JOBS_RESUME")
+			return nil
+		}
+		if err != nil {
+			continue
+		}
+		logs.Info(contextoJob(context.Background(), job.ID), "Reanudando job pendiente")
+		m.iniciar(job)
+	}
+	return nil
+}
+
+// Depurar elimina cada intervalo los jobs terminados hace más de la retención configurada, hasta que ctx
+// sea cancelado.
+func (m *Manager) Depurar(ctx context.Context, intervalo time.Duration) {
+	if intervalo <= 0 {
+		intervalo = IntervaloDepuracionPorDefecto
+	}
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ahora := <-ticker.C:
+			if err := m.repo.DeleteJobsTerminados(ahora.Add(-m.retencion)); err != nil {
+				logs.Logger.LogWarn("No fue posible eliminar los jobs terminados", "JOBS_PURGE",
+					"detalle", err.Error())
+			}
+		}
+	}
+}
+
+// Esperar bloquea hasta que terminen los jobs en proceso o hasta que expire ctx. Si ctx expira, los jobs
+// que esperan un cupo ya no se inician y los que están en proceso se cancelan; Esperar espera hasta
+// PlazoCancelacion a que estos últimos vuelvan a PENDING para que otra instancia los retome sin esperar
+// a que venza su reserva.
+func (m *Manager) Esperar(ctx context.Context) error {
+	terminados := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(terminados)
+	}()
+
+	select {
+	case <-terminados:
+		return nil
+	case <-ctx.Done():
+		m.once.Do(func() {
+			close(m.detener)
+			m.cancelar()
+		})
+		select {
+		case <-terminados:
+		case <-time.After(PlazoCancelacion):
+		}
+		return ctx.Err()
+	}
+}
+
+// reservar toma una plaza de la cola para el job. Retorna ErrColaLlena si no hay plazas y errEnCola si el
+// job ya tiene una plaza en esta instancia. Con pendiente, una cola llena se recuerda para reanudar los
+// jobs pendientes cuando se libere una plaza.
+func (m *Manager) reservar(id string, pendiente bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.encolados[id]; ok {
+		return errEnCola
+	}
+	select {
+	case m.plazas <- struct{}{}:
+		m.encolados[id] = struct{}{}
+		return nil
+	default:
+		m.pendientesSinPlaza = m.pendientesSinPlaza || pendiente
+		return ErrColaLlena
+	}
+}
+
+// liberar devuelve la plaza del job. Retorna true si quedaron jobs pendientes esperando una plaza.
+func (m *Manager) liberar(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.encolados, id)
+	<-m.plazas
+	pendientes := m.pendientesSinPlaza
+	m.pendientesSinPlaza = false
+	return pendientes
+}
+
+// iniciar procesa el job, que ya tiene una plaza, en una nueva goroutine cuando haya un cupo disponible.
+// Al terminar libera la plaza y reanuda los jobs pendientes que no tuvieron una.
+func (m *Manager) iniciar(job models.CGDJob) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			if !m.liberar(job.ID) {
+				return
+			}
+			select {
+			case <-m.detener:
+			default:
+				if err := m.Reanudar(); err != nil {
+					logs.Logger.LogError("Error al reanudar los jobs pendientes", err, "JOBS_RESUME")
+				}
+			}
+		}()
+		select {
+		case m.cupos <- struct{}{}:
+		case <-m.detener:
+			return
+		}
+		defer func() { <-m.cupos }()
+
+		// El cupo pudo liberarse después de detener el manager
+		select {
+		case <-m.detener:
+			return
+		default:
+		}
+		m.ejecutar(&job)
+	}()
+}
+
+// ejecutar reserva el job, procesa sus archivos y registra su resultado.
+func (m *Manager) ejecutar(job *models.CGDJob) {
+	ctx := contextoJob(m.ctx, job.ID)
+	if job.RequestID != "" {
+		ctx = logs.With(ctx, logs.RequestID(job.RequestID))
+	}
+	ahora := time.Now()
+	reservado, err := m.repo.ReclamarJob(job.ID, m.owner, ahora.Add(m.reserva), ahora)
+	if err != nil {
+		logs.Error(ctx, "Error al reservar el job", err)
+		return
+	}
+	if !reservado {
+		logs.Info(ctx, "El job ya fue reservado por otra instancia")
+		return
+	}
+	job.Estado = models.JobEnProceso
+	job.Owner = m.owner
+
+	detenerRenovacion := m.renovar(ctx, job.ID)
+	defer detenerRenovacion()
+
+	var transmittedFiles []models.TransmittedFile
+	if err := json.Unmarshal([]byte(job.Solicitud), &transmittedFiles); err != nil {
+		logs.Error(ctx, "Error al leer la solicitud del job", err)
+		job.Error = fmt.Sprintf("error al leer la solicitud: %v", err)
+		m.actualizar(ctx, job, models.JobFallido)
+		return
+	}
+
+	resultados := m.service.ProcesarTransmisiones(ctx, transmittedFiles)
+	if ctx.Err() != nil {
+		// Los archivos que no alcanzaron a procesarse se procesan al reanudar el job
+		logs.Warn(ctx, "Job interrumpido por el cierre del servicio, queda pendiente para reanudarse")
+		m.actualizar(ctx, job, models.JobPendiente)
+		return
+	}
+
+	response := service.NuevaRespuesta(resultados)
+	resultado, err := json.Marshal(response)
+	if err != nil {
+		logs.Error(ctx, "Error al serializar el resultado del job", err)
+		job.Error = fmt.Sprintf("error al serializar el resultado: %v", err)
+		m.actualizar(ctx, job, models.JobFallido)
+		return
+	}
+
+	job.Resultado = string(resultado)
+	m.actualizar(ctx, job, models.JobCompletado)
+	logs.Info(ctx, fmt.Sprintf("Job completado, archivos con errores: %d", response.ErrorCount))
+}
+
+// renovar extiende la reserva del job periódicamente hasta que se llame a la función retornada.
+func (m *Manager) renovar(ctx context.Context, id string) func() {
+	fin := make(chan struct{})
+	terminado := make(chan struct{})
+	go func() {
+		defer close(terminado)
+		ticker := time.NewTicker(max(m.reserva/3, time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-fin:
+				return
+			case ahora := <-ticker.C:
+				if err := m.repo.RenovarJob(id, m.owner, ahora.Add(m.reserva)); err != nil {
+					logs.Error(ctx, "Error al renovar la reserva del job", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(fin)
+		<-terminado
+	}
+}
+
+// actualizar registra el nuevo estado del job. Los errores solo se registran en el log,
+// el job se vuelve a procesar al reanudar si no quedó COMPLETED.
+func (m *Manager) actualizar(ctx context.Context, job *models.CGDJob, estado string) {
+	job.Estado = estado
+	job.FechaActualizacion = time.Now()
+	if err := m.repo.UpdateJob(job); err != nil {
+		if errors.Is(err, repository.ErrJobSinReserva) {
+			logs.Warn(ctx, fmt.Sprintf("No se registró el estado %s, otra instancia retomó el job", estado))
+			return
+		}
+		logs.Error(ctx, fmt.Sprintf("Error al actualizar el job a %s", estado), err)
+	}
+}
+
+// contextoJob retorna ctx con un logger que agrega el ID del job a cada mensaje.
+func contextoJob(ctx context.Context, id string) context.Context {
+	return logs.With(ctx, slog.String("job_id", id))
+}
+
+// nuevoID genera un UUID versión 4.
+func nuevoID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// nuevoOwner genera el identificador de la instancia con el nombre del host y un sufijo aleatorio, para
+// distinguir varias instancias en el mismo host.
+func nuevoOwner() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "gmf"
+	}
+	if len(host) > 80 {
+		host = host[:80]
+	}
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%s-%x", host, b)
+}
@@ -0,0 +1,508 @@
+package jobs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/jobs"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/service"
+	"gorm.io/gorm"
+)
+
+// MockJobRepository es un repositorio de jobs en memoria para las pruebas
+type MockJobRepository struct {
+	mu        sync.Mutex
+	Jobs      map[string]models.CGDJob
+	Estados   map[string][]string // Estados por los que pasó cada job
+	CreateErr error
+
+	Renovaciones int
+	Depuraciones []time.Time // Fechas límite con las que se eliminaron jobs terminados
+}
+
+func NewMockJobRepository() *MockJobRepository {
+	return &MockJobRepository{Jobs: map[string]models.CGDJob{}, Estados: map[string][]string{}}
+}
+
+func (m *MockJobRepository) CreateJob(job *models.CGDJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.CreateErr != nil {
+		return m.CreateErr
+	}
+	m.Jobs[job.ID] = *job
+	m.Estados[job.ID] = append(m.Estados[job.ID], job.Estado)
+	return nil
+}
+
+func (m *MockJobRepository) UpdateJob(job *models.CGDJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Jobs[job.ID].Owner != job.Owner {
+		return repository.ErrJobSinReserva
+	}
+	job.LeaseUntil = m.Jobs[job.ID].LeaseUntil
+	m.Jobs[job.ID] = *job
+	m.Estados[job.ID] = append(m.Estados[job.ID], job.Estado)
+	return nil
+}
+
+func (m *MockJobRepository) GetJob(id string) (*models.CGDJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.Jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+// disponible indica si ninguna instancia tiene reservado el job, igual que la consulta de GormJobRepository.
+func disponible(job models.CGDJob, ahora time.Time) bool {
+	return job.Estado == models.JobPendiente ||
+		(job.Estado == models.JobEnProceso && (job.LeaseUntil == nil || job.LeaseUntil.Before(ahora)))
+}
+
+func (m *MockJobRepository) GetJobsPendientes(ahora time.Time) ([]models.CGDJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var pendientes []models.CGDJob
+	for _, job := range m.Jobs {
+		if disponible(job, ahora) {
+			pendientes = append(pendientes, job)
+		}
+	}
+	return pendientes, nil
+}
+
+func (m *MockJobRepository) ReclamarJob(id, owner string, hasta, ahora time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.Jobs[id]
+	if !ok || !disponible(job, ahora) {
+		return false, nil
+	}
+	job.Estado = models.JobEnProceso
+	job.Owner = owner
+	job.LeaseUntil = &hasta
+	m.Jobs[id] = job
+	m.Estados[id] = append(m.Estados[id], job.Estado)
+	return true, nil
+}
+
+func (m *MockJobRepository) RenovarJob(id, owner string, hasta time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job := m.Jobs[id]
+	if job.Owner != owner {
+		return repository.ErrJobSinReserva
+	}
+	job.LeaseUntil = &hasta
+	m.Jobs[id] = job
+	m.Renovaciones++
+	return nil
+}
+
+func (m *MockJobRepository) DeleteJobsTerminados(antesDe time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, job := range m.Jobs {
+		terminado := job.Estado == models.JobCompletado || job.Estado == models.JobFallido
+		if terminado && job.FechaActualizacion.Before(antesDe) {
+			delete(m.Jobs, id)
+		}
+	}
+	m.Depuraciones = append(m.Depuraciones, antesDe)
+	return nil
+}
+
+// MockArchivoService simula el servicio, solo implementa ProcesarTransmisiones
+type MockArchivoService struct {
+	service.ArchivoServiceInterface
+	mu        sync.Mutex
+	Recibidos [][]models.TransmittedFile
+}
+
+func (m *MockArchivoService) ProcesarTransmisiones(
+	_ context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult {
+	m.mu.Lock()
+	m.Recibidos = append(m.Recibidos, transmittedFiles)
+	m.mu.Unlock()
+
+	resultados := make([]models.FileResult, 0, len(transmittedFiles))
+	for _, transmittedFile := range transmittedFiles {
+		var err error
+		if transmittedFile.TransmissionResult.Status == models.StatusError {
+			err = errors.New("mock error")
+		}
+		resultados = append(resultados, service.NuevoFileResult(transmittedFile.FileName, "ENVIADO", err))
+	}
+	return resultados
+}
+
+func TestEncolar(t *testing.T) {
+	repo := NewMockJobRepository()
+	mockService := &MockArchivoService{}
+	manager := jobs.NewManager(repo, mockService)
+
+	transmittedFiles := []models.TransmittedFile{
+		{FileName: "TUTGMF0001000120240312-0001", TransmissionResult: models.TransmissionResult{Status: "SUCCESSFUL"}},
+		{FileName: "TUTGMF0001000120240312-0002", TransmissionResult: models.TransmissionResult{Status: "ERROR"}},
+	}
+
+	job, err := manager.Encolar(context.Background(), "req-1", transmittedFiles)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, job.ID)
+	assert.Equal(t, models.JobPendiente, job.Estado)
+	assert.Equal(t, 2, job.TotalArchivos)
+
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	guardado, err := manager.Consultar(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobCompletado, guardado.Estado)
+	assert.Equal(t, []string{models.JobPendiente, models.JobEnProceso, models.JobCompletado}, repo.Estados[job.ID])
+	assert.Equal(t, [][]models.TransmittedFile{transmittedFiles}, mockService.Recibidos)
+
+	var response models.Response
+	assert.NoError(t, json.Unmarshal([]byte(guardado.Resultado), &response))
+	assert.Equal(t, 2, response.TotalFiles)
+	assert.Equal(t, 1, response.ErrorCount)
+	assert.False(t, response.Success)
+	assert.Len(t, response.Results, 2)
+}
+
+func TestEncolar_LogConJobIDYRequestID(t *testing.T) {
+	var salida bytes.Buffer
+	ctx := logs.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&salida, nil)))
+	manager := jobs.NewManager(NewMockJobRepository(), &MockArchivoService{}, jobs.WithContexto(ctx))
+
+	job, err := manager.Encolar(context.Background(), "req-1",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	// El procesamiento en segundo plano registra el ID del job y el de la solicitud que lo creó
+	assert.Equal(t, "req-1", job.RequestID)
+	assert.Contains(t, salida.String(), `"msg":"Job completado, archivos con errores: 0"`)
+	assert.Contains(t, salida.String(), `"job_id":"`+job.ID+`"`)
+	assert.Contains(t, salida.String(), `"request_id":"req-1"`)
+	assert.NotContains(t, salida.String(), `"file_name"`)
+}
+
+func TestEncolar_ErrorAlRegistrar(t *testing.T) {
+	repo := NewMockJobRepository()
+	repo.CreateErr = errors.New("conexión perdida")
+	mockService := &MockArchivoService{}
+	manager := jobs.NewManager(repo, mockService)
+
+	job, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	assert.Nil(t, job)
+	assert.EqualError(t, err, "conexión perdida")
+	assert.Empty(t, mockService.Recibidos)
+}
+
+func TestReanudar(t *testing.T) {
+	repo := NewMockJobRepository()
+	repo.Jobs["pendiente"] = models.CGDJob{
+		ID:        "pendiente",
+		Estado:    models.JobPendiente,
+		Solicitud: `[{"fileName":"TUTGMF0001000120240312-0001","transmissionResult":{"status":"SUCCESSFUL"}}]`,
+	}
+	vencida := time.Now().Add(-time.Minute)
+	vigente := time.Now().Add(time.Hour)
+	repo.Jobs["interrumpido"] = models.CGDJob{
+		ID:         "interrumpido",
+		Estado:     models.JobEnProceso,
+		Owner:      "instancia-detenida",
+		LeaseUntil: &vencida,
+		Solicitud:  `no es json`,
+	}
+	repo.Jobs["en-otra-instancia"] = models.CGDJob{
+		ID:         "en-otra-instancia",
+		Estado:     models.JobEnProceso,
+		Owner:      "otra-instancia",
+		LeaseUntil: &vigente,
+		Solicitud:  `[]`,
+	}
+	repo.Jobs["completado"] = models.CGDJob{ID: "completado", Estado: models.JobCompletado, Solicitud: `[]`}
+	mockService := &MockArchivoService{}
+	manager := jobs.NewManager(repo, mockService)
+
+	assert.NoError(t, manager.Reanudar())
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	assert.Equal(t, models.JobCompletado, repo.Jobs["pendiente"].Estado)
+	assert.Equal(t, models.JobFallido, repo.Jobs["interrumpido"].Estado)
+	assert.Contains(t, repo.Jobs["interrumpido"].Error, "error al leer la solicitud")
+	assert.Empty(t, repo.Estados["completado"])
+	assert.Empty(t, repo.Estados["en-otra-instancia"])
+	assert.Equal(t, "otra-instancia", repo.Jobs["en-otra-instancia"].Owner)
+	assert.Len(t, mockService.Recibidos, 1)
+}
+
+func TestReanudar_VariasInstanciasProcesanCadaJobUnaVez(t *testing.T) {
+	repo := NewMockJobRepository()
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		repo.Jobs[id] = models.CGDJob{
+			ID:        id,
+			Estado:    models.JobPendiente,
+			Solicitud: `[{"fileName":"TUTGMF0001000120240312-0001","transmissionResult":{"status":"SUCCESSFUL"}}]`,
+		}
+	}
+	mockService := &MockArchivoService{}
+	instancias := []*jobs.Manager{
+		jobs.NewManager(repo, mockService, jobs.WithOwner("instancia-1")),
+		jobs.NewManager(repo, mockService, jobs.WithOwner("instancia-2")),
+	}
+
+	for _, manager := range instancias {
+		assert.NoError(t, manager.Reanudar())
+	}
+	for _, manager := range instancias {
+		assert.NoError(t, manager.Esperar(context.Background()))
+	}
+
+	// Cada job se reserva una sola vez, por lo que se procesa una sola vez
+	assert.Len(t, mockService.Recibidos, 3)
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		assert.Equal(t, []string{models.JobEnProceso, models.JobCompletado}, repo.Estados[id])
+	}
+}
+
+// ServicioBloqueante registra cuántos jobs se procesan al mismo tiempo y bloquea hasta que se cierre Liberar.
+type ServicioBloqueante struct {
+	service.ArchivoServiceInterface
+	Liberar  chan struct{}
+	Iniciado chan struct{}
+	activos  atomic.Int32
+	maximo   atomic.Int32
+}
+
+func NuevoServicioBloqueante() *ServicioBloqueante {
+	return &ServicioBloqueante{Liberar: make(chan struct{}), Iniciado: make(chan struct{}, 100)}
+}
+
+func (s *ServicioBloqueante) ProcesarTransmisiones(ctx context.Context, _ []models.TransmittedFile) []models.FileResult {
+	activos := s.activos.Add(1)
+	for {
+		maximo := s.maximo.Load()
+		if activos <= maximo || s.maximo.CompareAndSwap(maximo, activos) {
+			break
+		}
+	}
+	s.Iniciado <- struct{}{}
+	select {
+	case <-s.Liberar:
+	case <-ctx.Done():
+	}
+	s.activos.Add(-1)
+	return nil
+}
+
+func TestEncolar_LimitaLosJobsEnParalelo(t *testing.T) {
+	repo := NewMockJobRepository()
+	servicio := NuevoServicioBloqueante()
+	manager := jobs.NewManager(repo, servicio, jobs.WithConcurrencia(2))
+
+	for i := 0; i < 5; i++ {
+		_, err := manager.Encolar(context.Background(), "",
+			[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+		assert.NoError(t, err)
+	}
+	<-servicio.Iniciado
+	<-servicio.Iniciado
+	close(servicio.Liberar)
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	assert.Equal(t, int32(2), servicio.maximo.Load())
+	for id := range repo.Jobs {
+		assert.Equal(t, models.JobCompletado, repo.Jobs[id].Estado)
+	}
+}
+
+func TestEncolar_ColaLlena(t *testing.T) {
+	repo := NewMockJobRepository()
+	servicio := NuevoServicioBloqueante()
+	manager := jobs.NewManager(repo, servicio, jobs.WithConcurrencia(1), jobs.WithCola(1))
+
+	for i := 0; i < 2; i++ {
+		_, err := manager.Encolar(context.Background(), "",
+			[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+		assert.NoError(t, err)
+	}
+	<-servicio.Iniciado
+
+	// Con un job en proceso y otro esperando un cupo, el siguiente se rechaza sin registrarlo
+	job, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.Nil(t, job)
+	assert.ErrorIs(t, err, jobs.ErrColaLlena)
+	repo.mu.Lock()
+	assert.Len(t, repo.Jobs, 2)
+	repo.mu.Unlock()
+
+	// Al terminar los jobs se liberan sus plazas
+	close(servicio.Liberar)
+	assert.Eventually(t, func() bool {
+		_, err := manager.Encolar(context.Background(), "",
+			[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, manager.Esperar(context.Background()))
+}
+
+func TestReanudar_ColaLlenaReanudaAlLiberarPlaza(t *testing.T) {
+	repo := NewMockJobRepository()
+	for _, id := range []string{"job-1", "job-2", "job-3", "job-4"} {
+		repo.Jobs[id] = models.CGDJob{
+			ID:        id,
+			Estado:    models.JobPendiente,
+			Solicitud: `[{"fileName":"TUTGMF0001000120240312-0001","transmissionResult":{"status":"SUCCESSFUL"}}]`,
+		}
+	}
+	mockService := &MockArchivoService{}
+	manager := jobs.NewManager(repo, mockService, jobs.WithConcurrencia(1), jobs.WithCola(0))
+
+	// Solo hay una plaza; los demás jobs se reanudan a medida que terminan los anteriores
+	assert.NoError(t, manager.Reanudar())
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		for _, job := range repo.Jobs {
+			if job.Estado != models.JobCompletado {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	assert.Len(t, mockService.Recibidos, 4)
+}
+
+func TestDepurar(t *testing.T) {
+	repo := NewMockJobRepository()
+	ahora := time.Now()
+	repo.Jobs["antiguo"] = models.CGDJob{
+		ID: "antiguo", Estado: models.JobCompletado, FechaActualizacion: ahora.Add(-2 * time.Hour)}
+	repo.Jobs["fallido"] = models.CGDJob{
+		ID: "fallido", Estado: models.JobFallido, FechaActualizacion: ahora.Add(-2 * time.Hour)}
+	repo.Jobs["reciente"] = models.CGDJob{ID: "reciente", Estado: models.JobCompletado, FechaActualizacion: ahora}
+	repo.Jobs["pendiente"] = models.CGDJob{
+		ID: "pendiente", Estado: models.JobPendiente, FechaActualizacion: ahora.Add(-2 * time.Hour)}
+	manager := jobs.NewManager(repo, &MockArchivoService{}, jobs.WithRetencion(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	terminado := make(chan struct{})
+	go func() {
+		manager.Depurar(ctx, 5*time.Millisecond)
+		close(terminado)
+	}()
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return len(repo.Depuraciones) > 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-terminado
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), repo.Depuraciones[0], time.Second)
+	assert.NotContains(t, repo.Jobs, "antiguo")
+	assert.NotContains(t, repo.Jobs, "fallido")
+	assert.Contains(t, repo.Jobs, "reciente")
+	assert.Contains(t, repo.Jobs, "pendiente")
+}
+
+func TestEsperar_ContextoVencidoNoIniciaJobsEnEspera(t *testing.T) {
+	repo := NewMockJobRepository()
+	servicio := NuevoServicioBloqueante()
+	manager := jobs.NewManager(repo, servicio, jobs.WithConcurrencia(1))
+
+	enProceso, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.NoError(t, err)
+	<-servicio.Iniciado
+	enEspera, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0002"}})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, manager.Esperar(ctx), context.Canceled)
+
+	// El job en proceso se cancela y vuelve a PENDING; el que esperaba un cupo no se inicia
+	assert.NoError(t, manager.Esperar(context.Background()))
+	assert.Equal(t, []string{models.JobPendiente, models.JobEnProceso, models.JobPendiente}, repo.Estados[enProceso.ID])
+	assert.Equal(t, []string{models.JobPendiente}, repo.Estados[enEspera.ID])
+}
+
+func TestEjecutar_RenuevaLaReserva(t *testing.T) {
+	repo := NewMockJobRepository()
+	servicio := NuevoServicioBloqueante()
+	manager := jobs.NewManager(repo, servicio, jobs.WithReserva(30*time.Millisecond))
+
+	job, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.NoError(t, err)
+	<-servicio.Iniciado
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return repo.Renovaciones >= 2
+	}, time.Second, 5*time.Millisecond)
+	close(servicio.Liberar)
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	assert.Equal(t, models.JobCompletado, repo.Jobs[job.ID].Estado)
+}
+
+func TestActualizar_ReservaRetomadaPorOtraInstancia(t *testing.T) {
+	repo := NewMockJobRepository()
+	servicio := NuevoServicioBloqueante()
+	manager := jobs.NewManager(repo, servicio, jobs.WithOwner("instancia-1"))
+
+	job, err := manager.Encolar(context.Background(), "",
+		[]models.TransmittedFile{{FileName: "TUTGMF0001000120240312-0001"}})
+	assert.NoError(t, err)
+	<-servicio.Iniciado
+
+	// Otra instancia retoma el job mientras esta lo procesa
+	repo.mu.Lock()
+	retomado := repo.Jobs[job.ID]
+	retomado.Owner = "instancia-2"
+	repo.Jobs[job.ID] = retomado
+	repo.mu.Unlock()
+
+	close(servicio.Liberar)
+	assert.NoError(t, manager.Esperar(context.Background()))
+
+	// El resultado de la instancia que perdió la reserva no se registra
+	assert.Equal(t, models.JobEnProceso, repo.Jobs[job.ID].Estado)
+	assert.Equal(t, "instancia-2", repo.Jobs[job.ID].Owner)
+}
+
+func TestConsultar_NoEncontrado(t *testing.T) {
+	manager := jobs.NewManager(NewMockJobRepository(), &MockArchivoService{})
+
+	job, err := manager.Consultar("no-existe")
+
+	assert.Nil(t, job)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
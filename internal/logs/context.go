@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Claves de los atributos comunes de los logs.
+const (
+	KeyFileName  = "file_name"
+	KeyRequestID = "request_id"
+	KeyIDArchivo = "id_archivo"
+	KeyEstado    = "estado"
+	KeyError     = "error"
+)
+
+// FileName retorna el atributo con el nombre del archivo transmitido.
+func FileName(fileName string) slog.Attr {
+	return slog.String(KeyFileName, fileName)
+}
+
+// RequestID retorna el atributo con el ID de la solicitud.
+func RequestID(requestID string) slog.Attr {
+	return slog.String(KeyRequestID, requestID)
+}
+
+// IDArchivo retorna el atributo con el ID del archivo en CGD_ARCHIVOS.
+func IDArchivo(idArchivo int64) slog.Attr {
+	return slog.Int64(KeyIDArchivo, idArchivo)
+}
+
+// Estado retorna el atributo con el estado del archivo.
+func Estado(estado string) slog.Attr {
+	return slog.String(KeyEstado, estado)
+}
+
+// Err retorna el atributo con el mensaje del error.
+func Err(err error) slog.Attr {
+	return slog.String(KeyError, err.Error())
+}
+
+// claveLogger es la clave del logger en el contexto.
+type claveLogger struct{}
+
+// WithLogger retorna una copia del contexto que lleva el logger indicado.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, claveLogger{}, logger)
+}
+
+// With retorna una copia del contexto cuyo logger agrega los atributos indicados a cada mensaje.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	args := make([]any, 0, len(attrs))
+	for _, attr := range attrs {
+		args = append(args, attr)
+	}
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// FromContext retorna el logger del contexto o, si no tiene uno, el logger de la aplicación.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(claveLogger{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return base()
+}
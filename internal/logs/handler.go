@@ -0,0 +1,197 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Claves de los campos fijos de cada mensaje.
+const (
+	keyTimestamp  = "timestamp"
+	keyLevel      = "level"
+	keyModuleName = "module_name"
+	keyLineNumber = "line_number"
+	keyMessage    = "message"
+)
+
+// formatoTimestamp es el formato de la fecha de los mensajes, con milisegundos.
+const formatoTimestamp = "2006-01-02 15:04:05.000"
+
+// zonaHoraria es la zona horaria de Colombia, con la que se registran las fechas.
+var zonaHoraria = sync.OnceValue(func() *time.Location {
+	location, err := time.LoadLocation("America/Bogota")
+	if err != nil {
+		return time.UTC
+	}
+	return location
+})
+
+// salidaEstandar escribe en os.Stdout al momento de cada mensaje, lo que permite redirigirla en las pruebas.
+type salidaEstandar struct{}
+
+func (salidaEstandar) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// nuevoManejador crea el handler de slog del formato indicado; los formatos desconocidos usan STRING.
+func nuevoManejador(cfg Config, w io.Writer) slog.Handler {
+	nivel := ParseLevel(cfg.Level)
+	if strings.EqualFold(cfg.Format, "JSON") {
+		return &manejadorOrigen{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       nivel,
+			ReplaceAttr: reemplazarCamposJSON,
+		})}
+	}
+	return &manejadorTexto{w: w, nivel: nivel, mu: &sync.Mutex{}}
+}
+
+// reemplazarCamposJSON usa los nombres y formatos de los campos fijos de los logs de la aplicación.
+func reemplazarCamposJSON(grupos []string, attr slog.Attr) slog.Attr {
+	if len(grupos) > 0 {
+		return attr
+	}
+	switch attr.Key {
+	case slog.TimeKey:
+		return slog.String(keyTimestamp, timestamp(attr.Value.Time()))
+	case slog.LevelKey:
+		return slog.String(keyLevel, nombreNivel(attr.Value.Any().(slog.Level)))
+	case slog.MessageKey:
+		return slog.String(keyMessage, attr.Value.String())
+	}
+	return attr
+}
+
+// timestamp da formato a la fecha de un mensaje en la zona horaria de Colombia.
+func timestamp(t time.Time) string {
+	return t.In(zonaHoraria()).Format(formatoTimestamp)
+}
+
+// origen obtiene el archivo y la línea desde donde se registró el mensaje.
+func origen(pc uintptr) (string, int) {
+	if pc == 0 {
+		return "???", 0
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return filepath.Base(frame.File), frame.Line
+}
+
+// manejadorOrigen agrega al mensaje el archivo y la línea desde donde se registró.
+type manejadorOrigen struct {
+	slog.Handler
+}
+
+func (h *manejadorOrigen) Handle(ctx context.Context, r slog.Record) error {
+	modulo, linea := origen(r.PC)
+	r = r.Clone()
+	r.AddAttrs(slog.String(keyModuleName, modulo), slog.Int(keyLineNumber, linea))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *manejadorOrigen) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &manejadorOrigen{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *manejadorOrigen) WithGroup(name string) slog.Handler {
+	return &manejadorOrigen{Handler: h.Handler.WithGroup(name)}
+}
+
+// manejadorTexto escribe cada mensaje en una línea de texto con el formato
+// "timestamp [LEVEL] [archivo:línea] [FileName: nombre] mensaje clave=valor".
+type manejadorTexto struct {
+	w     io.Writer
+	nivel slog.Level
+	attrs []slog.Attr
+	grupo string
+	mu    *sync.Mutex
+}
+
+func (h *manejadorTexto) Enabled(_ context.Context, nivel slog.Level) bool {
+	return nivel >= h.nivel
+}
+
+func (h *manejadorTexto) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, h.conGrupo(attr))
+		return true
+	})
+
+	fileName := "N/A"
+	var extra strings.Builder
+	for _, attr := range attrs {
+		if attr.Key == KeyFileName {
+			fileName = attr.Value.String()
+			continue
+		}
+		escribirAtributo(&extra, "", attr)
+	}
+
+	modulo, linea := origen(r.PC)
+	mensaje := fmt.Sprintf("%s [%s] [%s:%d] [FileName: %s] %s%s\n",
+		timestamp(r.Time), nombreNivel(r.Level), modulo, linea, fileName, r.Message, extra.String())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, mensaje)
+	return err
+}
+
+func (h *manejadorTexto) WithAttrs(attrs []slog.Attr) slog.Handler {
+	copia := *h
+	copia.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		copia.attrs = append(copia.attrs, h.conGrupo(attr))
+	}
+	return &copia
+}
+
+func (h *manejadorTexto) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	copia := *h
+	if h.grupo != "" {
+		name = h.grupo + "." + name
+	}
+	copia.grupo = name
+	return &copia
+}
+
+// conGrupo antepone el grupo actual a la clave del atributo.
+func (h *manejadorTexto) conGrupo(attr slog.Attr) slog.Attr {
+	if h.grupo != "" {
+		attr.Key = h.grupo + "." + attr.Key
+	}
+	return attr
+}
+
+// escribirAtributo escribe el atributo como " clave=valor", expandiendo los grupos.
+func escribirAtributo(b *strings.Builder, prefijo string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefijo += attr.Key + "."
+		}
+		for _, miembro := range attr.Value.Group() {
+			escribirAtributo(b, prefijo, miembro)
+		}
+		return
+	}
+	valor := attr.Value.String()
+	if valor == "" || strings.ContainsAny(valor, " =\"\n") {
+		valor = strconv.Quote(valor)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefijo, attr.Key, valor)
+}
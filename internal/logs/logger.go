@@ -1,14 +1,90 @@
 package logs
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// Config contiene la configuración del logger.
+type Config struct {
+	// Format es JSON o STRING.
+	Format string
+	// Level es el nivel mínimo que se registra: DEBUG, INFO, WARNING o ERROR.
+	Level string
+}
+
+// configurado es el logger creado con Configure.
+var configurado atomic.Pointer[slog.Logger]
+
+// loggerEntorno es un logger creado con LOG_FORMAT y LOG_LEVEL junto con la configuración que se leyó.
+type loggerEntorno struct {
+	cfg    Config
+	logger *slog.Logger
+}
+
+// deEntorno guarda el logger usado mientras no se llama a Configure, para no crear uno en cada mensaje.
+var deEntorno atomic.Pointer[loggerEntorno]
+
+// Configure crea el logger con la configuración indicada. Mientras no se configure, el formato y el nivel
+// se leen de LOG_FORMAT y LOG_LEVEL para poder registrar mensajes durante la carga de la configuración.
+func Configure(cfg Config) {
+	configurado.Store(New(cfg))
+}
+
+// New crea un logger de slog que escribe en la salida estándar con el formato y el nivel indicados.
+func New(cfg Config) *slog.Logger {
+	return slog.New(nuevoManejador(cfg, salidaEstandar{}))
+}
+
+// base retorna el logger configurado o, si aún no se configuró, uno creado con las variables de entorno.
+// El logger de las variables de entorno se crea de nuevo solo si cambian sus valores.
+func base() *slog.Logger {
+	if logger := configurado.Load(); logger != nil {
+		return logger
+	}
+	cfg := Config{Format: os.Getenv("LOG_FORMAT"), Level: os.Getenv("LOG_LEVEL")}
+	if actual := deEntorno.Load(); actual != nil && actual.cfg == cfg {
+		return actual.logger
+	}
+	logger := New(cfg)
+	deEntorno.Store(&loggerEntorno{cfg: cfg, logger: logger})
+	return logger
+}
+
+// ParseLevel convierte el nombre de un nivel en un slog.Level; los valores desconocidos se tratan como INFO.
+func ParseLevel(nivel string) slog.Level {
+	switch strings.ToUpper(strings.TrimSpace(nivel)) {
+	case "DEBUG":
+		return slog.LevelDebug
+	case "WARNING", "WARN":
+		return slog.LevelWarn
+	case "ERROR":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// nombreNivel retorna el nombre con el que se registra cada nivel.
+func nombreNivel(nivel slog.Level) string {
+	switch {
+	case nivel >= slog.LevelError:
+		return "ERROR"
+	case nivel >= slog.LevelWarn:
+		return "WARNING"
+	case nivel >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
 // LogInterface define una interfaz para el logger.
 type LogInterface interface {
 	LogError(message string, err error, fileName string)
@@ -17,151 +93,100 @@ type LogInterface interface {
 	LogDebug(message, fileName string)
 }
 
-// LoggerAdapter implementa LogInterface utilizando funciones específicas.
+// LoggerAdapter implementa LogInterface sobre el logger de slog.
 type LoggerAdapter struct{}
 
 func (l *LoggerAdapter) LogError(message string, err error, fileName string) {
-	LogError(message, err, fileName)
+	registrar(context.Background(), slog.LevelError, mensajeError(message, err), FileName(fileName))
 }
 
 func (l *LoggerAdapter) LogInfo(message, fileName string) {
-	LogInfo(message, fileName)
+	registrar(context.Background(), slog.LevelInfo, message, FileName(fileName))
 }
 
 func (l *LoggerAdapter) LogWarn(message string, fileName string, extraArgs ...string) {
-	LogWarn(message, fileName, extraArgs...)
+	registrar(context.Background(), slog.LevelWarn, message, atributosExtra(fileName, extraArgs)...)
 }
 
 func (l *LoggerAdapter) LogDebug(message, fileName string) {
-	LogDebug(message, fileName)
+	registrar(context.Background(), slog.LevelDebug, message, FileName(fileName))
 }
 
 var Logger LogInterface = &LoggerAdapter{}
 
-// BasePath almacena la ruta base del proyecto.
-var basePath string
+// LogInfo genera logs a nivel INFO.
+func LogInfo(message, fileName string) {
+	registrar(context.Background(), slog.LevelInfo, message, FileName(fileName))
+}
 
-func init() {
-	wd, err := os.Getwd()
-	if err != nil {
-		basePath = ""
-	} else {
-		basePath = filepath.ToSlash(wd)
-	}
+// LogWarn genera logs a nivel WARNING. extraArgs contiene pares clave, valor que se registran como atributos.
+func LogWarn(message string, fileName string, extraArgs ...string) {
+	registrar(context.Background(), slog.LevelWarn, message, atributosExtra(fileName, extraArgs)...)
 }
 
-// getCurrentTimestamp obtiene la fecha y hora actual en la zona horaria de Colombia con milisegundos.
-func getCurrentTimestamp() string {
-	location, err := time.LoadLocation("America/Bogota")
-	if err != nil {
-		location = time.UTC
-	}
-	return time.Now().In(location).Format("2006-01-02 15:04:05.000")
+// LogError genera logs a nivel ERROR.
+func LogError(message string, err error, fileName string) {
+	registrar(context.Background(), slog.LevelError, mensajeError(message, err), FileName(fileName))
 }
 
-// runtimeCaller es una variable que facilita las pruebas.
-var runtimeCaller = runtime.Caller
+// LogDebug genera logs a nivel DEBUG.
+func LogDebug(message, fileName string) {
+	registrar(context.Background(), slog.LevelDebug, message, FileName(fileName))
+}
 
-// getCallerInfo obtiene el archivo y la línea desde donde se llamó el logger.
-func getCallerInfo() (string, int) {
-	_, file, line, ok := runtimeCaller(4)
-	if !ok {
-		return "???", 0
-	}
-	relativePath := filepath.Base(file)
-	return relativePath, line
-}
-
-// logMessageJSON maneja el formato JSON del log.
-func logMessageJSON(level, message, fileName string) string {
-	timestamp := getCurrentTimestamp()
-	moduleName, lineNumber := getCallerInfo()
-
-	logData := struct {
-		Timestamp  string `json:"timestamp"`
-		Level      string `json:"level"`
-		ModuleName string `json:"module_name"`
-		LineNumber int    `json:"line_number"`
-		RequestID  string `json:"request_id"`
-		Message    string `json:"message"`
-	}{
-		Timestamp:  timestamp,
-		Level:      level,
-		ModuleName: moduleName,
-		LineNumber: lineNumber,
-		RequestID:  fileName,
-		Message:    message,
-	}
+// Debug registra un mensaje a nivel DEBUG con el logger del contexto.
+func Debug(ctx context.Context, message string, attrs ...slog.Attr) {
+	registrar(ctx, slog.LevelDebug, message, attrs...)
+}
 
-	jsonLog, err := json.Marshal(logData)
-	if err != nil {
-		return `{"error": "failed to marshal log to JSON"}`
-	}
-	return string(jsonLog)
+// Info registra un mensaje a nivel INFO con el logger del contexto.
+func Info(ctx context.Context, message string, attrs ...slog.Attr) {
+	registrar(ctx, slog.LevelInfo, message, attrs...)
 }
 
-// logMessagePlain maneja el formato detallado en texto plano.
-func logMessagePlain(level, message, fileName string) string {
-	timestamp := getCurrentTimestamp()
-	moduleName, lineNumber := getCallerInfo()
-	return fmt.Sprintf("%s [%s] [%s:%d] [FileName: %s] %s",
-		timestamp, level, moduleName, lineNumber, fileName, message)
+// Warn registra un mensaje a nivel WARNING con el logger del contexto.
+func Warn(ctx context.Context, message string, attrs ...slog.Attr) {
+	registrar(ctx, slog.LevelWarn, message, attrs...)
 }
 
-// LogInfo genera logs a nivel INFO.
-func LogInfo(message, fileName string) {
-	format := os.Getenv("LOG_FORMAT")
-	if format == "JSON" {
-		fmt.Println(logMessageJSON("INFO", message, fileName))
-	} else {
-		fmt.Println(logMessagePlain("INFO", message, fileName))
+// Error registra un mensaje a nivel ERROR con el logger del contexto y el error como atributo.
+func Error(ctx context.Context, message string, err error, attrs ...slog.Attr) {
+	if err != nil {
+		attrs = append(attrs, Err(err))
 	}
+	registrar(ctx, slog.LevelError, message, attrs...)
 }
 
-// LogWarn genera logs a nivel WARNING.
-func LogWarn(message string, fileName string, extraArgs ...string) {
-	var formattedMessage string
-	if len(extraArgs) >= 2 {
-		key := extraArgs[0]
-		value := extraArgs[1]
-		formattedMessage = fmt.Sprintf("%s - %s: %s", message, key, value)
-	} else {
-		formattedMessage = message
+// registrar escribe el mensaje con el logger del contexto. El origen del mensaje es quien llamó a la
+// función exportada del paquete, por eso debe llamarse directamente desde ella.
+func registrar(ctx context.Context, nivel slog.Level, message string, attrs ...slog.Attr) {
+	logger := FromContext(ctx)
+	if !logger.Enabled(ctx, nivel) {
+		return
 	}
 
-	format := os.Getenv("LOG_FORMAT")
-	if format == "JSON" {
-		fmt.Println(logMessageJSON("WARNING", formattedMessage, fileName))
-	} else {
-		fmt.Println(logMessagePlain("WARNING", formattedMessage, fileName))
-	}
-}
+	// Omitir runtime.Callers, registrar y la función exportada
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
 
-// LogError genera logs a nivel ERROR.
-func LogError(message string, err error, fileName string) {
-	var formattedMessage string
-	if err != nil {
-		formattedMessage = fmt.Sprintf("%s - Error: %v", message, err)
-	} else {
-		formattedMessage = message
-	}
+	record := slog.NewRecord(time.Now(), nivel, message, pcs[0])
+	record.AddAttrs(attrs...)
+	_ = logger.Handler().Handle(ctx, record)
+}
 
-	format := os.Getenv("LOG_FORMAT")
-	if format == "JSON" {
-		fmt.Println(logMessageJSON("ERROR", formattedMessage, fileName))
-	} else {
-		fmt.Println(logMessagePlain("ERROR", formattedMessage, fileName))
+// mensajeError agrega el detalle del error al mensaje.
+func mensajeError(message string, err error) string {
+	if err == nil {
+		return message
 	}
+	return fmt.Sprintf("%s - Error: %v", message, err)
 }
 
-// LogDebug genera logs a nivel DEBUG.
-func LogDebug(message, fileName string) {
-	if logLevel := os.Getenv("LOG_LEVEL"); logLevel == "DEBUG" {
-		format := os.Getenv("LOG_FORMAT")
-		if format == "JSON" {
-			fmt.Println(logMessageJSON("DEBUG", message, fileName))
-		} else {
-			fmt.Println(logMessagePlain("DEBUG", message, fileName))
-		}
+// atributosExtra convierte los pares clave, valor de LogWarn en atributos.
+func atributosExtra(fileName string, extraArgs []string) []slog.Attr {
+	attrs := []slog.Attr{FileName(fileName)}
+	for i := 0; i+1 < len(extraArgs); i += 2 {
+		attrs = append(attrs, slog.String(extraArgs[i], extraArgs[i+1]))
 	}
+	return attrs
 }
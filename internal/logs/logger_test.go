@@ -1,6 +1,8 @@
 package logs
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -276,3 +278,121 @@ func TestLogErrorWithoutDetails(t *testing.T) {
 		t.Errorf("No se esperaba incluir detalles de error, pero se encontraron. Salida: %s", output)
 	}
 }
+
+// decodificarJSON convierte la única línea JSON de la salida en un mapa.
+func decodificarJSON(t *testing.T, output string) map[string]any {
+	t.Helper()
+	var campos map[string]any
+	if err := json.Unmarshal([]byte(output), &campos); err != nil {
+		t.Fatalf("Se esperaba una línea JSON, pero no fue posible decodificarla: %v. Salida: %s", err, output)
+	}
+	return campos
+}
+
+func TestConfigureNivelMinimo(t *testing.T) {
+	Configure(Config{Format: "STRING", Level: "WARNING"})
+	t.Cleanup(func() { configurado.Store(nil) })
+
+	output := captureOutput(func() {
+		LogInfo("Mensaje de información que debe ser ignorado", "logger_test.go")
+		LogWarn("Mensaje de advertencia", "logger_test.go")
+	})
+
+	// Verifica que solo se registre el mensaje con nivel igual o superior al configurado
+	if strings.Contains(output, "[INFO]") {
+		t.Errorf("No se esperaba registrar mensajes INFO con nivel WARNING. Salida: %s", output)
+	}
+	if !strings.Contains(output, "[WARNING]") {
+		t.Errorf("Se esperaba registrar el mensaje WARNING. Salida: %s", output)
+	}
+}
+
+func TestConfigurePrioridadSobreEntorno(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "STRING")
+	Configure(Config{Format: "JSON", Level: "INFO"})
+	t.Cleanup(func() { configurado.Store(nil) })
+
+	output := captureOutput(func() {
+		LogInfo("Mensaje con la configuración aplicada", "logger_test.go")
+	})
+
+	campos := decodificarJSON(t, output)
+	if campos["level"] != "INFO" {
+		t.Errorf("Se esperaba el nivel INFO, pero se obtuvo %v", campos["level"])
+	}
+}
+
+func TestLogContextoAtributosTipados(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "JSON")
+
+	ctx := With(context.Background(), RequestID("req-1"))
+	output := captureOutput(func() {
+		Info(ctx, "Se ha marcado el archivo con su nuevo estado",
+			FileName("RE_PRO_TUTGMF0001003920241021-0001.zip"), IDArchivo(1234567890123456), Estado("ENVIADO"))
+	})
+
+	campos := decodificarJSON(t, output)
+	esperados := map[string]any{
+		"level":       "INFO",
+		"message":     "Se ha marcado el archivo con su nuevo estado",
+		"request_id":  "req-1",
+		"file_name":   "RE_PRO_TUTGMF0001003920241021-0001.zip",
+		"id_archivo":  float64(1234567890123456),
+		"estado":      "ENVIADO",
+		"module_name": "logger_test.go",
+	}
+	for clave, valor := range esperados {
+		if campos[clave] != valor {
+			t.Errorf("Se esperaba %s=%v, pero se obtuvo %v. Salida: %s", clave, valor, campos[clave], output)
+		}
+	}
+}
+
+func TestLogContextoError(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "STRING")
+
+	ctx := With(context.Background(), RequestID("req-2"))
+	output := captureOutput(func() {
+		Error(ctx, "Error al actualizar el archivo", fmt.Errorf("conexión cerrada"), FileName("archivo.zip"))
+	})
+
+	for _, esperado := range []string{"[ERROR]", "[FileName: archivo.zip]", "request_id=req-2", `error="conexión cerrada"`} {
+		if !strings.Contains(output, esperado) {
+			t.Errorf("Se esperaba %q en la salida. Salida: %s", esperado, output)
+		}
+	}
+}
+
+func TestFromContextSinLogger(t *testing.T) {
+	if FromContext(context.Background()) == nil {
+		t.Error("Se esperaba el logger de la aplicación para un contexto sin logger")
+	}
+}
+
+func TestBaseReutilizaLoggerDeEntorno(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "JSON")
+	t.Setenv("LOG_LEVEL", "INFO")
+
+	primero := base()
+	if primero != base() {
+		t.Error("Se esperaba el mismo logger mientras no cambien LOG_FORMAT y LOG_LEVEL")
+	}
+
+	t.Setenv("LOG_LEVEL", "DEBUG")
+	if primero == base() {
+		t.Error("Se esperaba un logger nuevo al cambiar LOG_LEVEL")
+	}
+}
+
+func TestLogWarnAtributosExtra(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "JSON")
+
+	output := captureOutput(func() {
+		LogWarn("Nombre de archivo inválido", "archivo.zip", "detalle", "extensión no permitida")
+	})
+
+	campos := decodificarJSON(t, output)
+	if campos["detalle"] != "extensión no permitida" || campos["file_name"] != "archivo.zip" {
+		t.Errorf("Se esperaban los atributos detalle y file_name. Salida: %s", output)
+	}
+}
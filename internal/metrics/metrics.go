@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Tipos de archivo usados como etiqueta en las métricas de procesamiento.
+const (
+	TipoAnulacion  = "anulacion"
+	TipoMovimiento = "movimiento"
+)
+
+// DefaultBuckets son los límites en segundos del histograma de duración de solicitudes HTTP.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	// ArchivosProcesados cuenta los archivos procesados por tipo, estado de transmisión y resultado.
+	ArchivosProcesados = NewCounterVec(
+		"gmf_files_processed_total",
+		"Archivos transmitidos procesados por tipo, estado reportado por el gateway y resultado.",
+		"tipo", "status", "outcome",
+	)
+
+	// ArchivosPorEstado cuenta los archivos por el estado de cgd_archivos en el que quedaron.
+	ArchivosPorEstado = NewCounterVec(
+		"gmf_files_estado_total",
+		"Archivos actualizados por tipo y estado resultante en cgd_archivos.",
+		"tipo", "estado",
+	)
+
+	// ErroresRepositorio cuenta los errores de base de datos por operación del repositorio.
+	ErroresRepositorio = NewCounterVec(
+		"gmf_repository_errors_total",
+		"Errores retornados por el repositorio por operación.",
+		"operation",
+	)
+
+	// DuracionSolicitudes mide la duración de las solicitudes HTTP por handler, método y código de respuesta.
+	DuracionSolicitudes = NewHistogramVec(
+		"gmf_http_request_duration_seconds",
+		"Duración de las solicitudes HTTP en segundos.",
+		DefaultBuckets,
+		"handler", "method", "code",
+	)
+)
+
+// Default es el registro donde se exponen las métricas de la aplicación.
+var Default = NewRegistry()
+
+func init() {
+	Default.MustRegister(ArchivosProcesados, ArchivosPorEstado, ErroresRepositorio, DuracionSolicitudes)
+}
+
+// Handler retorna el handler HTTP que expone el registro Default.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// TipoArchivo retorna la etiqueta de tipo de archivo según si es una anulación.
+func TipoArchivo(isAnulacion bool) string {
+	if isAnulacion {
+		return TipoAnulacion
+	}
+	return TipoMovimiento
+}
+
+// InstrumentHandler registra la duración de cada solicitud atendida por next en DuracionSolicitudes.
+func InstrumentHandler(handlerName string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(recorder, r)
+
+		DuracionSolicitudes.Observe(time.Since(start).Seconds(),
+			handlerName, r.Method, strconv.Itoa(recorder.status))
+	}
+}
+
+// statusRecorder guarda el código de respuesta escrito por el handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush envía al cliente los datos escritos hasta el momento si el ResponseWriter original lo permite.
+func (r *statusRecorder) Flush() {
+	r.wroteHeader = true
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap retorna el ResponseWriter original para que http.ResponseController acceda a sus funcionalidades.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
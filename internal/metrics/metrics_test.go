@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterVec_WriteTo(t *testing.T) {
+	counter := NewCounterVec("test_total", "Contador de prueba.", "tipo", "status")
+	registry := NewRegistry()
+	registry.MustRegister(counter)
+
+	counter.Inc(TipoMovimiento, "SUCCESSFUL")
+	counter.Add(2, TipoAnulacion, "ERROR")
+	counter.Inc(TipoMovimiento, "SUCCESSFUL")
+
+	var out strings.Builder
+	_, err := registry.WriteTo(&out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP test_total Contador de prueba.
+# TYPE test_total counter
+test_total{tipo="anulacion",status="ERROR"} 2
+test_total{tipo="movimiento",status="SUCCESSFUL"} 2
+`, out.String())
+	assert.Equal(t, float64(2), counter.Value(TipoMovimiento, "SUCCESSFUL"))
+	assert.Equal(t, float64(0), counter.Value(TipoMovimiento, "ERROR"))
+}
+
+func TestCounterVec_EscapaEtiquetas(t *testing.T) {
+	counter := NewCounterVec("test_total", "Ayuda con \\ y\nsalto.", "detalle")
+	registry := NewRegistry()
+	registry.MustRegister(counter)
+
+	counter.Inc("comillas \" barra \\ salto \n")
+
+	var out strings.Builder
+	registry.WriteTo(&out)
+
+	assert.Contains(t, out.String(), `# HELP test_total Ayuda con \\ y\nsalto.`)
+	assert.Contains(t, out.String(), `test_total{detalle="comillas \" barra \\ salto \n"} 1`)
+}
+
+func TestCounterVec_EtiquetasIncorrectas(t *testing.T) {
+	counter := NewCounterVec("test_total", "Contador de prueba.", "tipo")
+
+	assert.Panics(t, func() { counter.Inc() })
+	assert.Panics(t, func() { counter.Add(-1, TipoMovimiento) })
+}
+
+func TestHistogramVec_WriteTo(t *testing.T) {
+	histogram := NewHistogramVec("test_seconds", "Histograma de prueba.", []float64{1, 0.1}, "handler")
+	registry := NewRegistry()
+	registry.MustRegister(histogram)
+
+	histogram.Observe(0.05, "h")
+	histogram.Observe(0.5, "h")
+	histogram.Observe(3, "h")
+
+	var out strings.Builder
+	registry.WriteTo(&out)
+
+	assert.Equal(t, `# HELP test_seconds Histograma de prueba.
+# TYPE test_seconds histogram
+test_seconds_bucket{handler="h",le="0.1"} 1
+test_seconds_bucket{handler="h",le="1"} 2
+test_seconds_bucket{handler="h",le="+Inf"} 3
+test_seconds_sum{handler="h"} 3.55
+test_seconds_count{handler="h"} 3
+`, out.String())
+	assert.Equal(t, uint64(3), histogram.Count("h"))
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(NewCounterVec("test_total", "Contador de prueba."))
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, contentType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "# TYPE test_total counter")
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	handler := InstrumentHandler("TestInstrumentHandler", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPartialContent)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/transmission", nil))
+
+	assert.Equal(t, uint64(1), DuracionSolicitudes.Count("TestInstrumentHandler", http.MethodPost, "206"))
+	assert.Equal(t, uint64(0), DuracionSolicitudes.Count("TestInstrumentHandler", http.MethodPost, "500"))
+}
+
+func TestInstrumentHandler_FlushYUnwrap(t *testing.T) {
+	handler := InstrumentHandler("TestInstrumentHandler_FlushYUnwrap", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("parcial"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	recorder := httptest.NewRecorder()
+
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/transmission", nil))
+
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, uint64(1), DuracionSolicitudes.Count("TestInstrumentHandler_FlushYUnwrap", http.MethodGet, "200"))
+}
@@ -0,0 +1,287 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType es el tipo de contenido del formato de exposición de texto de Prometheus.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// collector es implementado por cada tipo de métrica que puede escribirse en el registro.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry agrupa las métricas y las expone en el formato de texto de Prometheus.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry crea un registro de métricas vacío.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// MustRegister agrega las métricas al registro.
+func (r *Registry) MustRegister(collectors ...collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// WriteTo escribe todas las métricas registradas en el formato de texto de Prometheus.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// Handler retorna el handler HTTP que expone las métricas del registro.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		r.WriteTo(w)
+	})
+}
+
+// CounterVec es un contador dividido por etiquetas.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec crea un contador con los nombres de etiquetas indicados.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+}
+
+// Inc incrementa en uno el contador para los valores de etiqueta indicados.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add suma v al contador para los valores de etiqueta indicados. v no puede ser negativo.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: el contador %s no puede disminuir", c.name))
+	}
+	key := labelKey(c.name, c.labels, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] += v
+}
+
+// Value retorna el valor actual del contador para los valores de etiqueta indicados.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := labelKey(c.name, c.labels, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, splitKey(key)), formatFloat(c.values[key]))
+	}
+}
+
+// histogramValue contiene las observaciones de un histograma para una combinación de etiquetas.
+type histogramValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec es un histograma dividido por etiquetas.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+// NewHistogramVec crea un histograma con los límites superiores de bucket y etiquetas indicados.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: sorted,
+		values:  make(map[string]*histogramValue),
+	}
+}
+
+// Observe registra una observación para los valores de etiqueta indicados.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(h.name, h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = value
+	}
+	for i, upperBound := range h.buckets {
+		if v <= upperBound {
+			value.counts[i]++
+		}
+	}
+	value.sum += v
+	value.count++
+}
+
+// Count retorna la cantidad de observaciones para los valores de etiqueta indicados.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := labelKey(h.name, h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if value, ok := h.values[key]; ok {
+		return value.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range sortedKeys(h.values) {
+		labelValues := splitKey(key)
+		value := h.values[key]
+
+		for i, upperBound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+				formatLabels(bucketLabels, withLabel(labelValues, formatFloat(upperBound))), value.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name,
+			formatLabels(bucketLabels, withLabel(labelValues, "+Inf")), value.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, labelValues), formatFloat(value.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, labelValues), value.count)
+	}
+}
+
+// withLabel retorna una copia de labelValues con un valor adicional al final.
+func withLabel(labelValues []string, value string) []string {
+	result := make([]string, len(labelValues), len(labelValues)+1)
+	copy(result, labelValues)
+	return append(result, value)
+}
+
+// keySeparator separa los valores de etiqueta en la clave interna de cada serie.
+const keySeparator = "\xff"
+
+// labelKey construye la clave interna de una serie validando la cantidad de etiquetas.
+func labelKey(name string, labels, labelValues []string) string {
+	if len(labels) != len(labelValues) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas y recibió %d", name, len(labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, keySeparator)
+}
+
+// splitKey obtiene los valores de etiqueta a partir de la clave interna.
+func splitKey(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, keySeparator)
+}
+
+// sortedKeys retorna las claves del mapa ordenadas para producir una salida estable.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeHeader escribe las líneas HELP y TYPE de una métrica.
+func writeHeader(w *bufio.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// formatLabels da formato a las etiquetas de una serie, por ejemplo {tipo="movimiento"}.
+func formatLabels(labels, labelValues []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(labels))
+	for i, label := range labels {
+		var value string
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, escaper.Replace(value)))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat da formato a un valor numérico según el formato de exposición.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter cuenta los bytes escritos para cumplir con io.WriterTo.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -49,10 +49,14 @@ func (CGDArchivos) TableName() string {
 }
 
 // CGDArchivoEstados representa la estructura de la tabla CGD_ARCHIVO_ESTADO.
+// EstadoInicial y EstadoFinal corresponden al Estado de CGD_ARCHIVO antes y después de la transición;
+// GAWRtaTransEstado y GAWRtaTransCodigo guardan el resultado reportado por el gateway que la originó.
 type CGDArchivoEstados struct {
 	IDArchivo         int64     `json:"id_archivo" gorm:"type:numeric(16);primaryKey;foreignKey:IDArchivo"`
 	EstadoInicial     string    `json:"estado_inicial" gorm:"type:varchar(50)"`
 	EstadoFinal       string    `json:"estado_final" gorm:"type:varchar(50);primaryKey"`
+	GAWRtaTransEstado string    `json:"gaw_rta_trans_estado" gorm:"type:varchar(50)"`
+	GAWRtaTransCodigo string    `json:"gaw_rta_trans_codigo" gorm:"type:varchar(4)"`
 	FechaCambioEstado time.Time `json:"fecha_cambio_estado" gorm:"type:timestamp;primaryKey;not null;autoCreateTime(6)"`
 }
 
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ArchivoConsulta contiene los campos de CGD_ARCHIVO que se exponen en la consulta del estado de un archivo.
+type ArchivoConsulta struct {
+	IDArchivo                int64     `json:"id_archivo"`
+	ACGNombreArchivo         string    `json:"acg_nombre_archivo"`
+	Estado                   string    `json:"estado"`
+	GAWRtaTransEstado        string    `json:"gaw_rta_trans_estado"`
+	GAWRtaTransCodigo        string    `json:"gaw_rta_trans_codigo"`
+	GAWRtaTransDetalle       string    `json:"gaw_rta_trans_detalle"`
+	ACGTotalTx               int64     `json:"acg_total_tx"`
+	ACGMontoTotalTx          float64   `json:"acg_monto_total_tx"`
+	ACGTotalTxDebito         int64     `json:"acg_total_tx_debito"`
+	ACGMontoTotalTxDebito    float64   `json:"acg_monto_total_tx_debito"`
+	ACGTotalTxReverso        int64     `json:"acg_total_tx_reverso"`
+	ACGMontoTotalTxReverso   float64   `json:"acg_monto_total_tx_reverso"`
+	ACGTotalTxReintegro      int64     `json:"acg_total_tx_reintegro"`
+	ACGMontoTotalTxReintegro float64   `json:"acg_monto_total_tx_reintegro"`
+	AnulacionNombreArchivo   string    `json:"anulacion_nombre_archivo"`
+	AnulacionJustificacion   string    `json:"anulacion_justificacion"`
+	AnulacionFechaAnulacion  time.Time `json:"anulacion_fecha_anulacion"`
+}
+
+// ErrorResponse estructura la respuesta de los endpoints de consulta cuando la solicitud falla.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FiltroHistorial contiene el rango de fechas y la página a consultar del histórico de estados de un archivo.
+// Desde es inclusivo y Hasta es exclusivo; un valor cero indica que no se filtra por ese extremo.
+type FiltroHistorial struct {
+	Desde        time.Time
+	Hasta        time.Time
+	Pagina       int
+	TamanoPagina int
+}
+
+// HistorialResponse estructura la respuesta del histórico de estados de un archivo.
+type HistorialResponse struct {
+	IDArchivo        int64               `json:"id_archivo"`
+	ACGNombreArchivo string              `json:"acg_nombre_archivo"`
+	Total            int64               `json:"total"`
+	Page             int                 `json:"page"`
+	PageSize         int                 `json:"page_size"`
+	Transitions      []CGDArchivoEstados `json:"transitions"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Estados de una clave de idempotencia.
+const (
+	IdempotenciaEnProceso  = "IN_PROGRESS"
+	IdempotenciaCompletada = "COMPLETED"
+)
+
+// CGDIdempotencia representa la estructura de la tabla CGD_IDEMPOTENCIA, donde se guarda
+// la respuesta enviada para cada Idempotency-Key recibido en /transmission. La clave se registra
+// IN_PROGRESS antes de procesar la solicitud y pasa a COMPLETED con la respuesta enviada.
+type CGDIdempotencia struct {
+	Clave           string    `json:"clave" gorm:"type:varchar(255);primaryKey"`
+	HashSolicitud   string    `json:"hash_solicitud" gorm:"type:char(64);not null"`
+	Estado          string    `json:"estado" gorm:"type:varchar(20);not null;default:COMPLETED"`
+	CodigoRespuesta int       `json:"codigo_respuesta" gorm:"type:smallint;not null"`
+	CuerpoRespuesta string    `json:"cuerpo_respuesta" gorm:"type:text;not null"`
+	FechaCreacion   time.Time `json:"fecha_creacion" gorm:"type:timestamp;not null"`
+	FechaExpiracion time.Time `json:"fecha_expiracion" gorm:"type:timestamp;not null;index"`
+}
+
+func (CGDIdempotencia) TableName() string {
+	return "cgd_idempotencia"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Estados de un job de procesamiento asíncrono.
+const (
+	JobPendiente  = "PENDING"
+	JobEnProceso  = "RUNNING"
+	JobCompletado = "COMPLETED"
+	JobFallido    = "FAILED"
+)
+
+// CGDJob representa la estructura de la tabla CGD_JOBS, donde se guardan las solicitudes de /transmission
+// recibidas en modo asíncrono y su resultado. Owner es la instancia que procesa el job y LeaseUntil el
+// momento hasta el que lo tiene reservado; si la instancia se detiene sin terminarlo, otra lo retoma
+// cuando vence la reserva.
+type CGDJob struct {
+	ID                 string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Estado             string     `json:"estado" gorm:"type:varchar(20);not null;index"`
+	TotalArchivos      int        `json:"total_archivos" gorm:"type:integer;not null"`
+	Solicitud          string     `json:"solicitud" gorm:"type:text;not null"`
+	RequestID          string     `json:"request_id" gorm:"type:varchar(100)"`
+	Resultado          string     `json:"resultado" gorm:"type:text"`
+	Error              string     `json:"error" gorm:"type:varchar(1000)"`
+	Owner              string     `json:"owner" gorm:"type:varchar(100)"`
+	LeaseUntil         *time.Time `json:"lease_until" gorm:"type:timestamp"`
+	FechaCreacion      time.Time  `json:"fecha_creacion" gorm:"type:timestamp;not null"`
+	FechaActualizacion time.Time  `json:"fecha_actualizacion" gorm:"type:timestamp;not null;index"`
+}
+
+func (CGDJob) TableName() string {
+	return "cgd_jobs"
+}
+
+// JobResponse estructura la respuesta del estado de un job.
+type JobResponse struct {
+	JobID      string          `json:"job_id"`
+	Status     string          `json:"status"`
+	TotalFiles int             `json:"total_files"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
@@ -5,6 +5,12 @@ type TransmisionResponse struct {
 	TransmittedFiles []TransmittedFile `json:"transmittedFiles"`
 }
 
+// Estados de transmisión reportados por el gateway.
+const (
+	StatusSuccessful = "SUCCESSFUL"
+	StatusError      = "ERROR"
+)
+
 // TransmittedFile representa un archivo transmitido y su resultado.
 type TransmittedFile struct {
 	FileName           string             `json:"fileName"`
@@ -18,10 +24,38 @@ type TransmissionResult struct {
 	Detail string `json:"detail"`
 }
 
+// Resultados posibles del procesamiento de un archivo transmitido.
+const (
+	OutcomeProcessed = "PROCESSED"
+	OutcomeFailed    = "FAILED"
+)
+
+// FileResult contiene el resultado del procesamiento de un archivo transmitido.
+type FileResult struct {
+	FileName  string `json:"file_name"`
+	Outcome   string `json:"outcome"`
+	Estado    string `json:"estado,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Message   string `json:"message"`
+}
+
 // Response estructura las respuestas del handler.
 type Response struct {
-	Message    string `json:"message"`
-	ErrorCount int    `json:"error_count,omitempty"`
-	TotalFiles int    `json:"total_files"`
-	Success    bool   `json:"success"`
+	Message    string       `json:"message"`
+	ErrorCount int          `json:"error_count,omitempty"`
+	TotalFiles int          `json:"total_files"`
+	Success    bool         `json:"success"`
+	Results    []FileResult `json:"results"`
+}
+
+// Violation describe un campo de la solicitud que no cumple el esquema esperado.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationResponse estructura la respuesta cuando la solicitud no supera la validación.
+type ValidationResponse struct {
+	Message    string      `json:"message"`
+	Violations []Violation `json:"violations"`
 }
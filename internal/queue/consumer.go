@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/service"
+	"gmf_transmission_response/internal/validation"
+)
+
+// Valores por defecto del consumidor.
+const (
+	VisibilityTimeoutPorDefecto = 30 * time.Second
+	WaitTimePorDefecto          = 20 * time.Second
+	// esperaTrasError es el tiempo que se espera antes de volver a consultar la cola si ReceiveMessage falla.
+	esperaTrasError = time.Second
+)
+
+// ConsumerOption configura un Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithDeadLetterQueue envía a dlq los mensajes que se han recibido más de maxReceiveCount veces
+// sin procesarse correctamente, igual que la redrive policy de SQS, y los mensajes con formato inválido.
+// Sin esta opción los mensajes con errores de base de datos se reintentan hasta que los elimine la
+// redrive policy de la cola y los mensajes inválidos se eliminan.
+func WithDeadLetterQueue(dlq QueueInterface, maxReceiveCount int) ConsumerOption {
+	return func(c *Consumer) {
+		if maxReceiveCount > 0 {
+			c.dlq = dlq
+			c.maxReceiveCount = maxReceiveCount
+		}
+	}
+}
+
+// WithVisibilityTimeout define el tiempo que un mensaje recibido permanece oculto antes de reintentarse.
+func WithVisibilityTimeout(timeout time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		if timeout > 0 {
+			c.visibilityTimeout = timeout
+		}
+	}
+}
+
+// WithWaitTime define el tiempo máximo de espera de cada ReceiveMessage.
+func WithWaitTime(waitTime time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		if waitTime >= 0 {
+			c.waitTime = waitTime
+		}
+	}
+}
+
+// Consumer recibe mensajes TransmisionResponse de una cola y procesa cada archivo con ArchivoService.
+// Si algún archivo falla por un error de base de datos el mensaje no se elimina y vuelve a entregarse al
+// vencer el VisibilityTimeout; los demás errores no se corrigen al reintentar, por lo que el mensaje se
+// elimina y los errores solo se registran en el log.
+type Consumer struct {
+	queue             QueueInterface
+	service           service.ArchivoServiceInterface
+	dlq               QueueInterface
+	maxReceiveCount   int
+	visibilityTimeout time.Duration
+	waitTime          time.Duration
+}
+
+// NewConsumer crea un consumidor de la cola indicada.
+func NewConsumer(queue QueueInterface, archivoService service.ArchivoServiceInterface, opts ...ConsumerOption) *Consumer {
+	c := &Consumer{
+		queue:             queue,
+		service:           archivoService,
+		visibilityTimeout: VisibilityTimeoutPorDefecto,
+		waitTime:          WaitTimePorDefecto,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run consume mensajes hasta que ctx sea cancelado.
+func (c *Consumer) Run(ctx context.Context) error {
+	logs.Logger.LogInfo("Consumidor de la cola iniciado", "QUEUE_CONSUMER")
+	for {
+		if err := c.Consumir(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			logs.Logger.LogError("Error al recibir mensajes de la cola", err, "QUEUE_CONSUMER")
+			select {
+			case <-ctx.Done():
+			case <-time.After(esperaTrasError):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	logs.Logger.LogInfo("Consumidor de la cola detenido", "QUEUE_CONSUMER")
+	return nil
+}
+
+// Consumir recibe un lote de mensajes y los procesa. Solo retorna error si ReceiveMessage falla.
+func (c *Consumer) Consumir(ctx context.Context) error {
+	output, err := c.queue.ReceiveMessage(ctx, &ReceiveMessageInput{
+		MaxNumberOfMessages: MaxNumberOfMessages,
+		VisibilityTimeout:   c.visibilityTimeout,
+		WaitTime:            c.waitTime,
+	})
+	if err != nil {
+		return err
+	}
+	for _, mensaje := range output.Messages {
+		c.procesarMensaje(ctx, mensaje)
+	}
+	return nil
+}
+
+// procesarMensaje procesa los archivos del mensaje y lo elimina si todos terminaron correctamente.
+func (c *Consumer) procesarMensaje(ctx context.Context, mensaje Message) {
+	ctx = logs.With(ctx, slog.String("message_id", mensaje.MessageID))
+	if c.dlq != nil && mensaje.ReceiveCount > c.maxReceiveCount {
+		c.moverADeadLetter(ctx, mensaje, fmt.Sprintf("se recibió %d veces sin procesarse", mensaje.ReceiveCount))
+		return
+	}
+
+	transmisionResponse, err := validation.DecodificarTransmisionResponse([]byte(mensaje.Body))
+	if err != nil {
+		// Un mensaje inválido no se procesará correctamente en ningún reintento
+		logs.Error(ctx, "Mensaje con formato inválido", err)
+		if c.dlq != nil {
+			c.moverADeadLetter(ctx, mensaje, "formato inválido")
+			return
+		}
+		if err := c.eliminar(ctx, mensaje); err != nil {
+			logs.Error(ctx, "Error al eliminar el mensaje con formato inválido", err)
+			return
+		}
+		// Solo se registra el tamaño del cuerpo, que puede contener datos de los archivos
+		logs.Warn(ctx, "Mensaje con formato inválido eliminado", slog.Int("tamano", len(mensaje.Body)))
+		return
+	}
+
+	var errs []error
+	reintentar := false
+	for _, transmittedFile := range transmisionResponse.TransmittedFiles {
+		if err := c.service.ProcesarTransmision(ctx, transmittedFile); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", transmittedFile.FileName, err))
+			reintentar = reintentar || reintentable(err)
+		}
+	}
+	if reintentar {
+		logs.Error(ctx, fmt.Sprintf("El mensaje se reintentará, intento %d", mensaje.ReceiveCount),
+			errors.Join(errs...))
+		return
+	}
+	if len(errs) > 0 {
+		logs.Error(ctx, "Archivos del mensaje con errores que no se corrigen al reintentar",
+			errors.Join(errs...))
+	}
+
+	if err := c.eliminar(ctx, mensaje); err != nil {
+		logs.Error(ctx, "Error al eliminar el mensaje procesado", err)
+		return
+	}
+	logs.Info(ctx, fmt.Sprintf("Mensaje procesado con %d archivos", len(transmisionResponse.TransmittedFiles)))
+}
+
+// reintentable indica si el error puede corregirse al reintentar el mensaje. Solo los errores de base de
+// datos lo son; los errores sin código se tratan igual que en service.CodigoError, como de base de datos.
+func reintentable(err error) bool {
+	var procesamientoErr *service.ProcesamientoError
+	if errors.As(err, &procesamientoErr) {
+		return procesamientoErr.Codigo == service.CodigoErrorBaseDatos
+	}
+	return true
+}
+
+// moverADeadLetter envía el mensaje a la dead-letter queue y lo elimina de la cola de origen.
+func (c *Consumer) moverADeadLetter(ctx context.Context, mensaje Message, motivo string) {
+	if _, err := c.dlq.SendMessage(ctx, &SendMessageInput{MessageBody: mensaje.Body}); err != nil {
+		logs.Error(ctx, "Error al enviar el mensaje a la dead-letter queue", err)
+		return
+	}
+	if err := c.eliminar(ctx, mensaje); err != nil {
+		logs.Error(ctx, "Error al eliminar el mensaje enviado a la dead-letter queue", err)
+		return
+	}
+	logs.Warn(ctx, "Mensaje enviado a la dead-letter queue", slog.String("motivo", motivo))
+}
+
+// eliminar elimina el mensaje de la cola de origen.
+func (c *Consumer) eliminar(ctx context.Context, mensaje Message) error {
+	return c.queue.DeleteMessage(ctx, &DeleteMessageInput{ReceiptHandle: mensaje.ReceiptHandle})
+}
@@ -0,0 +1,189 @@
+package queue_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/queue"
+	"gmf_transmission_response/internal/service"
+)
+
+const mensajeValido = `{"transmittedFiles":[
+	{"fileName":"TUTGMF0001000120240312-0001","transmissionResult":{"status":"SUCCESSFUL","code":"0000"}},
+	{"fileName":"TUTGMF0001000120240312-0002","transmissionResult":{"status":"SUCCESSFUL","code":"0000"}}]}`
+
+// MockArchivoService simula el servicio, solo implementa ProcesarTransmision
+type MockArchivoService struct {
+	service.ArchivoServiceInterface
+	mu         sync.Mutex
+	Recibidos  []string
+	Fallos     int // Cantidad de llamadas que fallan antes de procesar correctamente
+	ErrorFallo error
+}
+
+func (m *MockArchivoService) ProcesarTransmision(_ context.Context, transmittedFile models.TransmittedFile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Recibidos = append(m.Recibidos, transmittedFile.FileName)
+	if m.Fallos > 0 {
+		m.Fallos--
+		return m.ErrorFallo
+	}
+	return nil
+}
+
+func enviar(t *testing.T, q *queue.MemoryQueue, body string) {
+	_, err := q.SendMessage(context.Background(), &queue.SendMessageInput{MessageBody: body})
+	assert.NoError(t, err)
+}
+
+func TestConsumir_Exitoso(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0))
+
+	assert.NoError(t, consumer.Consumir(context.Background()))
+
+	assert.Equal(t, []string{"TUTGMF0001000120240312-0001", "TUTGMF0001000120240312-0002"}, mockService.Recibidos)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestConsumir_FalloNoEliminaMensaje(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{Fallos: 1, ErrorFallo: errors.New("conexión perdida")}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0), queue.WithVisibilityTimeout(time.Millisecond))
+
+	assert.NoError(t, consumer.Consumir(context.Background()))
+	assert.Equal(t, 1, q.Len())
+
+	// Al vencer el visibility timeout el mensaje se reintenta y se elimina
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, consumer.Consumir(context.Background()))
+	assert.Len(t, mockService.Recibidos, 4)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestConsumir_DeadLetterTrasMaxReceiveCount(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	dlq := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{Fallos: 100, ErrorFallo: errors.New("conexión perdida")}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0),
+		queue.WithVisibilityTimeout(time.Millisecond), queue.WithDeadLetterQueue(dlq, 2))
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		assert.NoError(t, consumer.Consumir(context.Background()))
+	}
+
+	// Se procesó en los dos primeros intentos y en el tercero se envió a la dead-letter queue
+	assert.Len(t, mockService.Recibidos, 4)
+	assert.Equal(t, 0, q.Len())
+	output, err := dlq.ReceiveMessage(context.Background(), &queue.ReceiveMessageInput{})
+	assert.NoError(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.JSONEq(t, mensajeValido, output.Messages[0].Body)
+}
+
+func TestConsumir_MensajeInvalido(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	dlq := queue.NewMemoryQueue()
+	enviar(t, q, `{"transmittedFiles":[]}`)
+	mockService := &MockArchivoService{}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0), queue.WithDeadLetterQueue(dlq, 3))
+
+	assert.NoError(t, consumer.Consumir(context.Background()))
+
+	assert.Empty(t, mockService.Recibidos)
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, 1, dlq.Len())
+}
+
+func TestConsumir_MensajeInvalidoSinDeadLetter(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, `no es json`)
+	consumer := queue.NewConsumer(q, &MockArchivoService{}, queue.WithWaitTime(0))
+
+	var salida bytes.Buffer
+	ctx := logs.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&salida, nil)))
+	assert.NoError(t, consumer.Consumir(ctx))
+
+	// Sin dead-letter queue el mensaje se elimina porque no se procesará en ningún reintento
+	assert.Equal(t, 0, q.Len())
+
+	// El log identifica el mensaje sin incluir su cuerpo
+	assert.Contains(t, salida.String(), `"msg":"Mensaje con formato inválido eliminado"`)
+	assert.Contains(t, salida.String(), `"message_id":"`)
+	assert.Contains(t, salida.String(), `"tamano":10`)
+	assert.NotContains(t, salida.String(), `no es json`)
+}
+
+func TestConsumir_LogConMessageID(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	consumer := queue.NewConsumer(q, &MockArchivoService{}, queue.WithWaitTime(0))
+
+	var salida bytes.Buffer
+	ctx := logs.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&salida, nil)))
+	assert.NoError(t, consumer.Consumir(ctx))
+
+	assert.Contains(t, salida.String(), `"msg":"Mensaje procesado con 2 archivos"`)
+	assert.Contains(t, salida.String(), `"message_id":"`)
+	assert.NotContains(t, salida.String(), `"file_name"`)
+}
+
+func TestConsumir_ErrorNoReintentableEliminaMensaje(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{Fallos: 1, ErrorFallo: &service.ProcesamientoError{
+		Codigo: service.CodigoNoEncontrado, Err: errors.New("archivo no encontrado")}}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0))
+
+	assert.NoError(t, consumer.Consumir(context.Background()))
+
+	assert.Len(t, mockService.Recibidos, 2)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestConsumir_ErrorBaseDatosNoEliminaMensaje(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{Fallos: 1, ErrorFallo: &service.ProcesamientoError{
+		Codigo: service.CodigoErrorBaseDatos, Err: errors.New("conexión perdida")}}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(0))
+
+	assert.NoError(t, consumer.Consumir(context.Background()))
+
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestRun_SeDetieneAlCancelar(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	enviar(t, q, mensajeValido)
+	mockService := &MockArchivoService{}
+	consumer := queue.NewConsumer(q, mockService, queue.WithWaitTime(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return q.Len() == 0 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run no se detuvo al cancelar el contexto")
+	}
+}
@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MaxNumberOfMessages es la cantidad máxima de mensajes que retorna ReceiveMessage, igual que en SQS.
+const MaxNumberOfMessages = 10
+
+// intervaloEspera es cada cuánto se revisa la cola mientras se espera a que haya mensajes disponibles.
+const intervaloEspera = 10 * time.Millisecond
+
+// ErrReceiptHandleInvalido indica que el receipt handle no corresponde a la última recepción de un mensaje.
+var ErrReceiptHandleInvalido = errors.New("receipt handle inválido o vencido")
+
+// mensajeEnMemoria contiene un mensaje de MemoryQueue y su estado de visibilidad.
+type mensajeEnMemoria struct {
+	Message
+	visibleDesde time.Time
+}
+
+// MemoryQueue es una cola en memoria con la semántica de SQS para ejecuciones locales y pruebas:
+// los mensajes recibidos quedan ocultos durante el VisibilityTimeout y vuelven a entregarse si no se eliminan.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	mensajes []*mensajeEnMemoria
+}
+
+// NewMemoryQueue crea una cola en memoria vacía.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{}
+}
+
+// SendMessage agrega un mensaje al final de la cola.
+func (q *MemoryQueue) SendMessage(_ context.Context, input *SendMessageInput) (*SendMessageOutput, error) {
+	id, err := nuevoIdentificador()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mensajes = append(q.mensajes, &mensajeEnMemoria{
+		Message:      Message{MessageID: id, Body: input.MessageBody},
+		visibleDesde: time.Now(),
+	})
+	return &SendMessageOutput{MessageID: id}, nil
+}
+
+// ReceiveMessage retorna los mensajes visibles, esperando hasta WaitTime a que haya alguno disponible.
+// Cada mensaje recibido recibe un nuevo ReceiptHandle y queda oculto durante VisibilityTimeout.
+func (q *MemoryQueue) ReceiveMessage(ctx context.Context, input *ReceiveMessageInput) (*ReceiveMessageOutput, error) {
+	limite := input.MaxNumberOfMessages
+	if limite <= 0 || limite > MaxNumberOfMessages {
+		limite = MaxNumberOfMessages
+	}
+
+	fin := time.Now().Add(input.WaitTime)
+	for {
+		mensajes, err := q.recibir(limite, input.VisibilityTimeout)
+		if err != nil || len(mensajes) > 0 || !time.Now().Before(fin) {
+			return &ReceiveMessageOutput{Messages: mensajes}, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return &ReceiveMessageOutput{}, ctx.Err()
+		case <-time.After(intervaloEspera):
+		}
+	}
+}
+
+// DeleteMessage elimina el mensaje de la recepción indicada.
+func (q *MemoryQueue) DeleteMessage(_ context.Context, input *DeleteMessageInput) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, mensaje := range q.mensajes {
+		if mensaje.ReceiptHandle != "" && mensaje.ReceiptHandle == input.ReceiptHandle {
+			q.mensajes = append(q.mensajes[:i], q.mensajes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrReceiptHandleInvalido
+}
+
+// Len retorna la cantidad de mensajes en la cola, visibles o no.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.mensajes)
+}
+
+// recibir marca como recibidos hasta limite mensajes visibles.
+func (q *MemoryQueue) recibir(limite int, visibilityTimeout time.Duration) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ahora := time.Now()
+	var recibidos []Message
+	for _, mensaje := range q.mensajes {
+		if len(recibidos) == limite {
+			break
+		}
+		if mensaje.visibleDesde.After(ahora) {
+			continue
+		}
+
+		receiptHandle, err := nuevoIdentificador()
+		if err != nil {
+			return nil, err
+		}
+		mensaje.ReceiptHandle = receiptHandle
+		mensaje.ReceiveCount++
+		mensaje.visibleDesde = ahora.Add(visibilityTimeout)
+		recibidos = append(recibidos, mensaje.Message)
+	}
+	return recibidos, nil
+}
+
+// nuevoIdentificador genera un identificador aleatorio para mensajes y receipt handles.
+func nuevoIdentificador() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,99 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/queue"
+)
+
+func TestMemoryQueue_EnviarRecibirEliminar(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+
+	enviado, err := q.SendMessage(ctx, &queue.SendMessageInput{MessageBody: "hola"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enviado.MessageID)
+
+	output, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{VisibilityTimeout: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, output.Messages, 1)
+	mensaje := output.Messages[0]
+	assert.Equal(t, enviado.MessageID, mensaje.MessageID)
+	assert.Equal(t, "hola", mensaje.Body)
+	assert.Equal(t, 1, mensaje.ReceiveCount)
+	assert.NotEmpty(t, mensaje.ReceiptHandle)
+
+	// Mientras el mensaje está oculto no se entrega de nuevo
+	output, err = q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{VisibilityTimeout: time.Minute})
+	assert.NoError(t, err)
+	assert.Empty(t, output.Messages)
+
+	assert.NoError(t, q.DeleteMessage(ctx, &queue.DeleteMessageInput{ReceiptHandle: mensaje.ReceiptHandle}))
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestMemoryQueue_ReintentoTrasVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+	_, err := q.SendMessage(ctx, &queue.SendMessageInput{MessageBody: "hola"})
+	assert.NoError(t, err)
+
+	primera, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{VisibilityTimeout: 0})
+	assert.NoError(t, err)
+	segunda, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{VisibilityTimeout: 0})
+	assert.NoError(t, err)
+
+	assert.Len(t, segunda.Messages, 1)
+	assert.Equal(t, 2, segunda.Messages[0].ReceiveCount)
+	assert.NotEqual(t, primera.Messages[0].ReceiptHandle, segunda.Messages[0].ReceiptHandle)
+
+	// El receipt handle de una recepción anterior ya no es válido
+	err = q.DeleteMessage(ctx, &queue.DeleteMessageInput{ReceiptHandle: primera.Messages[0].ReceiptHandle})
+	assert.ErrorIs(t, err, queue.ErrReceiptHandleInvalido)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestMemoryQueue_MaxNumberOfMessages(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+	for i := 0; i < 15; i++ {
+		_, err := q.SendMessage(ctx, &queue.SendMessageInput{MessageBody: "hola"})
+		assert.NoError(t, err)
+	}
+
+	output, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{MaxNumberOfMessages: 3, VisibilityTimeout: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, output.Messages, 3)
+
+	output, err = q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{VisibilityTimeout: time.Minute})
+	assert.NoError(t, err)
+	assert.Len(t, output.Messages, queue.MaxNumberOfMessages)
+}
+
+func TestMemoryQueue_LongPolling(t *testing.T) {
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		q.SendMessage(ctx, &queue.SendMessageInput{MessageBody: "tarde"})
+	}()
+
+	output, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{WaitTime: time.Second})
+	assert.NoError(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.Equal(t, "tarde", output.Messages[0].Body)
+}
+
+func TestMemoryQueue_LongPollingCancelado(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	q := queue.NewMemoryQueue()
+
+	output, err := q.ReceiveMessage(ctx, &queue.ReceiveMessageInput{WaitTime: time.Minute})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, output.Messages)
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Config contiene la configuración de la cola que lee el entrypoint del consumidor.
+type Config struct {
+	// URL es la URL de la cola de SQS de la que se reciben los mensajes.
+	URL string
+	// DeadLetterURL es la URL de la dead-letter queue; vacía se usa la redrive policy de la cola, si tiene.
+	DeadLetterURL string
+	// MaxReceiveCount es la cantidad de recepciones tras la cual un mensaje se envía a la dead-letter queue.
+	MaxReceiveCount   int
+	VisibilityTimeout time.Duration
+	WaitTime          time.Duration
+}
+
+// MaxReceiveCountPorDefecto es la cantidad de recepciones por defecto antes de enviar un mensaje a la
+// dead-letter queue.
+const MaxReceiveCountPorDefecto = 5
+
+// Message es un mensaje recibido de la cola, con los mismos campos que usa SQS.
+type Message struct {
+	MessageID string
+	// ReceiptHandle identifica esta recepción del mensaje y es el valor que se usa para eliminarlo.
+	ReceiptHandle string
+	Body          string
+	// ReceiveCount es la cantidad de veces que el mensaje ha sido recibido, incluyendo esta.
+	ReceiveCount int
+}
+
+// ReceiveMessageInput contiene los parámetros de ReceiveMessage.
+type ReceiveMessageInput struct {
+	// MaxNumberOfMessages es la cantidad máxima de mensajes a recibir, entre 1 y 10.
+	MaxNumberOfMessages int
+	// VisibilityTimeout es el tiempo durante el cual los mensajes recibidos no se entregan a otro consumidor.
+	VisibilityTimeout time.Duration
+	// WaitTime es el tiempo máximo que se espera a que haya mensajes disponibles (long polling).
+	WaitTime time.Duration
+}
+
+// ReceiveMessageOutput contiene los mensajes recibidos.
+type ReceiveMessageOutput struct {
+	Messages []Message
+}
+
+// DeleteMessageInput contiene los parámetros de DeleteMessage.
+type DeleteMessageInput struct {
+	ReceiptHandle string
+}
+
+// SendMessageInput contiene los parámetros de SendMessage.
+type SendMessageInput struct {
+	MessageBody string
+}
+
+// SendMessageOutput contiene el identificador del mensaje enviado.
+type SendMessageOutput struct {
+	MessageID string
+}
+
+// QueueInterface define las operaciones de una cola, modeladas sobre el API de SQS.
+type QueueInterface interface {
+	ReceiveMessage(ctx context.Context, input *ReceiveMessageInput) (*ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, input *DeleteMessageInput) error
+	SendMessage(ctx context.Context, input *SendMessageInput) (*SendMessageOutput, error)
+}
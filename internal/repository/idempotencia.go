@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gmf_transmission_response/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotenciaRepositoryInterface define los métodos para almacenar las claves de idempotencia.
+type IdempotenciaRepositoryInterface interface {
+	GetIdempotencia(clave string, ahora time.Time) (*models.CGDIdempotencia, error)
+	ReservarIdempotencia(registro *models.CGDIdempotencia) (bool, error)
+	CompletarIdempotencia(registro *models.CGDIdempotencia) error
+	LiberarIdempotencia(clave, hashSolicitud string) error
+	DeleteIdempotenciaExpirada(ahora time.Time) error
+}
+
+// ErrIdempotenciaNoReservada indica que la clave ya no está reservada para la solicitud, porque la reserva
+// venció y otra solicitud la tomó.
+var ErrIdempotenciaNoReservada = errors.New("la Idempotency-Key ya no está reservada para esta solicitud")
+
+// GormIdempotenciaRepository implementa el repositorio de idempotencia utilizando GORM.
+type GormIdempotenciaRepository struct {
+	DB *gorm.DB
+}
+
+// NewIdempotenciaRepository crea una nueva instancia de GormIdempotenciaRepository.
+func NewIdempotenciaRepository(db *gorm.DB) *GormIdempotenciaRepository {
+	return &GormIdempotenciaRepository{
+		DB: db,
+	}
+}
+
+// GetIdempotencia obtiene una clave de idempotencia que no haya expirado.
+func (r *GormIdempotenciaRepository) GetIdempotencia(clave string, ahora time.Time) (*models.CGDIdempotencia, error) {
+	var registro models.CGDIdempotencia
+	if err := r.DB.Where(
+		"clave = ? AND fecha_expiracion > ?", clave, ahora).First(&registro).Error; err != nil {
+		return nil, err
+	}
+	return &registro, nil
+}
+
+// ReservarIdempotencia registra la clave si no existe o si la registrada ya expiró. Retorna false si otra
+// solicitud tiene la clave, en proceso o completada; la inserción es atómica, por lo que de varias
+// solicitudes concurrentes con la misma clave solo una la reserva.
+func (r *GormIdempotenciaRepository) ReservarIdempotencia(registro *models.CGDIdempotencia) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "clave"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"hash_solicitud", "estado", "codigo_respuesta", "cuerpo_respuesta", "fecha_creacion", "fecha_expiracion",
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: `"cgd_idempotencia"."fecha_expiracion" <= ?`, Vars: []interface{}{registro.FechaCreacion}},
+		}},
+	}).Create(registro)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CompletarIdempotencia guarda la respuesta de la clave reservada por la misma solicitud. Retorna
+// ErrIdempotenciaNoReservada si la reserva ya no pertenece a la solicitud.
+func (r *GormIdempotenciaRepository) CompletarIdempotencia(registro *models.CGDIdempotencia) error {
+	result := r.DB.Model(&models.CGDIdempotencia{}).
+		Where("clave = ? AND hash_solicitud = ? AND estado = ?",
+			registro.Clave, registro.HashSolicitud, models.IdempotenciaEnProceso).
+		Updates(map[string]interface{}{
+			"estado":           models.IdempotenciaCompletada,
+			"codigo_respuesta": registro.CodigoRespuesta,
+			"cuerpo_respuesta": registro.CuerpoRespuesta,
+			"fecha_expiracion": registro.FechaExpiracion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotenciaNoReservada
+	}
+	return nil
+}
+
+// LiberarIdempotencia elimina la reserva de una solicitud que terminó sin una respuesta que deba repetirse.
+func (r *GormIdempotenciaRepository) LiberarIdempotencia(clave, hashSolicitud string) error {
+	return r.DB.Where("clave = ? AND hash_solicitud = ? AND estado = ?",
+		clave, hashSolicitud, models.IdempotenciaEnProceso).Delete(&models.CGDIdempotencia{}).Error
+}
+
+// DeleteIdempotenciaExpirada elimina las claves cuya ventana de retención ya terminó.
+func (r *GormIdempotenciaRepository) DeleteIdempotenciaExpirada(ahora time.Time) error {
+	return r.DB.Where("fecha_expiracion <= ?", ahora).Delete(&models.CGDIdempotencia{}).Error
+}
@@ -0,0 +1,166 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+)
+
+func TestGetIdempotencia(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+	ahora := time.Now()
+
+	mock.ExpectQuery(
+		`SELECT \* FROM "cgd_idempotencia" WHERE clave = \$1 AND fecha_expiracion > \$2 ORDER BY "cgd_idempotencia"."clave" LIMIT \$3`).
+		WithArgs("clave-1", ahora, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"clave", "hash_solicitud", "codigo_respuesta", "cuerpo_respuesta"}).
+			AddRow("clave-1", "hash", 200, `{"success":true}`))
+
+	// Ejecutar el método
+	registro, err := repo.GetIdempotencia("clave-1", ahora)
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.Equal(t, 200, registro.CodigoRespuesta)
+	assert.Equal(t, `{"success":true}`, registro.CuerpoRespuesta)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReservarIdempotencia(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+	ahora := time.Now()
+
+	registro := &models.CGDIdempotencia{
+		Clave:           "clave-1",
+		HashSolicitud:   "hash",
+		Estado:          models.IdempotenciaEnProceso,
+		FechaCreacion:   ahora,
+		FechaExpiracion: ahora.Add(time.Minute),
+	}
+
+	// La clave se inserta, o reemplaza solo si la registrada ya expiró
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "cgd_idempotencia" .* ON CONFLICT \("clave"\) DO UPDATE SET .* WHERE "cgd_idempotencia"."fecha_expiracion" <= \$8`).
+		WithArgs("clave-1", "hash", models.IdempotenciaEnProceso, 0, "", ahora, ahora.Add(time.Minute), ahora).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	reservada, err := repo.ReservarIdempotencia(registro)
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.True(t, reservada)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReservarIdempotencia_ClaveExistente(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+	ahora := time.Now()
+
+	// La clave vigente no se reemplaza, por lo que no se afecta ninguna fila
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "cgd_idempotencia"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	reservada, err := repo.ReservarIdempotencia(&models.CGDIdempotencia{
+		Clave: "clave-1", HashSolicitud: "hash", Estado: models.IdempotenciaEnProceso,
+		FechaCreacion: ahora, FechaExpiracion: ahora.Add(time.Minute),
+	})
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.False(t, reservada)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompletarIdempotencia(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+	expiracion := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_idempotencia" SET "codigo_respuesta"=\$1,"cuerpo_respuesta"=\$2,"estado"=\$3,"fecha_expiracion"=\$4 WHERE clave = \$5 AND hash_solicitud = \$6 AND estado = \$7`).
+		WithArgs(200, `{"success":true}`, models.IdempotenciaCompletada, expiracion,
+			"clave-1", "hash", models.IdempotenciaEnProceso).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	err := repo.CompletarIdempotencia(&models.CGDIdempotencia{
+		Clave: "clave-1", HashSolicitud: "hash", CodigoRespuesta: 200,
+		CuerpoRespuesta: `{"success":true}`, FechaExpiracion: expiracion,
+	})
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompletarIdempotencia_NoReservada(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_idempotencia"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	err := repo.CompletarIdempotencia(&models.CGDIdempotencia{Clave: "clave-1", HashSolicitud: "hash"})
+
+	// Verificar los resultados
+	assert.ErrorIs(t, err, repository.ErrIdempotenciaNoReservada)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLiberarIdempotencia(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "cgd_idempotencia" WHERE clave = \$1 AND hash_solicitud = \$2 AND estado = \$3`).
+		WithArgs("clave-1", "hash", models.IdempotenciaEnProceso).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	err := repo.LiberarIdempotencia("clave-1", "hash")
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteIdempotenciaExpirada(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewIdempotenciaRepository(gormDB)
+	ahora := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "cgd_idempotencia" WHERE fecha_expiracion <= \$1`).
+		WithArgs(ahora).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	err := repo.DeleteIdempotenciaExpirada(ahora)
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
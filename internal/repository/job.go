@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gmf_transmission_response/internal/models"
+	"gorm.io/gorm"
+)
+
+// JobRepositoryInterface define los métodos para almacenar los jobs de procesamiento asíncrono.
+type JobRepositoryInterface interface {
+	CreateJob(job *models.CGDJob) error
+	UpdateJob(job *models.CGDJob) error
+	GetJob(id string) (*models.CGDJob, error)
+	GetJobsPendientes(ahora time.Time) ([]models.CGDJob, error)
+	ReclamarJob(id, owner string, hasta, ahora time.Time) (bool, error)
+	RenovarJob(id, owner string, hasta time.Time) error
+	DeleteJobsTerminados(antesDe time.Time) error
+}
+
+// ErrJobSinReserva indica que la instancia ya no tiene reservado el job, porque su reserva venció y
+// otra instancia lo retomó.
+var ErrJobSinReserva = errors.New("la reserva del job venció y otra instancia lo retomó")
+
+// condicionDisponible selecciona los jobs que ninguna instancia tiene reservados: los pendientes y los
+// que quedaron en proceso con la reserva vencida, por ejemplo porque su instancia se detuvo.
+const condicionDisponible = "(estado = ? OR (estado = ? AND (lease_until IS NULL OR lease_until < ?)))"
+
+// GormJobRepository implementa el repositorio de jobs utilizando GORM.
+type GormJobRepository struct {
+	DB *gorm.DB
+}
+
+// NewJobRepository crea una nueva instancia de GormJobRepository.
+func NewJobRepository(db *gorm.DB) *GormJobRepository {
+	return &GormJobRepository{
+		DB: db,
+	}
+}
+
+// CreateJob inserta un nuevo job.
+func (r *GormJobRepository) CreateJob(job *models.CGDJob) error {
+	return registrarError("CreateJob", r.DB.Create(job).Error)
+}
+
+// UpdateJob actualiza el estado, el resultado y el error de un job, solo si la instancia indicada en
+// job.Owner aún lo tiene reservado. Retorna ErrJobSinReserva si otra instancia lo retomó.
+func (r *GormJobRepository) UpdateJob(job *models.CGDJob) error {
+	result := r.DB.Model(job).Where("owner = ?", job.Owner).Updates(map[string]interface{}{
+		"estado":              job.Estado,
+		"resultado":           job.Resultado,
+		"error":               job.Error,
+		"fecha_actualizacion": job.FechaActualizacion,
+	})
+	if result.Error != nil {
+		return registrarError("UpdateJob", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobSinReserva
+	}
+	return nil
+}
+
+// GetJob obtiene un job por su ID.
+func (r *GormJobRepository) GetJob(id string) (*models.CGDJob, error) {
+	var job models.CGDJob
+	if err := r.DB.Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, registrarError("GetJob", err)
+	}
+	return &job, nil
+}
+
+// GetJobsPendientes obtiene los jobs pendientes y los que quedaron en proceso con la reserva vencida,
+// en el orden en que fueron creados.
+func (r *GormJobRepository) GetJobsPendientes(ahora time.Time) ([]models.CGDJob, error) {
+	jobs := make([]models.CGDJob, 0)
+	if err := r.DB.Where(condicionDisponible, models.JobPendiente, models.JobEnProceso, ahora).
+		Order("fecha_creacion ASC").Find(&jobs).Error; err != nil {
+		return nil, registrarError("GetJobsPendientes", err)
+	}
+	return jobs, nil
+}
+
+// ReclamarJob reserva el job para la instancia owner hasta el momento indicado y lo pasa a RUNNING, solo
+// si está pendiente o su reserva venció. Retorna false si otra instancia lo tiene reservado o ya terminó.
+func (r *GormJobRepository) ReclamarJob(id, owner string, hasta, ahora time.Time) (bool, error) {
+	result := r.DB.Model(&models.CGDJob{}).
+		Where("id = ? AND "+condicionDisponible, id, models.JobPendiente, models.JobEnProceso, ahora).
+		Updates(map[string]interface{}{
+			"estado":              models.JobEnProceso,
+			"owner":               owner,
+			"lease_until":         hasta,
+			"fecha_actualizacion": ahora,
+		})
+	if result.Error != nil {
+		return false, registrarError("ReclamarJob", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RenovarJob extiende la reserva del job mientras la instancia owner lo procesa. Retorna ErrJobSinReserva
+// si otra instancia lo retomó.
+func (r *GormJobRepository) RenovarJob(id, owner string, hasta time.Time) error {
+	result := r.DB.Model(&models.CGDJob{}).
+		Where("id = ? AND owner = ? AND estado = ?", id, owner, models.JobEnProceso).
+		Update("lease_until", hasta)
+	if result.Error != nil {
+		return registrarError("RenovarJob", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobSinReserva
+	}
+	return nil
+}
+
+// DeleteJobsTerminados elimina los jobs COMPLETED y FAILED cuya última actualización es anterior a antesDe.
+func (r *GormJobRepository) DeleteJobsTerminados(antesDe time.Time) error {
+	return registrarError("DeleteJobsTerminados", r.DB.
+		Where("estado IN ? AND fecha_actualizacion < ?", []string{models.JobCompletado, models.JobFallido}, antesDe).
+		Delete(&models.CGDJob{}).Error)
+}
@@ -0,0 +1,209 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+)
+
+func TestCreateJob(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	ahora := time.Now()
+	job := &models.CGDJob{
+		ID:                 "6f1c2d3e-0000-4000-8000-000000000001",
+		Estado:             models.JobPendiente,
+		TotalArchivos:      1,
+		Solicitud:          `[]`,
+		RequestID:          "req-1",
+		FechaCreacion:      ahora,
+		FechaActualizacion: ahora,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "cgd_jobs"`).
+		WithArgs(job.ID, job.Estado, job.TotalArchivos, job.Solicitud, job.RequestID, "", "", "", nil, ahora,
+			ahora).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.CreateJob(job)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateJob(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	job := &models.CGDJob{
+		ID:                 "6f1c2d3e-0000-4000-8000-000000000001",
+		Estado:             models.JobCompletado,
+		Resultado:          `{"success":true}`,
+		Owner:              "instancia-1",
+		FechaActualizacion: time.Now(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs" SET "error"=\$1,"estado"=\$2,"fecha_actualizacion"=\$3,"resultado"=\$4 WHERE owner = \$5 AND "id" = \$6`).
+		WithArgs("", job.Estado, job.FechaActualizacion, job.Resultado, job.Owner, job.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateJob(job)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateJob_SinReserva(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	job := &models.CGDJob{ID: "job-1", Estado: models.JobCompletado, Owner: "instancia-1", FechaActualizacion: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.UpdateJob(job)
+
+	assert.ErrorIs(t, err, repository.ErrJobSinReserva)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJob(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	mock.ExpectQuery(`SELECT \* FROM "cgd_jobs" WHERE id = \$1 ORDER BY "cgd_jobs"."id" LIMIT \$2`).
+		WithArgs("job-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "estado", "total_archivos"}).
+			AddRow("job-1", models.JobEnProceso, 3))
+
+	job, err := repo.GetJob("job-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobEnProceso, job.Estado)
+	assert.Equal(t, 3, job.TotalArchivos)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobsPendientes(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	ahora := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "cgd_jobs" WHERE \(estado = \$1 OR \(estado = \$2 AND \(lease_until IS NULL OR lease_until < \$3\)\)\) ORDER BY fecha_creacion ASC`).
+		WithArgs(models.JobPendiente, models.JobEnProceso, ahora).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "estado"}).
+			AddRow("job-1", models.JobPendiente).
+			AddRow("job-2", models.JobEnProceso))
+
+	jobs, err := repo.GetJobsPendientes(ahora)
+
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobsPendientes_Error(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	mock.ExpectQuery(`SELECT \* FROM "cgd_jobs"`).WillReturnError(errors.New("query error"))
+
+	jobs, err := repo.GetJobsPendientes(time.Now())
+
+	assert.Nil(t, jobs)
+	assert.EqualError(t, err, "query error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReclamarJob(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	ahora := time.Now()
+	hasta := ahora.Add(5 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs" SET "estado"=\$1,"fecha_actualizacion"=\$2,"lease_until"=\$3,"owner"=\$4 WHERE id = \$5 AND \(estado = \$6 OR \(estado = \$7 AND \(lease_until IS NULL OR lease_until < \$8\)\)\)`).
+		WithArgs(models.JobEnProceso, ahora, hasta, "instancia-1", "job-1", models.JobPendiente, models.JobEnProceso, ahora).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reservado, err := repo.ReclamarJob("job-1", "instancia-1", hasta, ahora)
+
+	assert.NoError(t, err)
+	assert.True(t, reservado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReclamarJob_ReservadoPorOtraInstancia(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	reservado, err := repo.ReclamarJob("job-1", "instancia-1", time.Now().Add(time.Minute), time.Now())
+
+	assert.NoError(t, err)
+	assert.False(t, reservado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenovarJob(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	hasta := time.Now().Add(5 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs" SET "lease_until"=\$1 WHERE id = \$2 AND owner = \$3 AND estado = \$4`).
+		WithArgs(hasta, "job-1", "instancia-1", models.JobEnProceso).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.RenovarJob("job-1", "instancia-1", hasta))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenovarJob_SinReserva(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_jobs"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.RenovarJob("job-1", "instancia-1", time.Now())
+
+	assert.ErrorIs(t, err, repository.ErrJobSinReserva)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteJobsTerminados(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewJobRepository(gormDB)
+
+	antesDe := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "cgd_jobs" WHERE estado IN \(\$1,\$2\) AND fecha_actualizacion < \$3`).
+		WithArgs(models.JobCompletado, models.JobFallido, antesDe).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.DeleteJobsTerminados(antesDe))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
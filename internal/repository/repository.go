@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+
+	"gmf_transmission_response/internal/metrics"
 	"gmf_transmission_response/internal/models"
 	"gorm.io/gorm"
 )
@@ -8,13 +12,32 @@ import (
 // RepositoryInterface methods de la interfaz GormArchivoRepository
 type RepositoryInterface interface {
 	GetArchivoByNombreArchivo(nombreArchivo string) (*models.CGDArchivos, error)
-	UpdateArchivo(archivo *models.CGDArchivos) error
+	GetConsultaArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error)
+	GetHistorialEstados(idArchivo int64, filtro models.FiltroHistorial) ([]models.CGDArchivoEstados, int64, error)
+	UpdateArchivo(archivo *models.CGDArchivos, estadoAnterior string) error
 	InsertEstadoArchivo(estado *models.CGDArchivoEstados) error
+	GetArchivosByNombresArchivo(nombresArchivo []string) ([]models.CGDArchivos, error)
+	UpdateArchivos(actualizaciones []ActualizacionArchivo) error
+	InsertEstadosArchivo(estados []*models.CGDArchivoEstados) error
+	WithinTransaction(fn func(tx RepositoryInterface) error) error
 }
 
+// ErrEstadoModificado indica que el archivo cambió de estado después de leerlo, por lo que la actualización
+// se descartó para no sobrescribir una transición registrada por otra solicitud.
+var ErrEstadoModificado = errors.New("el estado del archivo cambió mientras se procesaba la respuesta")
+
 // GormArchivoRepository implementa el repositorio de Archivo utilizando GORM.
 type GormArchivoRepository struct {
 	DB *gorm.DB
+
+	// transaccion es la transacción en curso cuando el repositorio se creó en WithinTransaction.
+	transaccion *transaccion
+}
+
+// transaccion registra la primera operación que falló dentro de WithinTransaction para contabilizar
+// el error una sola vez, en la llamada más externa.
+type transaccion struct {
+	operacionFallida string
 }
 
 // NewArchivoRepository crea una nueva instancia de GormArchivoRepository
@@ -29,22 +52,194 @@ func (r *GormArchivoRepository) GetArchivoByNombreArchivo(nombreArchivo string)
 	var archivo models.CGDArchivos
 	if err := r.DB.Where(
 		"acg_nombre_archivo = ?", nombreArchivo).First(&archivo).Error; err != nil {
-		return nil, err
+		return nil, r.registrarError("GetArchivoByNombreArchivo", err)
 	}
 	return &archivo, nil
 }
 
-// UpdateArchivo actualiza el archivo en la base de datos con el nuevo estado de la transmisión.
-func (r *GormArchivoRepository) UpdateArchivo(archivo *models.CGDArchivos) error {
-	return r.DB.Model(&archivo).Updates(map[string]interface{}{
-		"gaw_rta_trans_estado":  archivo.GAWRtaTransEstado,
-		"gaw_rta_trans_codigo":  archivo.GAWRtaTransCodigo,
-		"gaw_rta_trans_detalle": archivo.GAWRtaTransDetalle,
-		"estado":                archivo.Estado,
-	}).Error
+// GetConsultaArchivo obtiene el estado de transmisión, los totales ACG y los datos de anulación
+// de un archivo por su nombre de archivo (ACGNombreArchivo).
+func (r *GormArchivoRepository) GetConsultaArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error) {
+	var consulta models.ArchivoConsulta
+	if err := r.DB.Model(&models.CGDArchivos{}).Where(
+		"acg_nombre_archivo = ?", acgNombreArchivo).Take(&consulta).Error; err != nil {
+		return nil, r.registrarError("GetConsultaArchivo", err)
+	}
+	return &consulta, nil
+}
+
+// GetHistorialEstados obtiene una página de las transiciones de estado de un archivo ordenadas por fecha,
+// junto con el total de transiciones que cumplen el filtro.
+func (r *GormArchivoRepository) GetHistorialEstados(
+	idArchivo int64, filtro models.FiltroHistorial) ([]models.CGDArchivoEstados, int64, error) {
+	query := r.DB.Model(&models.CGDArchivoEstados{}).Where("id_archivo = ?", idArchivo)
+	if !filtro.Desde.IsZero() {
+		query = query.Where("fecha_cambio_estado >= ?", filtro.Desde)
+	}
+	if !filtro.Hasta.IsZero() {
+		query = query.Where("fecha_cambio_estado < ?", filtro.Hasta)
+	}
+	// La sesión permite reutilizar las condiciones en el conteo y en la consulta de la página
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, r.registrarError("GetHistorialEstados", err)
+	}
+
+	estados := make([]models.CGDArchivoEstados, 0)
+	if err := query.Order("fecha_cambio_estado ASC").
+		Limit(filtro.TamanoPagina).
+		Offset((filtro.Pagina - 1) * filtro.TamanoPagina).
+		Find(&estados).Error; err != nil {
+		return nil, 0, r.registrarError("GetHistorialEstados", err)
+	}
+	return estados, total, nil
+}
+
+// UpdateArchivo actualiza el archivo en la base de datos con el nuevo estado de la transmisión,
+// solo si el archivo aún está en estadoAnterior. Retorna ErrEstadoModificado si otra solicitud
+// cambió el estado del archivo después de leerlo.
+func (r *GormArchivoRepository) UpdateArchivo(archivo *models.CGDArchivos, estadoAnterior string) error {
+	result := r.DB.Model(&models.CGDArchivos{}).
+		Where("id_archivo = ? AND estado = ?", archivo.IDArchivo, estadoAnterior).
+		Updates(map[string]interface{}{
+			"gaw_rta_trans_estado":  archivo.GAWRtaTransEstado,
+			"gaw_rta_trans_codigo":  archivo.GAWRtaTransCodigo,
+			"gaw_rta_trans_detalle": archivo.GAWRtaTransDetalle,
+			"estado":                archivo.Estado,
+		})
+	if result.Error != nil {
+		return r.registrarError("UpdateArchivo", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrEstadoModificado
+	}
+	return nil
 }
 
 // InsertEstadoArchivo inserta un nuevo estado en la tabla CGD_ARCHIVO_ESTADO.
 func (r *GormArchivoRepository) InsertEstadoArchivo(estado *models.CGDArchivoEstados) error {
-	return r.DB.Create(estado).Error
+	return r.registrarError("InsertEstadoArchivo", r.DB.Create(estado).Error)
+}
+
+// TamanoLoteSQL es la cantidad máxima de filas que se envían en cada sentencia de las operaciones masivas,
+// para no superar el límite de parámetros por sentencia de Postgres.
+const TamanoLoteSQL = 1000
+
+// GetArchivosByNombresArchivo obtiene los archivos cuyo ACGNombreArchivo está en la lista,
+// con una consulta por cada TamanoLoteSQL nombres. Los nombres que no existen simplemente no aparecen en el resultado.
+func (r *GormArchivoRepository) GetArchivosByNombresArchivo(nombresArchivo []string) ([]models.CGDArchivos, error) {
+	archivos := make([]models.CGDArchivos, 0, len(nombresArchivo))
+	for inicio := 0; inicio < len(nombresArchivo); inicio += TamanoLoteSQL {
+		fin := min(inicio+TamanoLoteSQL, len(nombresArchivo))
+
+		var lote []models.CGDArchivos
+		if err := r.DB.Where("acg_nombre_archivo IN ?", nombresArchivo[inicio:fin]).Find(&lote).Error; err != nil {
+			return nil, r.registrarError("GetArchivosByNombresArchivo", err)
+		}
+		archivos = append(archivos, lote...)
+	}
+	return archivos, nil
+}
+
+// ActualizacionArchivo es el archivo con su nuevo estado y el estado en el que se validó la transición.
+type ActualizacionArchivo struct {
+	Archivo        *models.CGDArchivos
+	EstadoAnterior string
+}
+
+// UpdateArchivos actualiza el resultado de la transmisión y el estado de varios archivos
+// con una sentencia UPDATE ... FROM (VALUES ...) por cada TamanoLoteSQL archivos.
+// Cada archivo solo se actualiza si aún está en su estado anterior; si alguno cambió de estado
+// retorna ErrEstadoModificado y quien llama debe revertir la transacción.
+func (r *GormArchivoRepository) UpdateArchivos(actualizaciones []ActualizacionArchivo) error {
+	for inicio := 0; inicio < len(actualizaciones); inicio += TamanoLoteSQL {
+		fin := min(inicio+TamanoLoteSQL, len(actualizaciones))
+		lote := actualizaciones[inicio:fin]
+
+		valores := make([]string, 0, len(lote))
+		args := make([]interface{}, 0, len(lote)*6)
+		for _, actualizacion := range lote {
+			archivo := actualizacion.Archivo
+			valores = append(valores, "(?::numeric, ?::varchar, ?::varchar, ?::varchar, ?::varchar, ?::varchar)")
+			args = append(args, archivo.IDArchivo, archivo.GAWRtaTransEstado, archivo.GAWRtaTransCodigo,
+				archivo.GAWRtaTransDetalle, archivo.Estado, actualizacion.EstadoAnterior)
+		}
+
+		sql := `UPDATE cgd_archivos AS a SET ` +
+			`gaw_rta_trans_estado = v.gaw_rta_trans_estado, ` +
+			`gaw_rta_trans_codigo = v.gaw_rta_trans_codigo, ` +
+			`gaw_rta_trans_detalle = v.gaw_rta_trans_detalle, ` +
+			`estado = v.estado ` +
+			`FROM (VALUES ` + strings.Join(valores, ", ") + `) ` +
+			`AS v(id_archivo, gaw_rta_trans_estado, gaw_rta_trans_codigo, gaw_rta_trans_detalle, estado, estado_anterior) ` +
+			`WHERE a.id_archivo = v.id_archivo AND a.estado = v.estado_anterior`
+		result := r.DB.Exec(sql, args...)
+		if result.Error != nil {
+			return r.registrarError("UpdateArchivos", result.Error)
+		}
+		if result.RowsAffected != int64(len(lote)) {
+			return ErrEstadoModificado
+		}
+	}
+	return nil
+}
+
+// InsertEstadosArchivo inserta varios estados en la tabla CGD_ARCHIVO_ESTADO
+// con un INSERT por cada TamanoLoteSQL registros.
+func (r *GormArchivoRepository) InsertEstadosArchivo(estados []*models.CGDArchivoEstados) error {
+	if len(estados) == 0 {
+		return nil
+	}
+	return r.registrarError("InsertEstadosArchivo", r.DB.CreateInBatches(estados, TamanoLoteSQL).Error)
+}
+
+// WithinTransaction ejecuta fn dentro de una transacción de base de datos.
+// Si fn retorna un error se hace rollback de todas las operaciones, en caso contrario se hace commit.
+// Los errores de las operaciones ejecutadas en fn se contabilizan una sola vez al terminar la transacción
+// más externa, con el nombre de la primera operación que falló.
+func (r *GormArchivoRepository) WithinTransaction(fn func(tx RepositoryInterface) error) error {
+	if r.transaccion != nil {
+		// Transacción anidada: la contabiliza la transacción más externa
+		return r.DB.Transaction(func(tx *gorm.DB) error {
+			return fn(&GormArchivoRepository{DB: tx, transaccion: r.transaccion})
+		})
+	}
+
+	t := &transaccion{}
+	var errFn error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		errFn = fn(&GormArchivoRepository{DB: tx, transaccion: t})
+		return errFn
+	})
+	switch {
+	case t.operacionFallida != "":
+		metrics.ErroresRepositorio.Inc(t.operacionFallida)
+	case err != nil && !errors.Is(err, errFn):
+		// Falló el inicio o el commit de la transacción, no una operación de fn
+		registrarError("WithinTransaction", err)
+	}
+	return err
+}
+
+// registrarError contabiliza el error de la operación, salvo dentro de WithinTransaction, donde solo se
+// recuerda la operación para contabilizarla al terminar la transacción.
+func (r *GormArchivoRepository) registrarError(operacion string, err error) error {
+	if r.transaccion == nil {
+		return registrarError(operacion, err)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && r.transaccion.operacionFallida == "" {
+		r.transaccion.operacionFallida = operacion
+	}
+	return err
+}
+
+// registrarError contabiliza el error de la operación en las métricas y lo retorna sin cambios.
+// Un registro no encontrado no se considera un error de base de datos.
+func registrarError(operacion string, err error) error {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		metrics.ErroresRepositorio.Inc(operacion)
+	}
+	return err
 }
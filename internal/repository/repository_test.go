@@ -1,6 +1,9 @@
 package repository_test
 
 import (
+	"errors"
+	"fmt"
+	"gmf_transmission_response/internal/metrics"
 	"gmf_transmission_response/internal/models"
 	"gmf_transmission_response/internal/repository"
 	"testing"
@@ -97,14 +100,15 @@ func TestUpdateArchivo(t *testing.T) {
 	// Agregar expectativas para el inicio de la transacción
 	mock.ExpectBegin()
 
-	// Configurar el mock para la consulta SQL de actualización
-	mock.ExpectExec(`UPDATE "cgd_archivos"`).
+	// Configurar el mock para la consulta SQL de actualización, condicionada al estado anterior
+	mock.ExpectExec(`UPDATE "cgd_archivos" SET .* WHERE id_archivo = \$5 AND estado = \$6`).
 		WithArgs(
 			archivo.Estado,
 			archivo.GAWRtaTransCodigo,
 			archivo.GAWRtaTransDetalle,
 			archivo.GAWRtaTransEstado,
 			archivo.IDArchivo,
+			"EMPAQUETADO",
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1)) // Simular éxito en la actualización
 
@@ -112,7 +116,7 @@ func TestUpdateArchivo(t *testing.T) {
 	mock.ExpectCommit()
 
 	// Ejecutar el método
-	err := repo.UpdateArchivo(archivo)
+	err := repo.UpdateArchivo(archivo, "EMPAQUETADO")
 
 	// Verificar que no hubo error
 	assert.NoError(t, err)
@@ -121,6 +125,23 @@ func TestUpdateArchivo(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUpdateArchivo_EstadoModificado(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivo := &models.CGDArchivos{IDArchivo: 1, GAWRtaTransEstado: "SUCCESSFUL", Estado: "ENVIADO"}
+
+	// Otra solicitud ya cambió el estado del archivo, por lo que ninguna fila cumple la condición
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_archivos"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.UpdateArchivo(archivo, "EMPAQUETADO")
+
+	assert.ErrorIs(t, err, repository.ErrEstadoModificado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestInsertEstadoArchivo(t *testing.T) {
 	// Configurar la base de datos de prueba y el mock
 	gormDB, mock := SetupTestDB(t)
@@ -129,8 +150,10 @@ func TestInsertEstadoArchivo(t *testing.T) {
 	// Definir el estado de archivo que será insertado
 	estadoArchivo := models.CGDArchivoEstados{
 		IDArchivo:         1,
-		EstadoInicial:     "PENDING",
-		EstadoFinal:       "SUCCESS",
+		EstadoInicial:     "EMPAQUETADO",
+		EstadoFinal:       "ENVIADO",
+		GAWRtaTransEstado: "SUCCESSFUL",
+		GAWRtaTransCodigo: "0000",
 		FechaCambioEstado: time.Now(),
 	}
 
@@ -141,6 +164,8 @@ func TestInsertEstadoArchivo(t *testing.T) {
 			estadoArchivo.IDArchivo,
 			estadoArchivo.EstadoInicial,
 			estadoArchivo.EstadoFinal,
+			estadoArchivo.GAWRtaTransEstado,
+			estadoArchivo.GAWRtaTransCodigo,
 			sqlmock.AnyArg()). // sqlmock.AnyArg para la fecha
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
@@ -152,3 +177,397 @@ func TestInsertEstadoArchivo(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet()) // Verificar que todas las expectativas fueron cumplidas
 }
+
+func TestWithinTransaction_Commit(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivo := &models.CGDArchivos{
+		IDArchivo:          1,
+		GAWRtaTransEstado:  "SUCCESSFUL",
+		GAWRtaTransCodigo:  "0000",
+		GAWRtaTransDetalle: "Transmisión exitosa",
+		Estado:             "ENVIADO",
+	}
+	estadoArchivo := &models.CGDArchivoEstados{
+		IDArchivo:         1,
+		EstadoInicial:     "PENDING",
+		EstadoFinal:       "SUCCESSFUL",
+		FechaCambioEstado: time.Now(),
+	}
+
+	// Ambas operaciones deben ejecutarse dentro de una única transacción
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_archivos"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO "cgd_archivo_estados"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Ejecutar el método
+	err := repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		if err := tx.UpdateArchivo(archivo, "PENDING"); err != nil {
+			return err
+		}
+		return tx.InsertEstadoArchivo(estadoArchivo)
+	})
+
+	// Verificar los resultados
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTransaction_Rollback(t *testing.T) {
+	// Configurar la base de datos de prueba y el mock
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivo := &models.CGDArchivos{
+		IDArchivo:         1,
+		GAWRtaTransEstado: "ERROR",
+		Estado:            "ENVIO_FALLIDO",
+	}
+	estadoArchivo := &models.CGDArchivoEstados{
+		IDArchivo:         1,
+		EstadoInicial:     "PENDING",
+		EstadoFinal:       "ERROR",
+		FechaCambioEstado: time.Now(),
+	}
+
+	// Si la inserción del histórico falla, la actualización del archivo debe revertirse
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "cgd_archivos"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO "cgd_archivo_estados"`).
+		WillReturnError(errors.New("insert error"))
+	mock.ExpectRollback()
+
+	// Ejecutar el método
+	err := repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		if err := tx.UpdateArchivo(archivo, "PENDING"); err != nil {
+			return err
+		}
+		return tx.InsertEstadoArchivo(estadoArchivo)
+	})
+
+	// Verificar los resultados
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "insert error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTransaction_ErrorSeContabilizaUnaVez(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+	antes := metrics.ErroresRepositorio.Value("InsertEstadoArchivo")
+	antesTransaccion := metrics.ErroresRepositorio.Value("WithinTransaction")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "cgd_archivo_estados"`).WillReturnError(errors.New("insert error"))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// El error de la operación dentro de una transacción anidada solo se contabiliza en la más externa
+	err := repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		return tx.WithinTransaction(func(tx repository.RepositoryInterface) error {
+			return tx.InsertEstadoArchivo(&models.CGDArchivoEstados{IDArchivo: 1})
+		})
+	})
+
+	assert.EqualError(t, err, "insert error")
+	assert.Equal(t, antes+1, metrics.ErroresRepositorio.Value("InsertEstadoArchivo"))
+	assert.Equal(t, antesTransaccion, metrics.ErroresRepositorio.Value("WithinTransaction"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTransaction_EstadoModificadoNoSeContabiliza(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+	antes := metrics.ErroresRepositorio.Value("WithinTransaction")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		return repository.ErrEstadoModificado
+	})
+
+	assert.ErrorIs(t, err, repository.ErrEstadoModificado)
+	assert.Equal(t, antes, metrics.ErroresRepositorio.Value("WithinTransaction"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTransaction_ErrorEnCommit(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+	antes := metrics.ErroresRepositorio.Value("WithinTransaction")
+
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+
+	err := repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		return nil
+	})
+
+	assert.EqualError(t, err, "commit error")
+	assert.Equal(t, antes+1, metrics.ErroresRepositorio.Value("WithinTransaction"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetConsultaArchivo(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	nombreArchivo := "TUTGMF0001000120240312-0001"
+
+	// Solo se consultan las columnas expuestas en la consulta
+	mock.ExpectQuery(
+		`SELECT "cgd_archivos"."id_archivo","cgd_archivos"."acg_nombre_archivo","cgd_archivos"."estado",.*`+
+			`"cgd_archivos"."anulacion_fecha_anulacion" FROM "cgd_archivos" WHERE acg_nombre_archivo = \$1 LIMIT \$2`).
+		WithArgs(nombreArchivo, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo", "acg_nombre_archivo", "estado", "gaw_rta_trans_codigo", "acg_total_tx"}).
+			AddRow(1, nombreArchivo, "ENVIADO", "0000", 25))
+
+	result, err := repo.GetConsultaArchivo(nombreArchivo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ArchivoConsulta{
+		IDArchivo:         1,
+		ACGNombreArchivo:  nombreArchivo,
+		Estado:            "ENVIADO",
+		GAWRtaTransCodigo: "0000",
+		ACGTotalTx:        25,
+	}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetConsultaArchivo_NoEncontrado(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	mock.ExpectQuery(`FROM "cgd_archivos" WHERE acg_nombre_archivo = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo"}))
+
+	result, err := repo.GetConsultaArchivo("TUTGMF0001000120240312-9999")
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetHistorialEstados(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	desde := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	hasta := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	filtro := models.FiltroHistorial{Desde: desde, Hasta: hasta, Pagina: 2, TamanoPagina: 10}
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "cgd_archivo_estados" `+
+		`WHERE id_archivo = \$1 AND fecha_cambio_estado >= \$2 AND fecha_cambio_estado < \$3`).
+		WithArgs(1, desde, hasta).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery(`SELECT \* FROM "cgd_archivo_estados" `+
+		`WHERE id_archivo = \$1 AND fecha_cambio_estado >= \$2 AND fecha_cambio_estado < \$3 `+
+		`ORDER BY fecha_cambio_estado ASC LIMIT \$4 OFFSET \$5`).
+		WithArgs(1, desde, hasta, 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo", "estado_inicial", "estado_final", "fecha_cambio_estado"}).
+			AddRow(1, "EMPAQUETADO", "ENVIO_FALLIDO", desde.Add(time.Hour)).
+			AddRow(1, "ENVIO_FALLIDO", "ENVIADO", desde.Add(2*time.Hour)))
+
+	estados, total, err := repo.GetHistorialEstados(1, filtro)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), total)
+	assert.Len(t, estados, 2)
+	assert.Equal(t, "ENVIO_FALLIDO", estados[0].EstadoFinal)
+	assert.Equal(t, "ENVIADO", estados[1].EstadoFinal)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetHistorialEstados_SinRangoDeFechas(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "cgd_archivo_estados" WHERE id_archivo = \$1$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "cgd_archivo_estados" WHERE id_archivo = \$1 ORDER BY fecha_cambio_estado ASC LIMIT \$2$`).
+		WithArgs(1, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo"}))
+
+	estados, total, err := repo.GetHistorialEstados(1, models.FiltroHistorial{Pagina: 1, TamanoPagina: 50})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NotNil(t, estados)
+	assert.Empty(t, estados)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivosByNombresArchivo(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	nombres := []string{"TUTGMF0001000120240312-0001", "TUTGMF0001000120240312-0002"}
+
+	// Todos los archivos se consultan en una sola sentencia
+	mock.ExpectQuery(`SELECT \* FROM "cgd_archivos" WHERE acg_nombre_archivo IN \(\$1,\$2\)`).
+		WithArgs(nombres[0], nombres[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo", "acg_nombre_archivo", "estado"}).
+			AddRow(1, nombres[0], "EMPAQUETADO").
+			AddRow(2, nombres[1], "ENVIADO"))
+
+	archivos, err := repo.GetArchivosByNombresArchivo(nombres)
+
+	assert.NoError(t, err)
+	assert.Len(t, archivos, 2)
+	assert.Equal(t, nombres[1], archivos[1].ACGNombreArchivo)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivosByNombresArchivo_ListaVacia(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivos, err := repo.GetArchivosByNombresArchivo(nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, archivos)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateArchivos(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivos := []*models.CGDArchivos{
+		{IDArchivo: 1, GAWRtaTransEstado: "SUCCESSFUL", GAWRtaTransCodigo: "0000", Estado: "ENVIADO"},
+		{IDArchivo: 2, GAWRtaTransEstado: "ERROR", GAWRtaTransCodigo: "0001",
+			GAWRtaTransDetalle: "Error en la transmisión", Estado: "ENVIO_FALLIDO"},
+	}
+
+	// Todos los archivos se actualizan con una sola sentencia
+	mock.ExpectExec(`UPDATE cgd_archivos AS a SET gaw_rta_trans_estado = v.gaw_rta_trans_estado, .*estado = v.estado `+
+		`FROM \(VALUES \(\$1::numeric, \$2::varchar, \$3::varchar, \$4::varchar, \$5::varchar, \$6::varchar\), `+
+		`\(\$7::numeric, \$8::varchar, \$9::varchar, \$10::varchar, \$11::varchar, \$12::varchar\)\) `+
+		`AS v\(id_archivo, gaw_rta_trans_estado, gaw_rta_trans_codigo, gaw_rta_trans_detalle, estado, estado_anterior\) `+
+		`WHERE a.id_archivo = v.id_archivo AND a.estado = v.estado_anterior`).
+		WithArgs(1, "SUCCESSFUL", "0000", "", "ENVIADO", "EMPAQUETADO",
+			2, "ERROR", "0001", "Error en la transmisión", "ENVIO_FALLIDO", "EMPAQUETADO").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := repo.UpdateArchivos(actualizaciones(archivos, "EMPAQUETADO"))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateArchivos_DivideEnLotes(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivos := make([]*models.CGDArchivos, repository.TamanoLoteSQL+1)
+	for i := range archivos {
+		archivos[i] = &models.CGDArchivos{IDArchivo: int64(i + 1), Estado: "ENVIADO"}
+	}
+
+	// Se envía una sentencia por cada TamanoLoteSQL archivos
+	mock.ExpectExec(`UPDATE cgd_archivos AS a`).WillReturnResult(sqlmock.NewResult(0, repository.TamanoLoteSQL))
+	mock.ExpectExec(`UPDATE cgd_archivos AS a .*FROM \(VALUES \(\$1::numeric, \$2::varchar, \$3::varchar, \$4::varchar, \$5::varchar, \$6::varchar\)\) AS v`).
+		WithArgs(repository.TamanoLoteSQL+1, "", "", "", "ENVIADO", "EMPAQUETADO").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.UpdateArchivos(actualizaciones(archivos, "EMPAQUETADO"))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateArchivos_Error(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	mock.ExpectExec(`UPDATE cgd_archivos AS a`).WillReturnError(errors.New("update error"))
+
+	err := repo.UpdateArchivos(actualizaciones([]*models.CGDArchivos{{IDArchivo: 1}}, "EMPAQUETADO"))
+
+	assert.EqualError(t, err, "update error")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateArchivos_EstadoModificado(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	archivos := []*models.CGDArchivos{{IDArchivo: 1, Estado: "ENVIADO"}, {IDArchivo: 2, Estado: "ENVIADO"}}
+
+	// Uno de los archivos ya no está en su estado anterior y no se actualiza
+	mock.ExpectExec(`UPDATE cgd_archivos AS a`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.UpdateArchivos(actualizaciones(archivos, "EMPAQUETADO"))
+
+	assert.ErrorIs(t, err, repository.ErrEstadoModificado)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetArchivosByNombresArchivo_DivideEnLotes(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	nombres := make([]string, repository.TamanoLoteSQL+1)
+	for i := range nombres {
+		nombres[i] = fmt.Sprintf("TUTGMF0001000120240312-%04d", i+1)
+	}
+
+	// Se envía una consulta por cada TamanoLoteSQL nombres
+	mock.ExpectQuery(`SELECT \* FROM "cgd_archivos" WHERE acg_nombre_archivo IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo", "acg_nombre_archivo"}).AddRow(1, nombres[0]))
+	mock.ExpectQuery(`SELECT \* FROM "cgd_archivos" WHERE acg_nombre_archivo IN \(\$1\)`).
+		WithArgs(nombres[repository.TamanoLoteSQL]).
+		WillReturnRows(sqlmock.NewRows([]string{"id_archivo", "acg_nombre_archivo"}).
+			AddRow(repository.TamanoLoteSQL+1, nombres[repository.TamanoLoteSQL]))
+
+	archivos, err := repo.GetArchivosByNombresArchivo(nombres)
+
+	assert.NoError(t, err)
+	assert.Len(t, archivos, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// actualizaciones construye las actualizaciones de los archivos con el mismo estado anterior.
+func actualizaciones(archivos []*models.CGDArchivos, estadoAnterior string) []repository.ActualizacionArchivo {
+	resultado := make([]repository.ActualizacionArchivo, 0, len(archivos))
+	for _, archivo := range archivos {
+		resultado = append(resultado, repository.ActualizacionArchivo{Archivo: archivo, EstadoAnterior: estadoAnterior})
+	}
+	return resultado
+}
+
+func TestInsertEstadosArchivo(t *testing.T) {
+	gormDB, mock := SetupTestDB(t)
+	repo := repository.NewArchivoRepository(gormDB)
+
+	fecha := time.Now()
+	estados := []*models.CGDArchivoEstados{
+		{IDArchivo: 1, EstadoInicial: "EMPAQUETADO", EstadoFinal: "ENVIADO",
+			GAWRtaTransEstado: "SUCCESSFUL", GAWRtaTransCodigo: "0000", FechaCambioEstado: fecha},
+		{IDArchivo: 2, EstadoInicial: "EMPAQUETADO", EstadoFinal: "ENVIO_FALLIDO",
+			GAWRtaTransEstado: "ERROR", GAWRtaTransCodigo: "0001", FechaCambioEstado: fecha},
+	}
+
+	// Todos los estados se insertan con una sola sentencia
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "cgd_archivo_estados" .* VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\),\(\$7,\$8,\$9,\$10,\$11,\$12\)`).
+		WithArgs(1, "EMPAQUETADO", "ENVIADO", "SUCCESSFUL", "0000", fecha,
+			2, "EMPAQUETADO", "ENVIO_FALLIDO", "ERROR", "0001", fecha).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := repo.InsertEstadosArchivo(estados)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
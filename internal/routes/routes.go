@@ -2,11 +2,35 @@ package routes
 
 import (
 	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/metrics"
 	"net/http"
 )
 
-func SetupRoutes(archivoHandle handler.ArchivoHandlerInterface) {
-	http.HandleFunc("/transmission", func(w http.ResponseWriter, r *http.Request) {
-		archivoHandle.HandleTransmisionResponses(w, r)
+func SetupRoutes(archivoHandle handler.ArchivoHandlerInterface, healthHandle handler.HealthHandlerInterface) {
+	http.HandleFunc("/transmission", metrics.InstrumentHandler("HandleTransmisionResponses",
+		func(w http.ResponseWriter, r *http.Request) {
+			archivoHandle.HandleTransmisionResponses(w, r)
+		}))
+
+	http.HandleFunc("GET /files/{acgNombreArchivo}", func(w http.ResponseWriter, r *http.Request) {
+		archivoHandle.HandleConsultarArchivo(w, r)
+	})
+
+	http.HandleFunc("GET /files/{acgNombreArchivo}/history", func(w http.ResponseWriter, r *http.Request) {
+		archivoHandle.HandleHistorialArchivo(w, r)
+	})
+
+	http.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		archivoHandle.HandleConsultarJob(w, r)
 	})
+
+	http.HandleFunc("GET /health/live", func(w http.ResponseWriter, r *http.Request) {
+		healthHandle.HandleLive(w, r)
+	})
+
+	http.HandleFunc("GET /health/ready", func(w http.ResponseWriter, r *http.Request) {
+		healthHandle.HandleReady(w, r)
+	})
+
+	http.Handle("GET /metrics", metrics.Handler())
 }
@@ -4,6 +4,8 @@ import (
 	"gmf_transmission_response/internal/routes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -16,12 +18,46 @@ func (m *MockArchivoHandler) HandleTransmisionResponses(w http.ResponseWriter, r
 	w.Write([]byte(`{"message": "Mock response"}`))
 }
 
-func TestSetupRoutes(t *testing.T) {
-	// Crear un mock de ArchivoHandler
-	mockHandler := &MockArchivoHandler{}
+func (m *MockArchivoHandler) HandleConsultarArchivo(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"acg_nombre_archivo":"` + r.PathValue("acgNombreArchivo") + `"}`))
+}
+
+func (m *MockArchivoHandler) HandleHistorialArchivo(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"history":"` + r.PathValue("acgNombreArchivo") + `"}`))
+}
 
+func (m *MockArchivoHandler) HandleConsultarJob(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"job_id":"` + r.PathValue("id") + `"}`))
+}
+
+// MockHealthHandler simula el comportamiento de HealthHandler
+type MockHealthHandler struct{}
+
+func (m *MockHealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"UP"}`))
+}
+
+func (m *MockHealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(`{"status":"DOWN"}`))
+}
+
+// setupRoutesOnce registra las rutas una sola vez, ya que http.DefaultServeMux no permite registrarlas de nuevo
+var setupRoutesOnce sync.Once
+
+func setupRoutes() {
+	setupRoutesOnce.Do(func() {
+		routes.SetupRoutes(&MockArchivoHandler{}, &MockHealthHandler{})
+	})
+}
+
+func TestSetupRoutes(t *testing.T) {
 	// Configurar las rutas usando la función SetupRoutes
-	routes.SetupRoutes(mockHandler)
+	setupRoutes()
 
 	// Crear una solicitud HTTP POST (ya que la ruta maneja POST)
 	req, err := http.NewRequest("POST", "/transmission", nil)
@@ -47,3 +83,80 @@ func TestSetupRoutes(t *testing.T) {
 		t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
 	}
 }
+
+func TestSetupRoutes_Health(t *testing.T) {
+	setupRoutes()
+	handler := http.DefaultServeMux
+
+	casos := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/health/live", http.StatusOK, `{"status":"UP"}`},
+		{http.MethodGet, "/health/ready", http.StatusServiceUnavailable, `{"status":"DOWN"}`},
+		{http.MethodPost, "/health/live", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, c := range casos {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != c.status {
+			t.Errorf("%s %s returned wrong status code: got %v want %v", c.method, c.path, rr.Code, c.status)
+		}
+		if c.body != "" && rr.Body.String() != c.body {
+			t.Errorf("%s %s returned unexpected body: got %v want %v", c.method, c.path, rr.Body.String(), c.body)
+		}
+	}
+}
+
+func TestSetupRoutes_Metrics(t *testing.T) {
+	setupRoutes()
+	handler := http.DefaultServeMux
+
+	// Una solicitud a /transmission debe quedar registrada en el histograma de duración
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/transmission", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	expected := `gmf_http_request_duration_seconds_count{handler="HandleTransmisionResponses",method="POST",code="200"}`
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("metrics output does not contain %s:\n%s", expected, rr.Body.String())
+	}
+}
+
+func TestSetupRoutes_ConsultarArchivo(t *testing.T) {
+	setupRoutes()
+	handler := http.DefaultServeMux
+
+	casos := []struct {
+		path string
+		body string
+	}{
+		{"/files/TUTGMF0001000120240312-0001", `{"acg_nombre_archivo":"TUTGMF0001000120240312-0001"}`},
+		{"/files/TUTGMF0001000120240312-0001/history", `{"history":"TUTGMF0001000120240312-0001"}`},
+		{"/jobs/6f1c2d3e-0000-4000-8000-000000000001", `{"job_id":"6f1c2d3e-0000-4000-8000-000000000001"}`},
+	}
+
+	for _, c := range casos {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("GET %s returned wrong status code: got %v want %v", c.path, rr.Code, http.StatusOK)
+		}
+		if rr.Body.String() != c.body {
+			t.Errorf("GET %s returned unexpected body: got %v want %v", c.path, rr.Body.String(), c.body)
+		}
+	}
+}
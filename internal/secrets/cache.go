@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gmf_transmission_response/internal/logs"
+	"golang.org/x/sync/singleflight"
+)
+
+// TTLPorDefecto es el tiempo que se conserva un secreto en caché si no se configura otro valor.
+const TTLPorDefecto = 5 * time.Minute
+
+// entradaSecreto es un secreto guardado en caché con el momento en que expira.
+type entradaSecreto struct {
+	valor      map[string]string
+	expiracion time.Time
+}
+
+// CachedProvider guarda en memoria los secretos obtenidos de otro proveedor durante un TTL,
+// para no consultar Secrets Manager en cada conexión nueva a la base de datos.
+type CachedProvider struct {
+	source SecretProvider
+	ttl    time.Duration
+	// consultas agrupa las consultas simultáneas del mismo secreto en una sola consulta al proveedor.
+	consultas singleflight.Group
+
+	mu       sync.Mutex
+	entradas map[string]entradaSecreto
+	// erroresRefresco guarda el error de la última consulta al proveedor de cada secreto, si falló.
+	erroresRefresco map[string]error
+}
+
+// NewCachedProvider crea una caché de secretos con el TTL indicado.
+func NewCachedProvider(source SecretProvider, ttl time.Duration) *CachedProvider {
+	if ttl <= 0 {
+		ttl = TTLPorDefecto
+	}
+	return &CachedProvider{
+		source:          source,
+		ttl:             ttl,
+		entradas:        make(map[string]entradaSecreto),
+		erroresRefresco: make(map[string]error),
+	}
+}
+
+// GetSecret retorna el secreto en caché o lo obtiene de nuevo si no existe o ya expiró.
+// Si la consulta falla y hay un valor expirado en caché, se retorna ese valor y se registra una advertencia,
+// para que una falla temporal del proveedor no impida abrir conexiones nuevas.
+func (c *CachedProvider) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	c.mu.Lock()
+	entrada, ok := c.entradas[secretName]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entrada.expiracion) {
+		return entrada.valor, nil
+	}
+
+	valor, err := c.Refresh(ctx, secretName)
+	if err != nil && ok {
+		logs.Warn(ctx, "No se pudo refrescar el secreto, se usa el valor expirado en caché",
+			slog.String("secreto", secretName), logs.Err(err))
+		return entrada.valor, nil
+	}
+	return valor, err
+}
+
+// ErrorRefresco retorna el error de la última consulta al proveedor del secreto, o nil si fue exitosa.
+// Permite detectar que el proveedor no está disponible aunque GetSecret siga retornando el valor en caché.
+func (c *CachedProvider) ErrorRefresco(secretName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.erroresRefresco[secretName]
+}
+
+// Refresh obtiene de nuevo el secreto sin importar el TTL, por ejemplo después de una rotación.
+// Si la consulta falla se conserva el valor anterior en caché. Las llamadas simultáneas para el mismo
+// secreto esperan el resultado de una sola consulta al proveedor, hecha con el contexto de la primera.
+func (c *CachedProvider) Refresh(ctx context.Context, secretName string) (map[string]string, error) {
+	resultado := c.consultas.DoChan(secretName, func() (any, error) {
+		valor, err := c.source.GetSecret(ctx, secretName)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if err != nil {
+			c.erroresRefresco[secretName] = err
+			return nil, err
+		}
+		delete(c.erroresRefresco, secretName)
+		c.entradas[secretName] = entradaSecreto{valor: valor, expiracion: time.Now().Add(c.ttl)}
+		return valor, nil
+	})
+
+	select {
+	case r := <-resultado:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(map[string]string), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,189 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/stretchr/testify/assert"
+	awsinternal "gmf_transmission_response/internal/aws"
+	"gmf_transmission_response/internal/secrets"
+)
+
+// MockSecretsManagerClient es un mock del cliente de Secrets Manager
+type MockSecretsManagerClient struct {
+	GetSecretValueFunc func(
+		ctx context.Context,
+		params *secretsmanager.GetSecretValueInput,
+		optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+func (m *MockSecretsManagerClient) GetSecretValue(
+	ctx context.Context,
+	params *secretsmanager.GetSecretValueInput,
+	optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return m.GetSecretValueFunc(ctx, params, optFns...)
+}
+
+// nuevoClienteRotado retorna un cliente que entrega una contraseña distinta en cada consulta
+func nuevoClienteRotado(llamadas *int) *MockSecretsManagerClient {
+	return &MockSecretsManagerClient{
+		GetSecretValueFunc: func(
+			ctx context.Context,
+			params *secretsmanager.GetSecretValueInput,
+			optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+			*llamadas++
+			if *llamadas > 2 {
+				return nil, errors.New("throttling")
+			}
+			password := []string{"", "pass-1", "pass-2"}[*llamadas]
+			return &secretsmanager.GetSecretValueOutput{
+				SecretString: aws.String(`{"USERNAME":"user","PASSWORD":"` + password + `"}`),
+			}, nil
+		},
+	}
+}
+
+func TestCachedProvider_GetSecret_UsaCache(t *testing.T) {
+	var llamadas int
+	cache := secrets.NewCachedProvider(&awsinternal.SecretsManager{Client: nuevoClienteRotado(&llamadas)}, time.Minute)
+
+	primero, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	segundo, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, llamadas)
+	assert.Equal(t, "pass-1", primero["PASSWORD"])
+	assert.Equal(t, primero, segundo)
+}
+
+func TestCachedProvider_GetSecret_Expirado(t *testing.T) {
+	var llamadas int
+	cache := secrets.NewCachedProvider(&awsinternal.SecretsManager{Client: nuevoClienteRotado(&llamadas)}, time.Millisecond)
+
+	_, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	secret, err := cache.GetSecret(context.Background(), "db")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, llamadas)
+	assert.Equal(t, "pass-2", secret["PASSWORD"])
+}
+
+func TestCachedProvider_Refresh(t *testing.T) {
+	var llamadas int
+	cache := secrets.NewCachedProvider(&awsinternal.SecretsManager{Client: nuevoClienteRotado(&llamadas)}, time.Hour)
+
+	_, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	secret, err := cache.Refresh(context.Background(), "db")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass-2", secret["PASSWORD"])
+
+	// Si el refresco falla se conserva el último valor obtenido
+	_, err = cache.Refresh(context.Background(), "db")
+	assert.ErrorContains(t, err, "throttling")
+	secret, err = cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass-2", secret["PASSWORD"])
+	assert.Equal(t, 3, llamadas)
+}
+
+func TestCachedProvider_GetSecret_ExpiradoSinProveedor(t *testing.T) {
+	var llamadas int
+	cliente := nuevoClienteRotado(&llamadas)
+	llamadas = 1 // La segunda consulta retorna pass-2 y las siguientes fallan
+	cache := secrets.NewCachedProvider(&awsinternal.SecretsManager{Client: cliente}, time.Millisecond)
+
+	_, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	// Si el proveedor falla después del TTL se retorna el último valor obtenido
+	secret, err := cache.GetSecret(context.Background(), "db")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass-2", secret["PASSWORD"])
+	assert.Equal(t, 3, llamadas)
+	// La falla del proveedor se sigue reportando mientras se usa el valor expirado
+	assert.ErrorContains(t, cache.ErrorRefresco("db"), "throttling")
+}
+
+func TestCachedProvider_ErrorRefresco_SeLimpiaConUnaConsultaExitosa(t *testing.T) {
+	proveedor := &MockProvider{Err: errors.New("throttling")}
+	cache := secrets.NewCachedProvider(proveedor, time.Minute)
+
+	_, err := cache.GetSecret(context.Background(), "db")
+	assert.ErrorContains(t, err, "throttling")
+	assert.ErrorContains(t, cache.ErrorRefresco("db"), "throttling")
+
+	proveedor.Err = nil
+	proveedor.Secret = map[string]string{"PASSWORD": "pass"}
+	_, err = cache.GetSecret(context.Background(), "db")
+
+	assert.NoError(t, err)
+	assert.NoError(t, cache.ErrorRefresco("db"))
+}
+
+func TestCachedProvider_GetSecret_SinValorEnCache(t *testing.T) {
+	cache := secrets.NewCachedProvider(&MockProvider{Err: errors.New("throttling")}, time.Minute)
+
+	_, err := cache.GetSecret(context.Background(), "db")
+
+	assert.ErrorContains(t, err, "throttling")
+}
+
+// proveedorLento retorna el secreto cuando se cierra liberar y cuenta las consultas
+type proveedorLento struct {
+	liberar  chan struct{}
+	llamadas atomic.Int32
+}
+
+func (p *proveedorLento) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	p.llamadas.Add(1)
+	<-p.liberar
+	return map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, nil
+}
+
+func TestCachedProvider_GetSecret_ConsultasSimultaneas(t *testing.T) {
+	proveedor := &proveedorLento{liberar: make(chan struct{})}
+	cache := secrets.NewCachedProvider(proveedor, time.Minute)
+
+	var wg sync.WaitGroup
+	resultados := make([]map[string]string, 10)
+	for i := range resultados {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resultados[i], _ = cache.GetSecret(context.Background(), "db")
+		}()
+	}
+	// Esperar a que la primera consulta llegue al proveedor antes de liberarla
+	assert.Eventually(t, func() bool { return proveedor.llamadas.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(proveedor.liberar)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), proveedor.llamadas.Load())
+	for _, resultado := range resultados {
+		assert.Equal(t, "pass", resultado["PASSWORD"])
+	}
+}
+
+func TestCachedProvider_Refresh_ContextoCancelado(t *testing.T) {
+	proveedor := &proveedorLento{liberar: make(chan struct{})}
+	defer close(proveedor.liberar)
+	cache := secrets.NewCachedProvider(proveedor, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.Refresh(ctx, "db")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
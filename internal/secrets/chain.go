@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ChainProvider consulta varios proveedores en orden y retorna el primer secreto encontrado.
+type ChainProvider struct {
+	providers []SecretProvider
+}
+
+// NewChainProvider crea un proveedor que consulta los proveedores indicados en orden.
+func NewChainProvider(providers ...SecretProvider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// GetSecret retorna el secreto del primer proveedor que lo obtiene sin error. Solo se consulta el siguiente
+// proveedor si el anterior no tiene el secreto; cualquier otro error, como una falla temporal de Secrets
+// Manager, se retorna sin consultar los demás. Si ninguno tiene el secreto, retorna los errores de todos.
+func (p *ChainProvider) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	errs := make([]error, 0, len(p.providers))
+	for _, provider := range p.providers {
+		secret, err := provider.GetSecret(ctx, secretName)
+		if err == nil {
+			return secret, nil
+		}
+		if !errors.Is(err, ErrSecretoNoEncontrado) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w: %s, no hay proveedores configurados", ErrSecretoNoEncontrado, secretName)
+	}
+	return nil, errors.Join(errs...)
+}
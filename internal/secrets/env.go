@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// VariablesDB relaciona las claves del secreto de la base de datos con las variables de entorno que las contienen.
+var VariablesDB = map[string]string{
+	"USERNAME": "DB_USER",
+	"PASSWORD": "DB_PASSWORD",
+}
+
+// EnvProvider obtiene los secretos de variables de entorno.
+type EnvProvider struct {
+	variables map[string]string
+}
+
+// NewEnvProvider crea un proveedor que arma el secreto con las variables indicadas para cada clave.
+func NewEnvProvider(variables map[string]string) *EnvProvider {
+	return &EnvProvider{variables: variables}
+}
+
+// GetSecret retorna el secreto armado con las variables de entorno. El nombre del secreto no se usa,
+// todas las claves se leen de las variables configuradas. Retorna ErrSecretoNoEncontrado si falta alguna.
+func (p *EnvProvider) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	secret := make(map[string]string, len(p.variables))
+	var faltantes []string
+	for clave, variable := range p.variables {
+		valor, ok := os.LookupEnv(variable)
+		if !ok {
+			faltantes = append(faltantes, variable)
+			continue
+		}
+		secret[clave] = valor
+	}
+
+	if len(faltantes) > 0 {
+		sort.Strings(faltantes)
+		return nil, fmt.Errorf("%w: %s, faltan las variables %v", ErrSecretoNoEncontrado, secretName, faltantes)
+	}
+	return secret, nil
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+// FileProvider obtiene los secretos de archivos JSON o .env, por ejemplo secretos montados por Docker o Kubernetes.
+type FileProvider struct {
+	path string
+}
+
+// NewFileProvider crea un proveedor para la ruta indicada. Si la ruta es un directorio, cada secreto se lee
+// del archivo con su nombre, con extensión .json, .env o sin extensión; si es un archivo, se usa para todos.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// GetSecret lee y deserializa el archivo del secreto. Retorna ErrSecretoNoEncontrado si el archivo no existe.
+func (p *FileProvider) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	archivo, err := p.archivo(secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	contenido, err := os.ReadFile(archivo)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el archivo del secreto: %w", err)
+	}
+
+	// Los archivos JSON tienen el mismo formato que los secretos de Secrets Manager
+	var secret map[string]string
+	if bytes.HasPrefix(bytes.TrimSpace(contenido), []byte("{")) {
+		if err := json.Unmarshal(contenido, &secret); err != nil {
+			return nil, fmt.Errorf("error deserializando el secreto: %w", err)
+		}
+		return secret, nil
+	}
+
+	secret, err = godotenv.UnmarshalBytes(contenido)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializando el secreto: %w", err)
+	}
+	return secret, nil
+}
+
+// archivo retorna la ruta del archivo que contiene el secreto.
+func (p *FileProvider) archivo(secretName string) (string, error) {
+	if p.path == "" {
+		return "", fmt.Errorf("%w: %s, no se configuró la ruta de los secretos", ErrSecretoNoEncontrado, secretName)
+	}
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s, no existe %s", ErrSecretoNoEncontrado, secretName, p.path)
+		}
+		return "", fmt.Errorf("error leyendo la ruta de los secretos: %w", err)
+	}
+	if !info.IsDir() {
+		return p.path, nil
+	}
+
+	for _, nombre := range []string{secretName + ".json", secretName + ".env", secretName} {
+		archivo := filepath.Join(p.path, nombre)
+		if info, err := os.Stat(archivo); err == nil && !info.IsDir() {
+			return archivo, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s, no existe en %s", ErrSecretoNoEncontrado, secretName, p.path)
+}
@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gmf_transmission_response/internal/apperrors"
+	"gmf_transmission_response/internal/aws"
+)
+
+// Nombres de los proveedores de secretos que se pueden configurar.
+const (
+	ProviderEnv   = "env"
+	ProviderFile  = "file"
+	ProviderAWS   = "aws"
+	ProviderChain = "chain"
+)
+
+// ErrSecretoNoEncontrado indica que el proveedor no tiene el secreto solicitado. Es el mismo error que
+// retorna el proveedor aws, por lo que se puede comparar con errors.Is sin importar el proveedor.
+var ErrSecretoNoEncontrado = apperrors.ErrSecretoNoEncontrado
+
+// SecretProvider obtiene un secreto deserializado por su nombre. La consulta se cancela con ctx.
+type SecretProvider interface {
+	GetSecret(ctx context.Context, secretName string) (map[string]string, error)
+}
+
+// Config contiene la configuración del proveedor de secretos.
+type Config struct {
+	// Provider es env, file, aws o chain; si está vacío se usa aws.
+	Provider string
+	// Chain son los proveedores que se consultan en orden cuando Provider es chain.
+	Chain []string
+	// FilePath es el archivo o directorio de los secretos del proveedor file.
+	FilePath string
+	// AWS es la configuración del cliente de Secrets Manager del proveedor aws.
+	AWS aws.Config
+}
+
+// NewProvider crea el proveedor de secretos configurado.
+func NewProvider(cfg Config) (SecretProvider, error) {
+	nombre := cfg.Provider
+	if nombre == "" {
+		nombre = ProviderAWS
+	}
+	if nombre != ProviderChain {
+		return nuevoProvider(nombre, cfg)
+	}
+
+	var providers []SecretProvider
+	for _, nombre := range cfg.Chain {
+		nombre = strings.TrimSpace(nombre)
+		if nombre == "" {
+			continue
+		}
+		provider, err := nuevoProvider(nombre, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("la cadena de proveedores debe indicar al menos un proveedor de secretos")
+	}
+	return NewChainProvider(providers...), nil
+}
+
+// Proveedores retorna los nombres de los proveedores que se consultan con la configuración.
+func (c Config) Proveedores() []string {
+	if c.Provider == ProviderChain {
+		return c.Chain
+	}
+	if c.Provider == "" {
+		return []string{ProviderAWS}
+	}
+	return []string{c.Provider}
+}
+
+// nuevoProvider crea el proveedor con el nombre indicado.
+func nuevoProvider(nombre string, cfg Config) (SecretProvider, error) {
+	switch nombre {
+	case ProviderEnv:
+		return NewEnvProvider(VariablesDB), nil
+	case ProviderFile:
+		return NewFileProvider(cfg.FilePath), nil
+	case ProviderAWS:
+		return aws.NewSecretsManager(cfg.AWS)
+	default:
+		return nil, fmt.Errorf("proveedor de secretos desconocido: %q", nombre)
+	}
+}
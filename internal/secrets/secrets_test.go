@@ -0,0 +1,150 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	awsinternal "gmf_transmission_response/internal/aws"
+	"gmf_transmission_response/internal/secrets"
+)
+
+// MockProvider retorna un secreto o un error fijo y cuenta las consultas
+type MockProvider struct {
+	Secret   map[string]string
+	Err      error
+	Llamadas int
+}
+
+func (m *MockProvider) GetSecret(ctx context.Context, secretName string) (map[string]string, error) {
+	m.Llamadas++
+	return m.Secret, m.Err
+}
+
+func TestEnvProvider_GetSecret(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "secreta")
+
+	secret, err := secrets.NewEnvProvider(secrets.VariablesDB).GetSecret(context.Background(), "gmf-secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USERNAME": "postgres", "PASSWORD": "secreta"}, secret)
+}
+
+func TestEnvProvider_VariableFaltante(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	os.Unsetenv("DB_PASSWORD")
+
+	_, err := secrets.NewEnvProvider(secrets.VariablesDB).GetSecret(context.Background(), "gmf-secret")
+
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+	assert.ErrorContains(t, err, "DB_PASSWORD")
+}
+
+func TestFileProvider_Directorio(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "gmf-secret.json"),
+		[]byte(`{"USERNAME":"json-user","PASSWORD":"json-pass"}`), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "otro-secreto"),
+		[]byte("# Secreto montado\nUSERNAME=env-user\nPASSWORD=\"env pass\"\n"), 0o600))
+	provider := secrets.NewFileProvider(dir)
+
+	secret, err := provider.GetSecret(context.Background(), "gmf-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USERNAME": "json-user", "PASSWORD": "json-pass"}, secret)
+
+	secret, err = provider.GetSecret(context.Background(), "otro-secreto")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USERNAME": "env-user", "PASSWORD": "env pass"}, secret)
+
+	_, err = provider.GetSecret(context.Background(), "inexistente")
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+}
+
+func TestFileProvider_Archivo(t *testing.T) {
+	archivo := filepath.Join(t.TempDir(), "db.env")
+	assert.NoError(t, os.WriteFile(archivo, []byte("USERNAME=user\nPASSWORD=pass\n"), 0o600))
+
+	secret, err := secrets.NewFileProvider(archivo).GetSecret(context.Background(), "gmf-secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USERNAME": "user", "PASSWORD": "pass"}, secret)
+}
+
+func TestFileProvider_Errores(t *testing.T) {
+	_, err := secrets.NewFileProvider("").GetSecret(context.Background(), "gmf-secret")
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+
+	_, err = secrets.NewFileProvider(filepath.Join(t.TempDir(), "no-existe")).GetSecret(context.Background(), "gmf-secret")
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+
+	archivo := filepath.Join(t.TempDir(), "db.json")
+	assert.NoError(t, os.WriteFile(archivo, []byte(`{"USERNAME":`), 0o600))
+	_, err = secrets.NewFileProvider(archivo).GetSecret(context.Background(), "gmf-secret")
+	assert.ErrorContains(t, err, "error deserializando el secreto")
+}
+
+func TestChainProvider_PrimerSecretoEncontrado(t *testing.T) {
+	primero := &MockProvider{Err: secrets.ErrSecretoNoEncontrado}
+	segundo := &MockProvider{Secret: map[string]string{"USERNAME": "user"}}
+	tercero := &MockProvider{Secret: map[string]string{"USERNAME": "otro"}}
+
+	secret, err := secrets.NewChainProvider(primero, segundo, tercero).GetSecret(context.Background(), "gmf-secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user", secret["USERNAME"])
+	assert.Equal(t, []int{1, 1, 0}, []int{primero.Llamadas, segundo.Llamadas, tercero.Llamadas})
+}
+
+func TestChainProvider_NingunProveedor(t *testing.T) {
+	chain := secrets.NewChainProvider(&MockProvider{Err: secrets.ErrSecretoNoEncontrado},
+		&MockProvider{Err: fmt.Errorf("%w: gmf-secret", secrets.ErrSecretoNoEncontrado)})
+
+	_, err := chain.GetSecret(context.Background(), "gmf-secret")
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+
+	_, err = secrets.NewChainProvider().GetSecret(context.Background(), "gmf-secret")
+	assert.ErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+}
+
+func TestChainProvider_ErrorDelProveedorNoPasaAlSiguiente(t *testing.T) {
+	errAWS := errors.New("error obteniendo el secreto: timeout")
+	primero := &MockProvider{Err: secrets.ErrSecretoNoEncontrado}
+	segundo := &MockProvider{Err: errAWS}
+	tercero := &MockProvider{Secret: map[string]string{"USERNAME": "otro"}}
+
+	_, err := secrets.NewChainProvider(primero, segundo, tercero).GetSecret(context.Background(), "gmf-secret")
+
+	// Una falla temporal no se oculta con el secreto de otro proveedor ni se reporta como secreto inexistente
+	assert.Same(t, errAWS, err)
+	assert.NotErrorIs(t, err, secrets.ErrSecretoNoEncontrado)
+	assert.Equal(t, []int{1, 1, 0}, []int{primero.Llamadas, segundo.Llamadas, tercero.Llamadas})
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := secrets.NewProvider(secrets.Config{Provider: secrets.ProviderEnv})
+	assert.NoError(t, err)
+	assert.IsType(t, &secrets.EnvProvider{}, provider)
+
+	provider, err = secrets.NewProvider(secrets.Config{Provider: secrets.ProviderFile, FilePath: t.TempDir()})
+	assert.NoError(t, err)
+	assert.IsType(t, &secrets.FileProvider{}, provider)
+
+	provider, err = secrets.NewProvider(secrets.Config{AWS: awsinternal.Config{Local: true}})
+	assert.NoError(t, err)
+	assert.IsType(t, &awsinternal.SecretsManager{}, provider)
+
+	provider, err = secrets.NewProvider(secrets.Config{Provider: secrets.ProviderChain, Chain: []string{"env", " file"}})
+	assert.NoError(t, err)
+	assert.IsType(t, &secrets.ChainProvider{}, provider)
+
+	_, err = secrets.NewProvider(secrets.Config{Provider: secrets.ProviderChain})
+	assert.ErrorContains(t, err, "al menos un proveedor")
+
+	_, err = secrets.NewProvider(secrets.Config{Provider: secrets.ProviderChain, Chain: []string{"env", "vault"}})
+	assert.ErrorContains(t, err, `"vault"`)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gorm.io/gorm"
+)
+
+// Valores de paginación del histórico de estados.
+const (
+	TamanoPaginaPorDefecto = 50
+	MaxTamanoPagina        = 500
+)
+
+// ConsultarArchivo retorna el estado actual de transmisión de un archivo.
+// Retorna un error con código NOT_FOUND si no existe un archivo con ese nombre.
+func (s *ArchivoService) ConsultarArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error) {
+	consulta, err := s.repo.GetConsultaArchivo(acgNombreArchivo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoProcesamientoError(CodigoNoEncontrado, err)
+		}
+		logs.Logger.LogError("Error al consultar el archivo", err, acgNombreArchivo)
+		return nil, nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+	}
+	return consulta, nil
+}
+
+// ConsultarHistorial retorna las transiciones de estado de un archivo ordenadas por fecha.
+// Si el filtro no indica la página o su tamaño se usa la primera página de TamanoPaginaPorDefecto registros.
+// Retorna un error con código NOT_FOUND si no existe un archivo con ese nombre.
+func (s *ArchivoService) ConsultarHistorial(
+	acgNombreArchivo string, filtro models.FiltroHistorial) (*models.HistorialResponse, error) {
+	if filtro.Pagina <= 0 {
+		filtro.Pagina = 1
+	}
+	if filtro.TamanoPagina <= 0 {
+		filtro.TamanoPagina = TamanoPaginaPorDefecto
+	}
+	if filtro.TamanoPagina > MaxTamanoPagina {
+		filtro.TamanoPagina = MaxTamanoPagina
+	}
+
+	consulta, err := s.ConsultarArchivo(acgNombreArchivo)
+	if err != nil {
+		return nil, err
+	}
+
+	estados, total, err := s.repo.GetHistorialEstados(consulta.IDArchivo, filtro)
+	if err != nil {
+		logs.Logger.LogError("Error al consultar el histórico de estados", err, acgNombreArchivo)
+		return nil, nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+	}
+
+	return &models.HistorialResponse{
+		IDArchivo:        consulta.IDArchivo,
+		ACGNombreArchivo: consulta.ACGNombreArchivo,
+		Total:            total,
+		Page:             filtro.Pagina,
+		PageSize:         filtro.TamanoPagina,
+		Transitions:      estados,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/service"
+	"gorm.io/gorm"
+)
+
+func TestConsultarArchivo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	consulta := &models.ArchivoConsulta{
+		IDArchivo:         10001202403120001,
+		ACGNombreArchivo:  "TUTGMF0001000120240312-0001",
+		Estado:            "ENVIADO",
+		GAWRtaTransEstado: "SUCCESSFUL",
+		GAWRtaTransCodigo: "0000",
+	}
+	mockRepo.On("GetConsultaArchivo", consulta.ACGNombreArchivo).Return(consulta, nil)
+
+	result, err := archivoService.ConsultarArchivo(consulta.ACGNombreArchivo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, consulta, result)
+}
+
+func TestConsultarArchivo_Errores(t *testing.T) {
+	casos := []struct {
+		nombre string
+		err    error
+		codigo string
+	}{
+		{"no encontrado", gorm.ErrRecordNotFound, service.CodigoNoEncontrado},
+		{"error de base de datos", errors.New("conexión perdida"), service.CodigoErrorBaseDatos},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			archivoService := service.NewArchivoService(mockRepo)
+			mockRepo.On("GetConsultaArchivo", "TUTGMF0001000120240312-0001").Return(nil, c.err)
+
+			result, err := archivoService.ConsultarArchivo("TUTGMF0001000120240312-0001")
+
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, c.err)
+			assert.Equal(t, c.codigo, service.CodigoError(err))
+		})
+	}
+}
+
+func TestConsultarHistorial(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	consulta := &models.ArchivoConsulta{IDArchivo: 10001202403120001, ACGNombreArchivo: "TUTGMF0001000120240312-0001"}
+	desde := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	estados := []models.CGDArchivoEstados{
+		{IDArchivo: consulta.IDArchivo, EstadoInicial: "EMPAQUETADO", EstadoFinal: "ENVIO_FALLIDO"},
+		{IDArchivo: consulta.IDArchivo, EstadoInicial: "ENVIO_FALLIDO", EstadoFinal: "ENVIADO"},
+	}
+
+	// Sin página ni tamaño se consulta la primera página con el tamaño por defecto
+	mockRepo.On("GetConsultaArchivo", consulta.ACGNombreArchivo).Return(consulta, nil)
+	mockRepo.On("GetHistorialEstados", consulta.IDArchivo, models.FiltroHistorial{
+		Desde:        desde,
+		Pagina:       1,
+		TamanoPagina: service.TamanoPaginaPorDefecto,
+	}).Return(estados, int64(2), nil)
+
+	result, err := archivoService.ConsultarHistorial(consulta.ACGNombreArchivo, models.FiltroHistorial{Desde: desde})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.HistorialResponse{
+		IDArchivo:        consulta.IDArchivo,
+		ACGNombreArchivo: consulta.ACGNombreArchivo,
+		Total:            2,
+		Page:             1,
+		PageSize:         service.TamanoPaginaPorDefecto,
+		Transitions:      estados,
+	}, result)
+}
+
+func TestConsultarHistorial_TamanoMaximo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	consulta := &models.ArchivoConsulta{IDArchivo: 1, ACGNombreArchivo: "TUTGMF0001000120240312-0001"}
+	mockRepo.On("GetConsultaArchivo", consulta.ACGNombreArchivo).Return(consulta, nil)
+	mockRepo.On("GetHistorialEstados", consulta.IDArchivo, models.FiltroHistorial{
+		Pagina:       3,
+		TamanoPagina: service.MaxTamanoPagina,
+	}).Return([]models.CGDArchivoEstados{}, int64(0), nil)
+
+	result, err := archivoService.ConsultarHistorial(consulta.ACGNombreArchivo,
+		models.FiltroHistorial{Pagina: 3, TamanoPagina: service.MaxTamanoPagina + 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, service.MaxTamanoPagina, result.PageSize)
+	assert.Empty(t, result.Transitions)
+}
+
+func TestConsultarHistorial_Errores(t *testing.T) {
+	t.Run("archivo no encontrado", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		archivoService := service.NewArchivoService(mockRepo)
+		mockRepo.On("GetConsultaArchivo", "TUTGMF0001000120240312-9999").Return(nil, gorm.ErrRecordNotFound)
+
+		result, err := archivoService.ConsultarHistorial("TUTGMF0001000120240312-9999", models.FiltroHistorial{})
+
+		assert.Nil(t, result)
+		assert.Equal(t, service.CodigoNoEncontrado, service.CodigoError(err))
+		mockRepo.AssertNotCalled(t, "GetHistorialEstados", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error al consultar el histórico", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		archivoService := service.NewArchivoService(mockRepo)
+		consulta := &models.ArchivoConsulta{IDArchivo: 1, ACGNombreArchivo: "TUTGMF0001000120240312-0001"}
+		mockRepo.On("GetConsultaArchivo", consulta.ACGNombreArchivo).Return(consulta, nil)
+		mockRepo.On("GetHistorialEstados", consulta.IDArchivo, mock.Anything).
+			Return(nil, int64(0), errors.New("conexión perdida"))
+
+		result, err := archivoService.ConsultarHistorial(consulta.ACGNombreArchivo, models.FiltroHistorial{})
+
+		assert.Nil(t, result)
+		assert.Equal(t, service.CodigoErrorBaseDatos, service.CodigoError(err))
+	})
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+
+	"gmf_transmission_response/internal/statemachine"
+)
+
+// Códigos de error legibles por máquina que se reportan por cada archivo procesado.
+const (
+	CodigoNoEncontrado       = "NOT_FOUND"
+	CodigoTransicionInvalida = "ILLEGAL_TRANSITION"
+	CodigoErrorBaseDatos     = "DB_ERROR"
+	CodigoErrorValidacion    = "VALIDATION_ERROR"
+)
+
+// ProcesamientoError asocia un código de error a la causa que impidió procesar un archivo.
+type ProcesamientoError struct {
+	Codigo string
+	Err    error
+}
+
+func (e *ProcesamientoError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProcesamientoError) Unwrap() error {
+	return e.Err
+}
+
+// nuevoProcesamientoError envuelve err con el código indicado.
+func nuevoProcesamientoError(codigo string, err error) error {
+	return &ProcesamientoError{Codigo: codigo, Err: err}
+}
+
+// CodigoError obtiene el código de error asociado a err.
+// Los errores sin categoría conocida se reportan como errores de base de datos.
+func CodigoError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var procesamientoErr *ProcesamientoError
+	if errors.As(err, &procesamientoErr) {
+		return procesamientoErr.Codigo
+	}
+
+	if errors.Is(err, statemachine.ErrTransicionInvalida) {
+		return CodigoTransicionInvalida
+	}
+
+	return CodigoErrorBaseDatos
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gmf_transmission_response/internal/filename"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gorm.io/gorm"
+)
+
+// UmbralLotePorDefecto es la cantidad de archivos a partir de la cual una solicitud se procesa como un lote.
+const UmbralLotePorDefecto = 50
+
+// errArchivoDuplicado indica que el mismo archivo aparece más de una vez en el lote.
+var errArchivoDuplicado = errors.New("el archivo aparece más de una vez en el lote")
+
+// procesarLote procesa los archivos transmitidos con una consulta, una actualización y una inserción
+// para todo el lote, en lugar de tres operaciones por archivo.
+// La actualización de los archivos y el registro del histórico se confirman o revierten juntos para
+// todo el lote: si fallan, todos los archivos que iban a cambiar de estado se reportan con DB_ERROR.
+// Si otra solicitud cambió el estado de alguno de los archivos después de leerlos, el lote se revierte
+// y esos archivos se procesan uno a uno para validar cada transición contra su estado actual.
+func (s *ArchivoService) procesarLote(
+	ctx context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult {
+	procesados := make([]archivoProcesado, len(transmittedFiles))
+	errs := make([]error, len(transmittedFiles))
+	nombres := make([]*filename.NombreArchivo, len(transmittedFiles))
+
+	// Un lote que no se inició antes de cancelar ctx queda sin procesar y puede reintentarse
+	if err := ctx.Err(); err != nil {
+		for i := range errs {
+			errs[i] = nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+		}
+		return s.resultadosLote(transmittedFiles, procesados, errs)
+	}
+
+	// Rechazar los nombres mal formados antes de consultar la base de datos
+	nombresArchivo := make([]string, 0, len(transmittedFiles))
+	for i, transmittedFile := range transmittedFiles {
+		ctxArchivo := logs.With(ctx, logs.FileName(transmittedFile.FileName))
+		nombres[i], errs[i] = s.parsearNombre(ctxArchivo, transmittedFile.FileName)
+		if errs[i] == nil {
+			nombresArchivo = append(nombresArchivo, transmittedFile.FileName)
+		}
+	}
+
+	archivos, err := s.repo.GetArchivosByNombresArchivo(nombresArchivo)
+	if err != nil {
+		logs.Error(ctx, "Error al obtener los archivos del lote de la base de datos", err)
+		for i := range transmittedFiles {
+			if errs[i] == nil {
+				errs[i] = nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+			}
+		}
+		return s.resultadosLote(transmittedFiles, procesados, errs)
+	}
+
+	archivosPorNombre := make(map[string]*models.CGDArchivos, len(archivos))
+	for i := range archivos {
+		archivosPorNombre[archivos[i].ACGNombreArchivo] = &archivos[i]
+	}
+
+	var pendientes []int
+	var actualizaciones []repository.ActualizacionArchivo
+	var historial []*models.CGDArchivoEstados
+	vistos := make(map[string]bool, len(nombresArchivo))
+	for i, transmittedFile := range transmittedFiles {
+		if errs[i] != nil {
+			continue
+		}
+		fileName := transmittedFile.FileName
+		ctxArchivo := logs.With(ctx, logs.FileName(fileName))
+
+		if vistos[fileName] {
+			logs.Warn(ctxArchivo, "Archivo duplicado en el lote")
+			errs[i] = nuevoProcesamientoError(CodigoErrorValidacion, errArchivoDuplicado)
+			continue
+		}
+		vistos[fileName] = true
+
+		archivo, ok := archivosPorNombre[fileName]
+		if !ok {
+			logs.Error(ctxArchivo, "Error al obtener archivo de la base de datos", gorm.ErrRecordNotFound)
+			errs[i] = nuevoProcesamientoError(CodigoNoEncontrado, gorm.ErrRecordNotFound)
+			continue
+		}
+		estadoAnterior := archivo.Estado
+
+		nuevoEstado, procesado, err := s.evaluarTransicion(ctxArchivo, transmittedFile, nombres[i], archivo)
+		procesados[i], errs[i] = procesado, err
+		if err != nil || procesado.repetido {
+			continue
+		}
+
+		aplicarResultado(archivo, transmittedFile, nuevoEstado)
+		pendientes = append(pendientes, i)
+		actualizaciones = append(actualizaciones,
+			repository.ActualizacionArchivo{Archivo: archivo, EstadoAnterior: estadoAnterior})
+		historial = append(historial, nuevoHistorial(archivo.IDArchivo, estadoAnterior, nuevoEstado, transmittedFile))
+	}
+
+	if len(pendientes) == 0 {
+		return s.resultadosLote(transmittedFiles, procesados, errs)
+	}
+
+	err = s.repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		if err := tx.UpdateArchivos(actualizaciones); err != nil {
+			if !errors.Is(err, repository.ErrEstadoModificado) {
+				logs.Error(ctx, "Error al actualizar los archivos del lote en la base de datos", err)
+			}
+			return err
+		}
+		if err := tx.InsertEstadosArchivo(historial); err != nil {
+			logs.Error(ctx, "Error al insertar los estados de los archivos del lote", err)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrEstadoModificado) {
+		logs.Warn(ctx, "Archivos del lote modificados por otra solicitud, se procesan uno a uno")
+		ejecutarEnPool(len(pendientes), s.concurrencia, func(k int) {
+			i := pendientes[k]
+			procesados[i], errs[i] = s.procesarArchivo(ctx, transmittedFiles[i])
+		})
+	} else if err != nil {
+		for k, i := range pendientes {
+			procesados[i] = archivoProcesado{estado: historial[k].EstadoInicial}
+			errs[i] = nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+		}
+	} else {
+		logs.Info(ctx, fmt.Sprintf(
+			"Estados de %d archivos insertados correctamente en la tabla CGD_ARCHIVO_ESTADO", len(pendientes)))
+	}
+
+	return s.resultadosLote(transmittedFiles, procesados, errs)
+}
+
+// resultadosLote construye el resultado de cada archivo del lote en el orden de la solicitud.
+func (s *ArchivoService) resultadosLote(transmittedFiles []models.TransmittedFile,
+	procesados []archivoProcesado, errs []error) []models.FileResult {
+	resultados := make([]models.FileResult, len(transmittedFiles))
+	for i, transmittedFile := range transmittedFiles {
+		resultados[i] = s.nuevoResultado(transmittedFile, procesados[i], errs[i])
+	}
+	return resultados
+}
@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/service"
+)
+
+// archivoEmpaquetado construye un archivo en estado EMPAQUETADO que coincide con TUTGMF0001000120240312-<consecutivo>.
+func archivoEmpaquetado(consecutivo int64, acgNombreArchivo string) models.CGDArchivos {
+	return models.CGDArchivos{
+		IDArchivo:          10001202403120000 + consecutivo,
+		ACGNombreArchivo:   acgNombreArchivo,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     consecutivo,
+		Estado:             "EMPAQUETADO",
+	}
+}
+
+func transmitido(fileName, status, code string) models.TransmittedFile {
+	return models.TransmittedFile{
+		FileName:           fileName,
+		TransmissionResult: models.TransmissionResult{Status: status, Code: code},
+	}
+}
+
+func TestProcesarTransmisiones_Lote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(2))
+
+	transmittedFiles := []models.TransmittedFile{
+		transmitido("TUTGMF0001000120240312-0001", "SUCCESSFUL", "0000"),
+		transmitido("nombre-invalido", "SUCCESSFUL", "0000"),
+		transmitido("TUTGMF0001000120240312-0002", "ERROR", "0001"),
+		transmitido("TUTGMF0001000120240312-0003", "SUCCESSFUL", "0000"),
+		transmitido("TUTGMF0001000120240312-0004-A", "SUCCESSFUL", "0000"),
+	}
+
+	// El archivo 0003 no existe y la anulación 0004 aún no fue enviada
+	archivos := []models.CGDArchivos{
+		archivoEmpaquetado(1, "TUTGMF0001000120240312-0001"),
+		archivoEmpaquetado(2, "TUTGMF0001000120240312-0002"),
+		archivoEmpaquetado(4, "TUTGMF0001000120240312-0004-A"),
+	}
+
+	mockRepo.On("GetArchivosByNombresArchivo", []string{
+		"TUTGMF0001000120240312-0001",
+		"TUTGMF0001000120240312-0002",
+		"TUTGMF0001000120240312-0003",
+		"TUTGMF0001000120240312-0004-A",
+	}).Return(archivos, nil).Once()
+	mockRepo.On("UpdateArchivos", mock.MatchedBy(func(actualizaciones []repository.ActualizacionArchivo) bool {
+		return len(actualizaciones) == 2 &&
+			actualizaciones[0].Archivo.IDArchivo == 10001202403120001 && actualizaciones[0].Archivo.Estado == "ENVIADO" &&
+			actualizaciones[0].Archivo.GAWRtaTransEstado == "SUCCESSFUL" && actualizaciones[0].EstadoAnterior == "EMPAQUETADO" &&
+			actualizaciones[1].Archivo.IDArchivo == 10001202403120002 && actualizaciones[1].Archivo.Estado == "ENVIO_FALLIDO" &&
+			actualizaciones[1].Archivo.GAWRtaTransCodigo == "0001" && actualizaciones[1].EstadoAnterior == "EMPAQUETADO"
+	})).Return(nil).Once()
+	mockRepo.On("InsertEstadosArchivo", mock.MatchedBy(func(estados []*models.CGDArchivoEstados) bool {
+		return len(estados) == 2 &&
+			estados[0].EstadoInicial == "EMPAQUETADO" && estados[0].EstadoFinal == "ENVIADO" &&
+			estados[0].GAWRtaTransEstado == "SUCCESSFUL" && estados[0].GAWRtaTransCodigo == "0000" &&
+			estados[1].EstadoInicial == "EMPAQUETADO" && estados[1].EstadoFinal == "ENVIO_FALLIDO" &&
+			estados[1].GAWRtaTransEstado == "ERROR" && estados[1].GAWRtaTransCodigo == "0001"
+	})).Return(nil).Once()
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), transmittedFiles)
+
+	assert.Len(t, resultados, len(transmittedFiles))
+	for i, resultado := range resultados {
+		assert.Equal(t, transmittedFiles[i].FileName, resultado.FileName)
+	}
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	assert.Equal(t, "ENVIADO", resultados[0].Estado)
+	assert.Equal(t, service.CodigoErrorValidacion, resultados[1].ErrorCode)
+	assert.Equal(t, models.OutcomeProcessed, resultados[2].Outcome)
+	assert.Equal(t, "ENVIO_FALLIDO", resultados[2].Estado)
+	assert.Equal(t, service.CodigoNoEncontrado, resultados[3].ErrorCode)
+	assert.Equal(t, service.CodigoTransicionInvalida, resultados[4].ErrorCode)
+	assert.Equal(t, "EMPAQUETADO", resultados[4].Estado)
+
+	// Las operaciones por archivo no se usan en el procesamiento por lotes
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetArchivoByNombreArchivo", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "InsertEstadoArchivo", mock.Anything)
+}
+
+func TestProcesarTransmisiones_LoteBajoElUmbral(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(2))
+
+	archivo := archivoEmpaquetado(1, "TUTGMF0001000120240312-0001")
+	mockRepo.On("GetArchivoByNombreArchivo", archivo.ACGNombreArchivo).Return(&archivo, nil)
+	mockRepo.On("UpdateArchivo", &archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{
+		transmitido(archivo.ACGNombreArchivo, "SUCCESSFUL", "0000"),
+	})
+
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	mockRepo.AssertNotCalled(t, "GetArchivosByNombresArchivo", mock.Anything)
+}
+
+func TestProcesarTransmisiones_LoteRespuestaRepetidaYDuplicado(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(1))
+
+	archivo := archivoEmpaquetado(1, "TUTGMF0001000120240312-0001")
+	archivo.Estado = "ENVIADO"
+	archivo.GAWRtaTransEstado = "SUCCESSFUL"
+	archivo.GAWRtaTransCodigo = "0000"
+
+	mockRepo.On("GetArchivosByNombresArchivo", mock.Anything).Return([]models.CGDArchivos{archivo}, nil)
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{
+		transmitido(archivo.ACGNombreArchivo, "SUCCESSFUL", "0000"),
+		transmitido(archivo.ACGNombreArchivo, "SUCCESSFUL", "0000"),
+	})
+
+	// Sin archivos que cambien de estado no se abre la transacción
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	assert.Equal(t, "ENVIADO", resultados[0].Estado)
+	assert.Equal(t, service.CodigoErrorValidacion, resultados[1].ErrorCode)
+	mockRepo.AssertNotCalled(t, "UpdateArchivos", mock.Anything)
+	mockRepo.AssertNotCalled(t, "InsertEstadosArchivo", mock.Anything)
+}
+
+func TestProcesarTransmisiones_LoteErrorAlConsultar(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(1))
+
+	mockRepo.On("GetArchivosByNombresArchivo", mock.Anything).Return(nil, errors.New("conexión perdida"))
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{
+		transmitido("TUTGMF0001000120240312-0001", "SUCCESSFUL", "0000"),
+		transmitido("nombre-invalido", "SUCCESSFUL", "0000"),
+	})
+
+	assert.Equal(t, service.CodigoErrorBaseDatos, resultados[0].ErrorCode)
+	assert.Equal(t, service.CodigoErrorValidacion, resultados[1].ErrorCode)
+}
+
+func TestProcesarTransmisiones_ContextoCancelado(t *testing.T) {
+	mockRepo := new(MockRepository)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	transmittedFiles := []models.TransmittedFile{
+		transmitido("TUTGMF0001000120240312-0001", "SUCCESSFUL", "0000"),
+		transmitido("TUTGMF0001000120240312-0002", "SUCCESSFUL", "0000"),
+	}
+
+	// Con el contexto cancelado los archivos no se procesan, uno a uno ni como lote
+	for _, umbral := range []int{0, 1} {
+		archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(umbral))
+		resultados := archivoService.ProcesarTransmisiones(ctx, transmittedFiles)
+
+		for _, resultado := range resultados {
+			assert.Equal(t, models.OutcomeFailed, resultado.Outcome)
+			assert.Equal(t, service.CodigoErrorBaseDatos, resultado.ErrorCode)
+		}
+	}
+	mockRepo.AssertNotCalled(t, "GetArchivoByNombreArchivo", mock.Anything)
+	mockRepo.AssertNotCalled(t, "GetArchivosByNombresArchivo", mock.Anything)
+}
+
+func TestProcesarTransmisiones_LoteErrorAlActualizar(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(1))
+
+	archivos := []models.CGDArchivos{
+		archivoEmpaquetado(1, "TUTGMF0001000120240312-0001"),
+		archivoEmpaquetado(2, "TUTGMF0001000120240312-0002"),
+	}
+	mockRepo.On("GetArchivosByNombresArchivo", mock.Anything).Return(archivos, nil)
+	mockRepo.On("UpdateArchivos", mock.Anything).Return(errors.New("conexión perdida"))
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{
+		transmitido("TUTGMF0001000120240312-0001", "SUCCESSFUL", "0000"),
+		transmitido("TUTGMF0001000120240312-0002", "SUCCESSFUL", "0000"),
+	})
+
+	// Todo el lote se revierte y los archivos conservan su estado anterior
+	for _, resultado := range resultados {
+		assert.Equal(t, models.OutcomeFailed, resultado.Outcome)
+		assert.Equal(t, service.CodigoErrorBaseDatos, resultado.ErrorCode)
+		assert.Equal(t, "EMPAQUETADO", resultado.Estado)
+	}
+	mockRepo.AssertNotCalled(t, "InsertEstadosArchivo", mock.Anything)
+}
+
+func TestProcesarTransmisiones_LoteEstadoModificadoPorOtraSolicitud(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo, service.WithUmbralLote(1))
+
+	archivos := []models.CGDArchivos{
+		archivoEmpaquetado(1, "TUTGMF0001000120240312-0001"),
+		archivoEmpaquetado(2, "TUTGMF0001000120240312-0002"),
+	}
+	mockRepo.On("GetArchivosByNombresArchivo", mock.Anything).Return(archivos, nil)
+	mockRepo.On("UpdateArchivos", mock.Anything).Return(repository.ErrEstadoModificado)
+
+	// Al procesarlos uno a uno, el archivo 0002 ya fue marcado como ENVIADO por otra solicitud
+	actual1 := archivoEmpaquetado(1, "TUTGMF0001000120240312-0001")
+	actual2 := archivoEmpaquetado(2, "TUTGMF0001000120240312-0002")
+	mockRepo.On("GetArchivoByNombreArchivo", actual1.ACGNombreArchivo).Return(&actual1, nil)
+	mockRepo.On("GetArchivoByNombreArchivo", actual2.ACGNombreArchivo).Return(&actual2, nil)
+	mockRepo.On("UpdateArchivo", &actual1, "EMPAQUETADO").Return(nil)
+	mockRepo.On("UpdateArchivo", &actual2, "EMPAQUETADO").Return(repository.ErrEstadoModificado)
+	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil).Once()
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{
+		transmitido("TUTGMF0001000120240312-0001", "SUCCESSFUL", "0000"),
+		transmitido("TUTGMF0001000120240312-0002", "ERROR", "0001"),
+	})
+
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	assert.Equal(t, "ENVIADO", resultados[0].Estado)
+	assert.Equal(t, service.CodigoTransicionInvalida, resultados[1].ErrorCode)
+	mockRepo.AssertNotCalled(t, "InsertEstadosArchivo", mock.Anything)
+	mockRepo.AssertNumberOfCalls(t, "InsertEstadoArchivo", 1)
+}
@@ -0,0 +1,36 @@
+package service
+
+import "sync"
+
+// ejecutarEnPool ejecuta tarea para cada índice de 0 a total-1 usando como máximo workers goroutines.
+// Con un solo worker las tareas se ejecutan en orden en la goroutine actual.
+// Retorna cuando todas las tareas terminaron.
+func ejecutarEnPool(total, workers int, tarea func(i int)) {
+	if workers > total {
+		workers = total
+	}
+	if workers <= 1 {
+		for i := 0; i < total; i++ {
+			tarea(i)
+		}
+		return
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				tarea(i)
+			}
+		}()
+	}
+
+	for i := 0; i < total; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/filename"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/service"
+	"gorm.io/gorm"
+)
+
+// RepositorioConLatencia simula un repositorio con una latencia fija por operación.
+// Construye un archivo EMPAQUETADO a partir de cada nombre consultado y lleva la cuenta
+// de cuántas operaciones se ejecutan al mismo tiempo.
+type RepositorioConLatencia struct {
+	Latencia    time.Duration
+	NoExisten   map[string]bool
+	enCurso     atomic.Int32
+	maxEnCurso  atomic.Int32
+	mu          sync.Mutex
+	actualizado []string
+}
+
+func (r *RepositorioConLatencia) esperar() {
+	actual := r.enCurso.Add(1)
+	defer r.enCurso.Add(-1)
+	for {
+		maximo := r.maxEnCurso.Load()
+		if actual <= maximo || r.maxEnCurso.CompareAndSwap(maximo, actual) {
+			break
+		}
+	}
+	time.Sleep(r.Latencia)
+}
+
+func (r *RepositorioConLatencia) GetArchivoByNombreArchivo(nombreArchivo string) (*models.CGDArchivos, error) {
+	r.esperar()
+	if r.NoExisten[nombreArchivo] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	nombre, err := filename.Parse(nombreArchivo)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CGDArchivos{
+		IDArchivo:          nombre.Consecutivo,
+		ACGNombreArchivo:   nombreArchivo,
+		PlataformaOrigen:   nombre.PlataformaOrigen,
+		FechaNombreArchivo: nombre.FechaNombreArchivo(),
+		ACGConsecutivo:     nombre.Consecutivo,
+		Estado:             "EMPAQUETADO",
+	}, nil
+}
+
+func (r *RepositorioConLatencia) GetConsultaArchivo(string) (*models.ArchivoConsulta, error) {
+	return nil, errors.New("no implementado")
+}
+
+func (r *RepositorioConLatencia) GetHistorialEstados(
+	int64, models.FiltroHistorial) ([]models.CGDArchivoEstados, int64, error) {
+	return nil, 0, errors.New("no implementado")
+}
+
+func (r *RepositorioConLatencia) UpdateArchivo(archivo *models.CGDArchivos, _ string) error {
+	r.esperar()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actualizado = append(r.actualizado, archivo.ACGNombreArchivo)
+	return nil
+}
+
+func (r *RepositorioConLatencia) InsertEstadoArchivo(*models.CGDArchivoEstados) error {
+	r.esperar()
+	return nil
+}
+
+func (r *RepositorioConLatencia) GetArchivosByNombresArchivo(nombresArchivo []string) ([]models.CGDArchivos, error) {
+	r.esperar()
+	archivos := make([]models.CGDArchivos, 0, len(nombresArchivo))
+	for _, nombreArchivo := range nombresArchivo {
+		if r.NoExisten[nombreArchivo] {
+			continue
+		}
+		nombre, err := filename.Parse(nombreArchivo)
+		if err != nil {
+			return nil, err
+		}
+		archivos = append(archivos, models.CGDArchivos{
+			IDArchivo:          nombre.Consecutivo,
+			ACGNombreArchivo:   nombreArchivo,
+			PlataformaOrigen:   nombre.PlataformaOrigen,
+			FechaNombreArchivo: nombre.FechaNombreArchivo(),
+			ACGConsecutivo:     nombre.Consecutivo,
+			Estado:             "EMPAQUETADO",
+		})
+	}
+	return archivos, nil
+}
+
+func (r *RepositorioConLatencia) UpdateArchivos(actualizaciones []repository.ActualizacionArchivo) error {
+	r.esperar()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, actualizacion := range actualizaciones {
+		r.actualizado = append(r.actualizado, actualizacion.Archivo.ACGNombreArchivo)
+	}
+	return nil
+}
+
+func (r *RepositorioConLatencia) InsertEstadosArchivo([]*models.CGDArchivoEstados) error {
+	r.esperar()
+	return nil
+}
+
+func (r *RepositorioConLatencia) WithinTransaction(fn func(tx repository.RepositoryInterface) error) error {
+	return fn(r)
+}
+
+// lote construye un lote de archivos transmitidos exitosamente.
+func lote(cantidad int) []models.TransmittedFile {
+	transmittedFiles := make([]models.TransmittedFile, cantidad)
+	for i := range transmittedFiles {
+		transmittedFiles[i] = models.TransmittedFile{
+			FileName: fmt.Sprintf("TUTGMF0001000120240312-%04d", i+1),
+			TransmissionResult: models.TransmissionResult{
+				Status: "SUCCESSFUL",
+				Code:   "0000",
+			},
+		}
+	}
+	return transmittedFiles
+}
+
+func TestProcesarTransmisiones_Concurrente(t *testing.T) {
+	repo := &RepositorioConLatencia{
+		Latencia: time.Millisecond,
+		NoExisten: map[string]bool{
+			"TUTGMF0001000120240312-0003": true,
+			"TUTGMF0001000120240312-0017": true,
+		},
+	}
+	archivoService := service.NewArchivoService(repo, service.WithConcurrencia(4))
+	transmittedFiles := lote(20)
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), transmittedFiles)
+
+	// Los resultados conservan el orden de la solicitud aunque se procesen en paralelo
+	assert.Len(t, resultados, len(transmittedFiles))
+	for i, resultado := range resultados {
+		assert.Equal(t, transmittedFiles[i].FileName, resultado.FileName)
+		if repo.NoExisten[resultado.FileName] {
+			assert.Equal(t, models.OutcomeFailed, resultado.Outcome)
+			assert.Equal(t, service.CodigoNoEncontrado, resultado.ErrorCode)
+		} else {
+			assert.Equal(t, models.OutcomeProcessed, resultado.Outcome)
+			assert.Equal(t, "ENVIADO", resultado.Estado)
+		}
+	}
+	assert.Len(t, repo.actualizado, 18)
+	assert.LessOrEqual(t, repo.maxEnCurso.Load(), int32(4))
+	assert.Greater(t, repo.maxEnCurso.Load(), int32(1))
+}
+
+func TestProcesarTransmisiones_ConcurrenciaInvalida(t *testing.T) {
+	repo := &RepositorioConLatencia{}
+	archivoService := service.NewArchivoService(repo, service.WithConcurrencia(0))
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), lote(5))
+
+	// Una concurrencia inválida conserva el procesamiento secuencial
+	assert.Len(t, resultados, 5)
+	assert.Equal(t, int32(1), repo.maxEnCurso.Load())
+}
+
+// benchmarkProcesarTransmisiones procesa lotes de 100 archivos con 1 ms de latencia por operación.
+func benchmarkProcesarTransmisiones(b *testing.B, opts ...service.ServiceOption) {
+	archivoService := service.NewArchivoService(&RepositorioConLatencia{Latencia: time.Millisecond}, opts...)
+	transmittedFiles := lote(100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		archivoService.ProcesarTransmisiones(context.Background(), transmittedFiles)
+	}
+}
+
+func BenchmarkProcesarTransmisiones_Secuencial(b *testing.B) {
+	benchmarkProcesarTransmisiones(b, service.WithConcurrencia(1), service.WithUmbralLote(0))
+}
+
+func BenchmarkProcesarTransmisiones_Concurrente(b *testing.B) {
+	benchmarkProcesarTransmisiones(b, service.WithConcurrencia(16), service.WithUmbralLote(0))
+}
+
+func BenchmarkProcesarTransmisiones_Lote(b *testing.B) {
+	benchmarkProcesarTransmisiones(b, service.WithUmbralLote(50))
+}
@@ -1,10 +1,16 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"gmf_transmission_response/internal/filename"
 	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/metrics"
 	"gmf_transmission_response/internal/models"
 	"gmf_transmission_response/internal/repository"
+	"gmf_transmission_response/internal/statemachine"
+	"gorm.io/gorm"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,99 +18,339 @@ import (
 
 // ArchivoServiceInterface define los métodos que el servicio de archivos debe implementar.
 type ArchivoServiceInterface interface {
-	ProcesarTransmision(transmittedFile models.TransmittedFile) error
+	ProcesarTransmision(ctx context.Context, transmittedFile models.TransmittedFile) error
+	ProcesarTransmisiones(ctx context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult
+	ConsultarArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error)
+	ConsultarHistorial(acgNombreArchivo string, filtro models.FiltroHistorial) (*models.HistorialResponse, error)
 	RemoveExtension(fileName string) string
 	IsAnulacion(fileName string) bool
 	ValidateIDLength(id string) error
 }
 
+// ConcurrenciaPorDefecto es la cantidad de archivos que se procesan en paralelo si no se configura otro valor.
+const ConcurrenciaPorDefecto = 1
+
 // ArchivoService implementa el servicio de archivos.
 type ArchivoService struct {
-	repo repository.RepositoryInterface
+	repo         repository.RepositoryInterface
+	concurrencia int
+	umbralLote   int
+}
+
+// ServiceOption configura un ArchivoService.
+type ServiceOption func(*ArchivoService)
+
+// WithConcurrencia define cuántos archivos de un lote se procesan en paralelo.
+// Los valores menores a 1 se ignoran.
+func WithConcurrencia(concurrencia int) ServiceOption {
+	return func(s *ArchivoService) {
+		if concurrencia >= 1 {
+			s.concurrencia = concurrencia
+		}
+	}
+}
+
+// WithUmbralLote define a partir de cuántos archivos una solicitud se procesa con las operaciones masivas
+// del repositorio: las solicitudes con más de umbral archivos se procesan como un lote.
+// Un umbral de 0 deshabilita el procesamiento por lotes.
+func WithUmbralLote(umbral int) ServiceOption {
+	return func(s *ArchivoService) {
+		if umbral >= 0 {
+			s.umbralLote = umbral
+		}
+	}
 }
 
 // NewArchivoService crea una nueva instancia de ArchivoService.
-func NewArchivoService(repo repository.RepositoryInterface) *ArchivoService {
-	return &ArchivoService{
-		repo: repo,
+func NewArchivoService(repo repository.RepositoryInterface, opts ...ServiceOption) *ArchivoService {
+	s := &ArchivoService{
+		repo:         repo,
+		concurrencia: ConcurrenciaPorDefecto,
+		umbralLote:   UmbralLotePorDefecto,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ProcesarTransmisiones procesa cada archivo transmitido y retorna un resultado por archivo,
+// en el mismo orden en que fueron recibidos. Las solicitudes con más archivos que el umbral de
+// WithUmbralLote se procesan con las operaciones masivas del repositorio. Las demás se reparten
+// entre un máximo de WithConcurrencia workers; cada archivo usa su propia transacción sobre el
+// repositorio compartido. Los mensajes se registran con el logger de ctx.
+func (s *ArchivoService) ProcesarTransmisiones(
+	ctx context.Context, transmittedFiles []models.TransmittedFile) []models.FileResult {
+	if s.umbralLote > 0 && len(transmittedFiles) > s.umbralLote {
+		return s.procesarLote(ctx, transmittedFiles)
+	}
+
+	resultados := make([]models.FileResult, len(transmittedFiles))
+	ejecutarEnPool(len(transmittedFiles), s.concurrencia, func(i int) {
+		procesado, err := s.procesarArchivo(ctx, transmittedFiles[i])
+		resultados[i] = s.nuevoResultado(transmittedFiles[i], procesado, err)
+	})
+	return resultados
+}
+
+// nuevoResultado registra las métricas del archivo procesado y construye su resultado.
+func (s *ArchivoService) nuevoResultado(
+	transmittedFile models.TransmittedFile, procesado archivoProcesado, err error) models.FileResult {
+	s.registrarMetricas(transmittedFile, procesado, err)
+	resultado := NuevoFileResult(transmittedFile.FileName, procesado.estado, err)
+	if procesado.repetido {
+		resultado.Message = mensajeRespuestaRepetida
+	}
+	return resultado
 }
 
 // ProcesarTransmision procesa una respuesta de transmisión (movimiento o anulación).
-func (s *ArchivoService) ProcesarTransmision(transmittedFile models.TransmittedFile) error {
+// Los mensajes se registran con el logger de ctx.
+func (s *ArchivoService) ProcesarTransmision(ctx context.Context, transmittedFile models.TransmittedFile) error {
+	procesado, err := s.procesarArchivo(ctx, transmittedFile)
+	s.registrarMetricas(transmittedFile, procesado, err)
+	return err
+}
+
+// registrarMetricas contabiliza el resultado del procesamiento de un archivo.
+// El estado resultante solo se contabiliza cuando el archivo cambió de estado.
+func (s *ArchivoService) registrarMetricas(transmittedFile models.TransmittedFile, procesado archivoProcesado, err error) {
+	tipo := metrics.TipoArchivo(s.IsAnulacion(s.RemoveExtension(transmittedFile.FileName)))
+
+	outcome := models.OutcomeProcessed
+	if err != nil {
+		outcome = models.OutcomeFailed
+	}
+	metrics.ArchivosProcesados.Inc(tipo, transmittedFile.TransmissionResult.Status, outcome)
+
+	if err == nil && !procesado.repetido {
+		metrics.ArchivosPorEstado.Inc(tipo, procesado.estado)
+	}
+}
+
+// mensajeRespuestaRepetida se reporta cuando el gateway reenvía una respuesta ya registrada.
+const mensajeRespuestaRepetida = "La respuesta ya había sido registrada, no se realizaron cambios"
+
+// archivoProcesado contiene el estado en el que queda un archivo luego de procesarlo.
+type archivoProcesado struct {
+	estado   string
+	repetido bool
+}
+
+// procesarArchivo procesa un archivo transmitido y retorna el estado en el que queda el archivo.
+// Si el procesamiento falla, el estado retornado es el que tenía el archivo antes de procesarlo.
+func (s *ArchivoService) procesarArchivo(
+	ctx context.Context, transmittedFile models.TransmittedFile) (archivoProcesado, error) {
 	fileName := transmittedFile.FileName
-	isAnulacion := s.IsAnulacion(s.RemoveExtension(fileName))
+	ctx = logs.With(ctx, logs.FileName(fileName))
 
-	if isAnulacion {
-		logs.Logger.LogInfo("La transmisión es una anulación", fileName)
-	} else {
-		logs.Logger.LogInfo("La transmisión es un movimiento", fileName)
+	// Los archivos que no se iniciaron antes de cancelar ctx quedan sin procesar y pueden reintentarse
+	if err := ctx.Err(); err != nil {
+		return archivoProcesado{}, nuevoProcesamientoError(CodigoErrorBaseDatos, err)
+	}
+
+	// Rechazar los nombres mal formados antes de consultar la base de datos
+	nombre, err := s.parsearNombre(ctx, fileName)
+	if err != nil {
+		return archivoProcesado{}, err
 	}
 
 	archivo, err := s.repo.GetArchivoByNombreArchivo(fileName)
 	if err != nil {
-		logs.Logger.LogError("Error al obtener archivo de la base de datos", err, fileName)
-		return err
+		logs.Error(ctx, "Error al obtener archivo de la base de datos", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return archivoProcesado{}, nuevoProcesamientoError(CodigoNoEncontrado, err)
+		}
+		return archivoProcesado{}, nuevoProcesamientoError(CodigoErrorBaseDatos, err)
 	}
+	estadoAnterior := archivo.Estado
 
-	if err := s.actualizarEstadoArchivo(archivo, transmittedFile, isAnulacion); err != nil {
-		return err
+	nuevoEstado, procesado, err := s.evaluarTransicion(ctx, transmittedFile, nombre, archivo)
+	if err != nil || procesado.repetido {
+		return procesado, err
 	}
 
-	estadoArchivo := &models.CGDArchivoEstados{
-		IDArchivo:         archivo.IDArchivo,
-		EstadoInicial:     archivo.GAWRtaTransEstado,
-		EstadoFinal:       transmittedFile.TransmissionResult.Status,
-		FechaCambioEstado: time.Now(),
+	// La actualización del archivo y el registro del histórico se confirman o revierten juntos.
+	err = s.repo.WithinTransaction(func(tx repository.RepositoryInterface) error {
+		if err := s.actualizarEstadoArchivo(ctx, tx, archivo, transmittedFile, estadoAnterior, nuevoEstado); err != nil {
+			return err
+		}
+
+		estadoArchivo := nuevoHistorial(archivo.IDArchivo, estadoAnterior, nuevoEstado, transmittedFile)
+		if err := tx.InsertEstadoArchivo(estadoArchivo); err != nil {
+			logs.Error(ctx, "Error al insertar estado del archivo", err)
+			return err
+		}
+
+		return nil
+	})
+	if errors.Is(err, repository.ErrEstadoModificado) {
+		// Otra solicitud cambió el estado del archivo después de validarlo, la transición ya no es válida
+		logs.Warn(ctx, "Transición de estado rechazada", logs.Err(err))
+		return archivoProcesado{estado: estadoAnterior}, nuevoProcesamientoError(CodigoTransicionInvalida, err)
+	}
+	if err != nil {
+		return archivoProcesado{estado: estadoAnterior}, nuevoProcesamientoError(CodigoErrorBaseDatos, err)
 	}
 
-	if err := s.repo.InsertEstadoArchivo(estadoArchivo); err != nil {
-		logs.Logger.LogError("Error al insertar estado del archivo", err, fileName)
-		return err
+	logs.Info(ctx, "Estado insertado correctamente en la tabla CGD_ARCHIVO_ESTADO")
+	return archivoProcesado{estado: nuevoEstado}, nil
+}
+
+// parsearNombre interpreta el nombre del archivo transmitido y registra si es una anulación o un movimiento.
+func (s *ArchivoService) parsearNombre(ctx context.Context, fileName string) (*filename.NombreArchivo, error) {
+	nombre, err := filename.Parse(fileName)
+	if err != nil {
+		logs.Warn(ctx, "Nombre de archivo inválido", logs.Err(err))
+		return nil, nuevoProcesamientoError(CodigoErrorValidacion, err)
 	}
 
-	logs.Logger.LogInfo("Estado insertado correctamente en la tabla CGD_ARCHIVO_ESTADO", fileName)
-	return nil
+	if nombre.EsAnulacion {
+		logs.Info(ctx, "La transmisión es una anulación")
+	} else {
+		logs.Info(ctx, "La transmisión es un movimiento")
+	}
+	return nombre, nil
 }
 
-// actualizarEstadoArchivo actualiza el estado del archivo dependiendo si es anulación o movimiento.
-func (s *ArchivoService) actualizarEstadoArchivo(
-	archivo *models.CGDArchivos, transmittedFile models.TransmittedFile, isAnulacion bool) error {
-	// Actualizar el estado en función del resultado de la transmisión
-	archivo.GAWRtaTransEstado = transmittedFile.TransmissionResult.Status
-	archivo.GAWRtaTransCodigo = transmittedFile.TransmissionResult.Code
-	archivo.GAWRtaTransDetalle = transmittedFile.TransmissionResult.Detail
+// evaluarTransicion valida la respuesta de transmisión contra el archivo registrado y calcula su nuevo estado.
+// Si la respuesta ya había sido registrada retorna un archivoProcesado repetido, en cuyo caso no se debe escribir.
+func (s *ArchivoService) evaluarTransicion(ctx context.Context, transmittedFile models.TransmittedFile,
+	nombre *filename.NombreArchivo, archivo *models.CGDArchivos) (string, archivoProcesado, error) {
+	estadoAnterior := archivo.Estado
 
-	var filename = archivo.NombreArchivo
+	if err := nombre.ValidarContra(archivo); err != nil {
+		logs.Warn(ctx, "El nombre no coincide con el archivo registrado", logs.Err(err))
+		return "", archivoProcesado{estado: estadoAnterior}, nuevoProcesamientoError(CodigoErrorValidacion, err)
+	}
 
-	if transmittedFile.TransmissionResult.Status == "ERROR" {
-		if isAnulacion {
-			archivo.Estado = "ANULACION_FALLIDA"
-			logs.LogInfo("Se ha marcado el archivo en estado ANULACION_FALLIDA.", filename)
-		} else {
-			archivo.Estado = "ENVIO_FALLIDO"
-			logs.LogInfo("Se ha marcado el archivo en estado ENVIO_FALLIDO.", filename)
-		}
-	} else {
-		// Si la transmisión fue exitosa
+	// Si el gateway reenvía una respuesta ya registrada, se retorna el resultado original sin escribir de nuevo
+	nuevoEstado := s.determinarNuevoEstado(transmittedFile.TransmissionResult.Status, nombre.EsAnulacion)
+	if s.esRespuestaRepetida(archivo, transmittedFile.TransmissionResult, nuevoEstado) {
+		logs.Info(ctx, "La respuesta de transmisión ya había sido registrada")
+		return nuevoEstado, archivoProcesado{estado: estadoAnterior, repetido: true}, nil
+	}
+
+	// Validar que el archivo pueda pasar al nuevo estado antes de escribir en la base de datos
+	if err := statemachine.ValidarTransicion(estadoAnterior, nuevoEstado); err != nil {
+		logs.Warn(ctx, "Transición de estado rechazada", logs.Err(err))
+		return "", archivoProcesado{estado: estadoAnterior}, nuevoProcesamientoError(CodigoTransicionInvalida, err)
+	}
+
+	return nuevoEstado, archivoProcesado{estado: nuevoEstado}, nil
+}
+
+// nuevoHistorial construye el registro del histórico para una transición del estado de negocio,
+// junto con la respuesta del gateway que la originó.
+func nuevoHistorial(idArchivo int64, estadoAnterior, nuevoEstado string,
+	transmittedFile models.TransmittedFile) *models.CGDArchivoEstados {
+	return &models.CGDArchivoEstados{
+		IDArchivo:         idArchivo,
+		EstadoInicial:     estadoAnterior,
+		EstadoFinal:       nuevoEstado,
+		GAWRtaTransEstado: transmittedFile.TransmissionResult.Status,
+		GAWRtaTransCodigo: transmittedFile.TransmissionResult.Code,
+		FechaCambioEstado: time.Now(),
+	}
+}
+
+// esRespuestaRepetida indica si el archivo ya tiene registrado el mismo resultado de transmisión
+// y el estado que ese resultado produciría.
+func (s *ArchivoService) esRespuestaRepetida(
+	archivo *models.CGDArchivos, resultado models.TransmissionResult, nuevoEstado string) bool {
+	return archivo.Estado == nuevoEstado &&
+		archivo.GAWRtaTransEstado == resultado.Status &&
+		archivo.GAWRtaTransCodigo == resultado.Code &&
+		archivo.GAWRtaTransDetalle == resultado.Detail
+}
+
+// determinarNuevoEstado calcula el estado del archivo según el resultado de la transmisión
+// y si se trata de una anulación o un movimiento.
+func (s *ArchivoService) determinarNuevoEstado(status string, isAnulacion bool) string {
+	if status == models.StatusError {
 		if isAnulacion {
-			archivo.Estado = "ANULACION_ENVIADA"
-			logs.LogInfo("Se ha marcado el archivo en estado ANULACION_ENVIADA.", filename)
-		} else {
-			archivo.Estado = "ENVIADO"
-			logs.LogInfo("Se ha marcado el archivo en estado ENVIADO.", filename)
+			return string(statemachine.EstadoAnulacionFallida)
 		}
+		return string(statemachine.EstadoEnvioFallido)
 	}
 
+	// Si la transmisión fue exitosa
+	if isAnulacion {
+		return string(statemachine.EstadoAnulacionEnviada)
+	}
+	return string(statemachine.EstadoEnviado)
+}
+
+// actualizarEstadoArchivo actualiza el archivo con el resultado de la transmisión y su nuevo estado,
+// siempre que el archivo siga en el estado con el que se validó la transición.
+func (s *ArchivoService) actualizarEstadoArchivo(ctx context.Context, tx repository.RepositoryInterface,
+	archivo *models.CGDArchivos, transmittedFile models.TransmittedFile, estadoAnterior, nuevoEstado string) error {
+	// Actualizar el estado en función del resultado de la transmisión
+	aplicarResultado(archivo, transmittedFile, nuevoEstado)
+
+	ctx = logs.With(ctx, logs.IDArchivo(archivo.IDArchivo), logs.Estado(nuevoEstado))
+	logs.Info(ctx, "Se ha marcado el archivo con su nuevo estado")
+
 	// Actualizar el archivo en la base de datos
-	if err := s.repo.UpdateArchivo(archivo); err != nil {
-		logs.LogError("Error al actualizar el archivo en la base de datos: %v", err, filename)
+	if err := tx.UpdateArchivo(archivo, estadoAnterior); err != nil {
+		logs.Error(ctx, "Error al actualizar el archivo en la base de datos", err)
 		return err
 	}
 
 	return nil
 }
 
+// NuevaRespuesta construye la respuesta de /transmission a partir de los resultados de cada archivo.
+func NuevaRespuesta(resultados []models.FileResult) models.Response {
+	var errorCount int
+	for _, resultado := range resultados {
+		if resultado.Outcome == models.OutcomeFailed {
+			errorCount++
+		}
+	}
+
+	response := models.Response{
+		TotalFiles: len(resultados),
+		ErrorCount: errorCount,
+		Success:    errorCount == 0,
+		Results:    resultados,
+	}
+	if errorCount > 0 {
+		response.Message = fmt.Sprintf("Se procesaron con errores %d de %d archivos", errorCount, len(resultados))
+	} else {
+		response.Message = "Todos los archivos fueron procesados correctamente"
+	}
+	return response
+}
+
+// aplicarResultado asigna al archivo el resultado de la transmisión y su nuevo estado.
+func aplicarResultado(archivo *models.CGDArchivos, transmittedFile models.TransmittedFile, nuevoEstado string) {
+	archivo.GAWRtaTransEstado = transmittedFile.TransmissionResult.Status
+	archivo.GAWRtaTransCodigo = transmittedFile.TransmissionResult.Code
+	archivo.GAWRtaTransDetalle = transmittedFile.TransmissionResult.Detail
+	archivo.Estado = nuevoEstado
+}
+
+// NuevoFileResult construye el resultado de un archivo a partir del estado final y el error de procesamiento.
+func NuevoFileResult(fileName, estado string, err error) models.FileResult {
+	if err != nil {
+		return models.FileResult{
+			FileName:  fileName,
+			Outcome:   models.OutcomeFailed,
+			Estado:    estado,
+			ErrorCode: CodigoError(err),
+			Message:   err.Error(),
+		}
+	}
+
+	return models.FileResult{
+		FileName: fileName,
+		Outcome:  models.OutcomeProcessed,
+		Estado:   estado,
+		Message:  "Archivo procesado exitosamente",
+	}
+}
+
 // removeExtension elimina la extensión de un nombre de archivo.
 func (s *ArchivoService) RemoveExtension(fileName string) string {
 	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
@@ -118,7 +364,8 @@ func (s *ArchivoService) IsAnulacion(fileName string) bool {
 // validateIDLength valida que el ID del archivo tenga una longitud de 16 caracteres.
 func (s *ArchivoService) ValidateIDLength(id string) error {
 	if len(id) != 16 {
-		return fmt.Errorf("el ID del archivo debe tener una longitud de 16 caracteres")
+		return nuevoProcesamientoError(CodigoErrorValidacion,
+			fmt.Errorf("el ID del archivo debe tener una longitud de 16 caracteres"))
 	}
 	return nil
 }
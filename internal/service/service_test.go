@@ -1,12 +1,21 @@
 package service_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gmf_transmission_response/internal/filename"
+	"gmf_transmission_response/internal/logs"
+	"gmf_transmission_response/internal/metrics"
 	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/repository"
 	"gmf_transmission_response/internal/service"
+	"gmf_transmission_response/internal/statemachine"
+	"gorm.io/gorm"
+	"log/slog"
 	"testing"
 )
 
@@ -23,14 +32,60 @@ func (m *MockRepository) GetArchivoByNombreArchivo(nombreArchivo string) (*model
 	return nil, args.Error(1)
 }
 
-func (m *MockRepository) UpdateArchivo(archivo *models.CGDArchivos) error {
-	return m.Called(archivo).Error(0)
+func (m *MockRepository) GetConsultaArchivo(acgNombreArchivo string) (*models.ArchivoConsulta, error) {
+	args := m.Called(acgNombreArchivo)
+	if consulta, ok := args.Get(0).(*models.ArchivoConsulta); ok {
+		return consulta, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetHistorialEstados(
+	idArchivo int64, filtro models.FiltroHistorial) ([]models.CGDArchivoEstados, int64, error) {
+	args := m.Called(idArchivo, filtro)
+	estados, _ := args.Get(0).([]models.CGDArchivoEstados)
+	return estados, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) UpdateArchivo(archivo *models.CGDArchivos, estadoAnterior string) error {
+	return m.Called(archivo, estadoAnterior).Error(0)
 }
 
 func (m *MockRepository) InsertEstadoArchivo(estado *models.CGDArchivoEstados) error {
 	return m.Called(estado).Error(0)
 }
 
+func (m *MockRepository) GetArchivosByNombresArchivo(nombresArchivo []string) ([]models.CGDArchivos, error) {
+	args := m.Called(nombresArchivo)
+	archivos, _ := args.Get(0).([]models.CGDArchivos)
+	return archivos, args.Error(1)
+}
+
+func (m *MockRepository) UpdateArchivos(actualizaciones []repository.ActualizacionArchivo) error {
+	return m.Called(actualizaciones).Error(0)
+}
+
+func (m *MockRepository) InsertEstadosArchivo(estados []*models.CGDArchivoEstados) error {
+	return m.Called(estados).Error(0)
+}
+
+// WithinTransaction ejecuta la función recibida usando el mismo mock como repositorio transaccional.
+func (m *MockRepository) WithinTransaction(fn func(tx repository.RepositoryInterface) error) error {
+	return fn(m)
+}
+
+// historialEsperado verifica el registro insertado en el histórico, sin considerar la fecha del cambio.
+func historialEsperado(idArchivo int64, estadoInicial, estadoFinal, status, codigo string) interface{} {
+	return mock.MatchedBy(func(estado *models.CGDArchivoEstados) bool {
+		return estado.IDArchivo == idArchivo &&
+			estado.EstadoInicial == estadoInicial &&
+			estado.EstadoFinal == estadoFinal &&
+			estado.GAWRtaTransEstado == status &&
+			estado.GAWRtaTransCodigo == codigo &&
+			!estado.FechaCambioEstado.IsZero()
+	})
+}
+
 // Test de procesamiento de transmisión exitosa
 func TestProcesarTransmision_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
@@ -46,16 +101,21 @@ func TestProcesarTransmision_Success(t *testing.T) {
 	}
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	// Simular respuestas del mock
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
-	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, "EMPAQUETADO").Return(nil)
+	mockRepo.On("InsertEstadoArchivo", historialEsperado(
+		archivo.IDArchivo, "EMPAQUETADO", "ENVIADO", "SUCCESSFUL", "0000")).Return(nil)
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	// Validaciones
 	assert.NoError(t, err)
@@ -78,16 +138,21 @@ func TestProcesarTransmision_Failure(t *testing.T) {
 	}
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	// Simular respuestas del mock
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
-	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", historialEsperado(
+		archivo.IDArchivo, "EMPAQUETADO", "ENVIO_FALLIDO", "ERROR", "0001")).Return(nil)
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	// Validaciones
 	assert.NoError(t, err)
@@ -112,7 +177,7 @@ func TestProcesarTransmision_ArchivoNoEncontrado(t *testing.T) {
 	// Simular que no se encuentra el archivo
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(nil, errors.New("archivo no encontrado"))
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	// Validaciones
 	assert.Error(t, err)
@@ -120,6 +185,27 @@ func TestProcesarTransmision_ArchivoNoEncontrado(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// Test de los mensajes registrados con el logger del contexto de la solicitud
+func TestProcesarTransmision_LogConContexto(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(nil, errors.New("timeout"))
+
+	var salida bytes.Buffer
+	ctx := logs.With(logs.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&salida, nil))),
+		logs.RequestID("req-1"))
+
+	err := archivoService.ProcesarTransmision(ctx, models.TransmittedFile{
+		FileName:           "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{Status: "SUCCESSFUL", Code: "0000"},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, salida.String(), `"msg":"Error al obtener archivo de la base de datos"`)
+	assert.Contains(t, salida.String(), `"request_id":"req-1"`)
+	assert.Contains(t, salida.String(), `"file_name":"TUTGMF0001000120240312-0001"`)
+}
+
 // Test de validación de longitud de ID inválido
 func TestProcesarTransmision_ValidacionID(t *testing.T) {
 	mockRepo := new(MockRepository)
@@ -132,7 +218,7 @@ func TestProcesarTransmision_ValidacionID(t *testing.T) {
 
 	// Simulamos que el ID tiene longitud incorrecta (más de 16 dígitos) utilizando `strconv.FormatInt`.
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
 	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
 
 	// Forzar que el archivo tenga un ID con más de 16 caracteres para la validación.
@@ -141,7 +227,7 @@ func TestProcesarTransmision_ValidacionID(t *testing.T) {
 	// Validar que el error de longitud sea detectado
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "el ID del archivo debe tener una longitud de 16 caracteres")
-	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything, mock.Anything)
 	mockRepo.AssertNotCalled(t, "InsertEstadoArchivo", mock.Anything)
 }
 func TestProcesarTransmision_Anulacion(t *testing.T) {
@@ -149,8 +235,12 @@ func TestProcesarTransmision_Anulacion(t *testing.T) {
 	archivoService := service.NewArchivoService(mockRepo)
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     2,
+		Estado:             "ENVIADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	transmittedFile := models.TransmittedFile{
@@ -163,14 +253,15 @@ func TestProcesarTransmision_Anulacion(t *testing.T) {
 	}
 
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0002-A").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
-	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", historialEsperado(
+		archivo.IDArchivo, "ENVIADO", "ANULACION_ENVIADA", "SUCCESSFUL", "0000")).Return(nil)
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	assert.NoError(t, err)
 	mockRepo.AssertCalled(t, "GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0002-A")
-	mockRepo.AssertCalled(t, "UpdateArchivo", archivo)
+	mockRepo.AssertCalled(t, "UpdateArchivo", archivo, mock.Anything)
 	mockRepo.AssertCalled(t, "InsertEstadoArchivo", mock.Anything)
 }
 
@@ -179,8 +270,12 @@ func TestProcesarTransmision_ErrorAlInsertarEstado(t *testing.T) {
 	archivoService := service.NewArchivoService(mockRepo)
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	transmittedFile := models.TransmittedFile{
@@ -193,10 +288,10 @@ func TestProcesarTransmision_ErrorAlInsertarEstado(t *testing.T) {
 	}
 
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
 	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(fmt.Errorf("mock error"))
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "mock error")
@@ -208,8 +303,12 @@ func TestProcesarTransmision_AnulacionFallida(t *testing.T) {
 	archivoService := service.NewArchivoService(mockRepo)
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     2,
+		Estado:             "ENVIADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	transmittedFile := models.TransmittedFile{
@@ -222,14 +321,15 @@ func TestProcesarTransmision_AnulacionFallida(t *testing.T) {
 	}
 
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0002-A").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(nil)
-	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", historialEsperado(
+		archivo.IDArchivo, "ENVIADO", "ANULACION_FALLIDA", "ERROR", "0001")).Return(nil)
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "ANULACION_FALLIDA", archivo.Estado) // Comprobamos el estado
-	mockRepo.AssertCalled(t, "UpdateArchivo", archivo)
+	mockRepo.AssertCalled(t, "UpdateArchivo", archivo, mock.Anything)
 }
 
 func TestProcesarTransmision_ErrorAlActualizarArchivo(t *testing.T) {
@@ -237,8 +337,12 @@ func TestProcesarTransmision_ErrorAlActualizarArchivo(t *testing.T) {
 	archivoService := service.NewArchivoService(mockRepo)
 
 	archivo := &models.CGDArchivos{
-		IDArchivo:         10001202403120001,
-		GAWRtaTransEstado: "PENDING",
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+		GAWRtaTransEstado:  "PENDING",
 	}
 
 	transmittedFile := models.TransmittedFile{
@@ -251,12 +355,321 @@ func TestProcesarTransmision_ErrorAlActualizarArchivo(t *testing.T) {
 	}
 
 	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
-	mockRepo.On("UpdateArchivo", archivo).Return(fmt.Errorf("mock error"))
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(fmt.Errorf("mock error"))
 	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
 
-	err := archivoService.ProcesarTransmision(transmittedFile)
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "mock error")
-	mockRepo.AssertCalled(t, "UpdateArchivo", archivo)
+	mockRepo.AssertCalled(t, "UpdateArchivo", archivo, mock.Anything)
+}
+
+func TestProcesarTransmision_TransicionInvalida(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	// Un archivo ya anulado no puede volver a quedar en estado ENVIADO
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "ANULACION_ENVIADA",
+		GAWRtaTransEstado:  "SUCCESSFUL",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{
+			Status: "SUCCESSFUL",
+			Code:   "0000",
+			Detail: "Transmisión exitosa",
+		},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	var transicionErr *statemachine.TransicionInvalidaError
+	assert.ErrorAs(t, err, &transicionErr)
+	assert.ErrorIs(t, err, statemachine.ErrTransicionInvalida)
+	assert.Equal(t, "ANULACION_ENVIADA", transicionErr.Desde)
+	assert.Equal(t, "ENVIADO", transicionErr.Hacia)
+	assert.Equal(t, "ANULACION_ENVIADA", archivo.Estado)
+	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "InsertEstadoArchivo", mock.Anything)
+}
+
+func TestProcesarTransmision_EstadoModificadoPorOtraSolicitud(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	// La lectura retorna EMPAQUETADO, pero otra solicitud cambia el estado antes de la actualización
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName:           "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{Status: "SUCCESSFUL", Code: "0000"},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
+	mockRepo.On("UpdateArchivo", archivo, "EMPAQUETADO").Return(repository.ErrEstadoModificado)
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	assert.ErrorIs(t, err, repository.ErrEstadoModificado)
+	assert.Equal(t, service.CodigoTransicionInvalida, service.CodigoError(err))
+	mockRepo.AssertNotCalled(t, "InsertEstadoArchivo", mock.Anything)
+}
+
+func TestProcesarTransmision_ReintentoEnvioFallido(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	// Un archivo con envío fallido puede quedar ENVIADO cuando el reintento es exitoso
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "ENVIO_FALLIDO",
+		GAWRtaTransEstado:  "ERROR",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{
+			Status: "SUCCESSFUL",
+			Code:   "0000",
+			Detail: "Transmisión exitosa",
+		},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", historialEsperado(
+		archivo.IDArchivo, "ENVIO_FALLIDO", "ENVIADO", "SUCCESSFUL", "0000")).Return(nil)
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ENVIADO", archivo.Estado)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcesarTransmisiones_ResultadosPorArchivo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	archivoEnviado := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+	}
+	archivoAnulado := &models.CGDArchivos{
+		IDArchivo:          10001202403120003,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     3,
+		Estado:             "ANULACION_ENVIADA",
+	}
+	archivoErrorBD := &models.CGDArchivos{
+		IDArchivo:          10001202403120004,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     4,
+		Estado:             "EMPAQUETADO",
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivoEnviado, nil)
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0002").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0003").Return(archivoAnulado, nil)
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0004").Return(archivoErrorBD, nil)
+	mockRepo.On("UpdateArchivo", archivoEnviado, mock.Anything).Return(nil)
+	mockRepo.On("UpdateArchivo", archivoErrorBD, mock.Anything).Return(fmt.Errorf("mock error"))
+	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+
+	transmittedFiles := []models.TransmittedFile{}
+	for _, fileName := range []string{
+		"TUTGMF0001000120240312-0001",
+		"TUTGMF0001000120240312-0002",
+		"TUTGMF0001000120240312-0003",
+		"TUTGMF0001000120240312-0004",
+	} {
+		transmittedFiles = append(transmittedFiles, models.TransmittedFile{
+			FileName: fileName,
+			TransmissionResult: models.TransmissionResult{
+				Status: "SUCCESSFUL",
+				Code:   "0000",
+				Detail: "Transmisión exitosa",
+			},
+		})
+	}
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), transmittedFiles)
+
+	assert.Len(t, resultados, 4)
+
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	assert.Equal(t, "ENVIADO", resultados[0].Estado)
+	assert.Empty(t, resultados[0].ErrorCode)
+
+	assert.Equal(t, models.OutcomeFailed, resultados[1].Outcome)
+	assert.Equal(t, service.CodigoNoEncontrado, resultados[1].ErrorCode)
+	assert.Empty(t, resultados[1].Estado)
+
+	assert.Equal(t, models.OutcomeFailed, resultados[2].Outcome)
+	assert.Equal(t, service.CodigoTransicionInvalida, resultados[2].ErrorCode)
+	assert.Equal(t, "ANULACION_ENVIADA", resultados[2].Estado)
+
+	assert.Equal(t, models.OutcomeFailed, resultados[3].Outcome)
+	assert.Equal(t, service.CodigoErrorBaseDatos, resultados[3].ErrorCode)
+	assert.Equal(t, "EMPAQUETADO", resultados[3].Estado)
+	assert.Equal(t, "mock error", resultados[3].Message)
+}
+
+func TestCodigoError(t *testing.T) {
+	assert.Empty(t, service.CodigoError(nil))
+	assert.Equal(t, service.CodigoErrorBaseDatos, service.CodigoError(errors.New("error genérico")))
+	assert.Equal(t, service.CodigoTransicionInvalida,
+		service.CodigoError(statemachine.ValidarTransicion("ANULACION_ENVIADA", "ENVIADO")))
+	assert.Equal(t, service.CodigoErrorValidacion,
+		service.CodigoError(service.NewArchivoService(nil).ValidateIDLength("123")))
+}
+
+func TestProcesarTransmision_NombreMalFormado(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF000100012024031-0001.txt", // La fecha tiene solo 7 dígitos
+		TransmissionResult: models.TransmissionResult{
+			Status: "SUCCESSFUL",
+			Code:   "0000",
+			Detail: "Transmisión exitosa",
+		},
+	}
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	// El nombre debe rechazarse sin consultar la base de datos
+	assert.ErrorIs(t, err, filename.ErrNombreInvalido)
+	assert.Equal(t, service.CodigoErrorValidacion, service.CodigoError(err))
+	mockRepo.AssertNotCalled(t, "GetArchivoByNombreArchivo", mock.Anything)
+}
+
+func TestProcesarTransmision_NombreNoCoincideConArchivo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240311",
+		ACGConsecutivo:     1,
+		Estado:             "EMPAQUETADO",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{
+			Status: "SUCCESSFUL",
+			Code:   "0000",
+			Detail: "Transmisión exitosa",
+		},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	assert.ErrorIs(t, err, filename.ErrNoCoincide)
+	assert.Contains(t, err.Error(), "fecha_nombre_archivo")
+	assert.Equal(t, service.CodigoErrorValidacion, service.CodigoError(err))
+	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything, mock.Anything)
+}
+
+func TestProcesarTransmisiones_RespuestaRepetida(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	// El archivo ya tiene registrado exactamente el mismo resultado de transmisión
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     1,
+		Estado:             "ENVIADO",
+		GAWRtaTransEstado:  "SUCCESSFUL",
+		GAWRtaTransCodigo:  "0000",
+		GAWRtaTransDetalle: "Transmisión exitosa",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF0001000120240312-0001",
+		TransmissionResult: models.TransmissionResult{
+			Status: "SUCCESSFUL",
+			Code:   "0000",
+			Detail: "Transmisión exitosa",
+		},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", "TUTGMF0001000120240312-0001").Return(archivo, nil)
+
+	resultados := archivoService.ProcesarTransmisiones(context.Background(), []models.TransmittedFile{transmittedFile})
+
+	// La repetición retorna el resultado original sin escribir de nuevo
+	assert.Len(t, resultados, 1)
+	assert.Equal(t, models.OutcomeProcessed, resultados[0].Outcome)
+	assert.Equal(t, "ENVIADO", resultados[0].Estado)
+	assert.Contains(t, resultados[0].Message, "ya había sido registrada")
+	assert.NoError(t, archivoService.ProcesarTransmision(context.Background(), transmittedFile))
+	mockRepo.AssertNotCalled(t, "UpdateArchivo", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "InsertEstadoArchivo", mock.Anything)
+}
+
+func TestProcesarTransmision_Metricas(t *testing.T) {
+	mockRepo := new(MockRepository)
+	archivoService := service.NewArchivoService(mockRepo)
+
+	archivo := &models.CGDArchivos{
+		IDArchivo:          10001202403120001,
+		PlataformaOrigen:   "00",
+		FechaNombreArchivo: "20240312",
+		ACGConsecutivo:     2,
+		Estado:             "ENVIADO",
+	}
+
+	transmittedFile := models.TransmittedFile{
+		FileName: "TUTGMF0001000120240312-0002-A",
+		TransmissionResult: models.TransmissionResult{
+			Status: "ERROR",
+			Code:   "0001",
+			Detail: "Error en la transmisión",
+		},
+	}
+
+	mockRepo.On("GetArchivoByNombreArchivo", transmittedFile.FileName).Return(archivo, nil)
+	mockRepo.On("UpdateArchivo", archivo, mock.Anything).Return(nil)
+	mockRepo.On("InsertEstadoArchivo", mock.Anything).Return(nil)
+
+	procesados := metrics.ArchivosProcesados.Value(metrics.TipoAnulacion, "ERROR", models.OutcomeProcessed)
+	porEstado := metrics.ArchivosPorEstado.Value(metrics.TipoAnulacion, "ANULACION_FALLIDA")
+
+	err := archivoService.ProcesarTransmision(context.Background(), transmittedFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, procesados+1,
+		metrics.ArchivosProcesados.Value(metrics.TipoAnulacion, "ERROR", models.OutcomeProcessed))
+	assert.Equal(t, porEstado+1, metrics.ArchivosPorEstado.Value(metrics.TipoAnulacion, "ANULACION_FALLIDA"))
 }
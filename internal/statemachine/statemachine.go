@@ -0,0 +1,72 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Estado representa un estado válido de la columna estado de la tabla CGD_ARCHIVOS.
+type Estado string
+
+const (
+	EstadoCargado          Estado = "CARGADO"
+	EstadoGenerado         Estado = "GENERADO"
+	EstadoEmpaquetado      Estado = "EMPAQUETADO"
+	EstadoEnviado          Estado = "ENVIADO"
+	EstadoEnvioFallido     Estado = "ENVIO_FALLIDO"
+	EstadoAnulacionEnviada Estado = "ANULACION_ENVIADA"
+	EstadoAnulacionFallida Estado = "ANULACION_FALLIDA"
+)
+
+// transiciones define, para cada estado, los estados a los que puede pasar un archivo.
+var transiciones = map[Estado][]Estado{
+	EstadoCargado:          {EstadoGenerado},
+	EstadoGenerado:         {EstadoEmpaquetado},
+	EstadoEmpaquetado:      {EstadoEnviado, EstadoEnvioFallido},
+	EstadoEnvioFallido:     {EstadoEnviado, EstadoEnvioFallido},
+	EstadoEnviado:          {EstadoAnulacionEnviada, EstadoAnulacionFallida},
+	EstadoAnulacionFallida: {EstadoAnulacionEnviada, EstadoAnulacionFallida},
+	EstadoAnulacionEnviada: {},
+}
+
+// ErrTransicionInvalida es el error base para las transiciones de estado no permitidas.
+var ErrTransicionInvalida = errors.New("transición de estado no permitida")
+
+// TransicionInvalidaError indica que un archivo no puede pasar del estado Desde al estado Hacia.
+type TransicionInvalidaError struct {
+	Desde string
+	Hacia string
+}
+
+func (e *TransicionInvalidaError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrTransicionInvalida.Error(), e.Desde, e.Hacia)
+}
+
+// Is permite comparar el error con ErrTransicionInvalida usando errors.Is.
+func (e *TransicionInvalidaError) Is(target error) bool {
+	return target == ErrTransicionInvalida
+}
+
+// EsEstadoValido indica si el estado está declarado en la máquina de estados.
+func EsEstadoValido(estado string) bool {
+	_, ok := transiciones[Estado(estado)]
+	return ok
+}
+
+// PuedeTransicionar indica si un archivo puede pasar del estado desde al estado hacia.
+func PuedeTransicionar(desde, hacia string) bool {
+	for _, permitido := range transiciones[Estado(desde)] {
+		if permitido == Estado(hacia) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidarTransicion retorna un *TransicionInvalidaError si la transición no está permitida.
+func ValidarTransicion(desde, hacia string) error {
+	if !PuedeTransicionar(desde, hacia) {
+		return &TransicionInvalidaError{Desde: desde, Hacia: hacia}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/statemachine"
+)
+
+func TestPuedeTransicionar_TransicionesPermitidas(t *testing.T) {
+	casos := []struct {
+		desde statemachine.Estado
+		hacia statemachine.Estado
+	}{
+		{statemachine.EstadoCargado, statemachine.EstadoGenerado},
+		{statemachine.EstadoGenerado, statemachine.EstadoEmpaquetado},
+		{statemachine.EstadoEmpaquetado, statemachine.EstadoEnviado},
+		{statemachine.EstadoEmpaquetado, statemachine.EstadoEnvioFallido},
+		{statemachine.EstadoEnvioFallido, statemachine.EstadoEnviado},
+		{statemachine.EstadoEnvioFallido, statemachine.EstadoEnvioFallido},
+		{statemachine.EstadoEnviado, statemachine.EstadoAnulacionEnviada},
+		{statemachine.EstadoEnviado, statemachine.EstadoAnulacionFallida},
+		{statemachine.EstadoAnulacionFallida, statemachine.EstadoAnulacionEnviada},
+	}
+
+	for _, c := range casos {
+		assert.True(t, statemachine.PuedeTransicionar(string(c.desde), string(c.hacia)),
+			"se esperaba permitir %s -> %s", c.desde, c.hacia)
+		assert.NoError(t, statemachine.ValidarTransicion(string(c.desde), string(c.hacia)))
+	}
+}
+
+func TestPuedeTransicionar_TransicionesNoPermitidas(t *testing.T) {
+	casos := []struct {
+		desde string
+		hacia string
+	}{
+		{"ANULACION_ENVIADA", "ENVIADO"},
+		{"ANULACION_ENVIADA", "ANULACION_FALLIDA"},
+		{"ENVIADO", "ENVIO_FALLIDO"},
+		{"EMPAQUETADO", "ANULACION_ENVIADA"},
+		{"CARGADO", "ENVIADO"},
+		{"", "ENVIADO"},
+		{"DESCONOCIDO", "ENVIADO"},
+	}
+
+	for _, c := range casos {
+		assert.False(t, statemachine.PuedeTransicionar(c.desde, c.hacia),
+			"se esperaba rechazar %s -> %s", c.desde, c.hacia)
+	}
+}
+
+func TestValidarTransicion_ErrorTipado(t *testing.T) {
+	err := statemachine.ValidarTransicion("ANULACION_ENVIADA", "ENVIADO")
+
+	var transicionErr *statemachine.TransicionInvalidaError
+	assert.True(t, errors.As(err, &transicionErr))
+	assert.True(t, errors.Is(err, statemachine.ErrTransicionInvalida))
+	assert.Equal(t, "ANULACION_ENVIADA", transicionErr.Desde)
+	assert.Equal(t, "ENVIADO", transicionErr.Hacia)
+	assert.Equal(t, "transición de estado no permitida: ANULACION_ENVIADA -> ENVIADO", err.Error())
+}
+
+func TestEsEstadoValido(t *testing.T) {
+	assert.True(t, statemachine.EsEstadoValido("ENVIADO"))
+	assert.True(t, statemachine.EsEstadoValido("ANULACION_ENVIADA"))
+	assert.False(t, statemachine.EsEstadoValido("PROCESADO"))
+	assert.False(t, statemachine.EsEstadoValido(""))
+}
@@ -0,0 +1,185 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gmf_transmission_response/internal/models"
+)
+
+// Longitudes máximas de las columnas donde se almacena el resultado de la transmisión.
+const (
+	MaxLongitudCodigo  = 4    // gaw_rta_trans_codigo varchar(4)
+	MaxLongitudDetalle = 1000 // gaw_rta_trans_detalle varchar(1000)
+)
+
+// statusPermitidos contiene los estados de transmisión aceptados.
+var statusPermitidos = map[string]bool{
+	models.StatusSuccessful: true,
+	models.StatusError:      true,
+}
+
+// Campos permitidos en cada nivel de la solicitud.
+var (
+	camposRaiz      = map[string]bool{"transmittedFiles": true}
+	camposArchivo   = map[string]bool{"fileName": true, "transmissionResult": true}
+	camposResultado = map[string]bool{"status": true, "code": true, "detail": true}
+)
+
+// ValidacionError agrupa todas las violaciones encontradas en una solicitud.
+type ValidacionError struct {
+	Violations []models.Violation
+}
+
+func (e *ValidacionError) Error() string {
+	mensajes := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		mensajes = append(mensajes, fmt.Sprintf("%s: %s", v.Field, v.Message))
+	}
+	return "solicitud inválida: " + strings.Join(mensajes, "; ")
+}
+
+// DecodificarTransmisionResponse decodifica el cuerpo de la solicitud y valida su esquema.
+// Retorna un *ValidacionError con todas las violaciones encontradas, o el error de sintaxis
+// si el cuerpo no es un JSON válido.
+func DecodificarTransmisionResponse(body []byte) (*models.TransmisionResponse, error) {
+	violaciones, err := validarCamposDesconocidos(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var transmisionResponse models.TransmisionResponse
+	if err := json.Unmarshal(body, &transmisionResponse); err != nil {
+		// Los errores de tipo no detienen la decodificación, el resto de campos se sigue validando
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		violaciones = append(violaciones, models.Violation{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("tipo inválido, se esperaba %s", typeErr.Type.String()),
+		})
+	}
+
+	violaciones = append(violaciones, ValidarTransmisionResponse(&transmisionResponse)...)
+	if len(violaciones) > 0 {
+		return nil, &ValidacionError{Violations: violaciones}
+	}
+
+	return &transmisionResponse, nil
+}
+
+// ValidarTransmisionResponse valida las reglas de negocio de una solicitud ya decodificada.
+func ValidarTransmisionResponse(transmisionResponse *models.TransmisionResponse) []models.Violation {
+	var violaciones []models.Violation
+
+	if len(transmisionResponse.TransmittedFiles) == 0 {
+		violaciones = append(violaciones, models.Violation{
+			Field:   "transmittedFiles",
+			Message: "debe contener al menos un archivo",
+		})
+	}
+
+	vistos := make(map[string]int)
+	for i, transmittedFile := range transmisionResponse.TransmittedFiles {
+		campo := fmt.Sprintf("transmittedFiles[%d]", i)
+		resultado := transmittedFile.TransmissionResult
+
+		if strings.TrimSpace(transmittedFile.FileName) == "" {
+			violaciones = append(violaciones, models.Violation{
+				Field:   campo + ".fileName",
+				Message: "es obligatorio",
+			})
+		} else if primero, ok := vistos[transmittedFile.FileName]; ok {
+			violaciones = append(violaciones, models.Violation{
+				Field:   campo + ".fileName",
+				Message: fmt.Sprintf("está duplicado con transmittedFiles[%d]", primero),
+			})
+		} else {
+			vistos[transmittedFile.FileName] = i
+		}
+
+		if !statusPermitidos[resultado.Status] {
+			violaciones = append(violaciones, models.Violation{
+				Field: campo + ".transmissionResult.status",
+				Message: fmt.Sprintf("valor %q no permitido, se esperaba %s o %s",
+					resultado.Status, models.StatusSuccessful, models.StatusError),
+			})
+		}
+
+		if utf8.RuneCountInString(resultado.Code) > MaxLongitudCodigo {
+			violaciones = append(violaciones, models.Violation{
+				Field:   campo + ".transmissionResult.code",
+				Message: fmt.Sprintf("no puede tener más de %d caracteres", MaxLongitudCodigo),
+			})
+		}
+
+		if utf8.RuneCountInString(resultado.Detail) > MaxLongitudDetalle {
+			violaciones = append(violaciones, models.Violation{
+				Field:   campo + ".transmissionResult.detail",
+				Message: fmt.Sprintf("no puede tener más de %d caracteres", MaxLongitudDetalle),
+			})
+		}
+	}
+
+	return violaciones
+}
+
+// validarCamposDesconocidos recorre el JSON y reporta cada campo que no pertenece al esquema.
+func validarCamposDesconocidos(body []byte) ([]models.Violation, error) {
+	var raiz map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raiz); err != nil {
+		return nil, err
+	}
+
+	violaciones := camposDesconocidos(raiz, camposRaiz, "")
+
+	var archivos []json.RawMessage
+	if err := json.Unmarshal(raiz["transmittedFiles"], &archivos); err != nil {
+		// El tipo inválido se reporta al decodificar la estructura completa
+		return violaciones, nil
+	}
+
+	for i, archivoRaw := range archivos {
+		prefijo := fmt.Sprintf("transmittedFiles[%d].", i)
+
+		var archivo map[string]json.RawMessage
+		if err := json.Unmarshal(archivoRaw, &archivo); err != nil {
+			continue
+		}
+		violaciones = append(violaciones, camposDesconocidos(archivo, camposArchivo, prefijo)...)
+
+		var resultado map[string]json.RawMessage
+		if err := json.Unmarshal(archivo["transmissionResult"], &resultado); err != nil {
+			continue
+		}
+		violaciones = append(violaciones,
+			camposDesconocidos(resultado, camposResultado, prefijo+"transmissionResult.")...)
+	}
+
+	return violaciones, nil
+}
+
+// camposDesconocidos retorna una violación por cada campo del objeto que no está permitido.
+func camposDesconocidos(objeto map[string]json.RawMessage, permitidos map[string]bool, prefijo string) []models.Violation {
+	var desconocidos []string
+	for campo := range objeto {
+		if !permitidos[campo] {
+			desconocidos = append(desconocidos, campo)
+		}
+	}
+	sort.Strings(desconocidos)
+
+	violaciones := make([]models.Violation, 0, len(desconocidos))
+	for _, campo := range desconocidos {
+		violaciones = append(violaciones, models.Violation{
+			Field:   prefijo + campo,
+			Message: "campo no permitido",
+		})
+	}
+	return violaciones
+}
@@ -0,0 +1,108 @@
+package validation_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gmf_transmission_response/internal/models"
+	"gmf_transmission_response/internal/validation"
+)
+
+// campos obtiene los nombres de los campos reportados en las violaciones
+func campos(err error) []string {
+	var validacionErr *validation.ValidacionError
+	if !errors.As(err, &validacionErr) {
+		return nil
+	}
+	resultado := make([]string, 0, len(validacionErr.Violations))
+	for _, v := range validacionErr.Violations {
+		resultado = append(resultado, v.Field)
+	}
+	return resultado
+}
+
+func TestDecodificarTransmisionResponse_Valida(t *testing.T) {
+	body := `{"transmittedFiles":[{"fileName":"TUTGMF0001000120240312-0001.txt",
+		"transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":"Transmisión exitosa"}}]}`
+
+	transmisionResponse, err := validation.DecodificarTransmisionResponse([]byte(body))
+
+	assert.NoError(t, err)
+	assert.Len(t, transmisionResponse.TransmittedFiles, 1)
+	assert.Equal(t, "TUTGMF0001000120240312-0001.txt", transmisionResponse.TransmittedFiles[0].FileName)
+}
+
+func TestDecodificarTransmisionResponse_JSONInvalido(t *testing.T) {
+	_, err := validation.DecodificarTransmisionResponse([]byte("invalid json"))
+
+	var validacionErr *validation.ValidacionError
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &validacionErr))
+}
+
+func TestDecodificarTransmisionResponse_ListaVacia(t *testing.T) {
+	_, err := validation.DecodificarTransmisionResponse([]byte(`{"transmittedFiles":[]}`))
+
+	assert.Equal(t, []string{"transmittedFiles"}, campos(err))
+}
+
+func TestDecodificarTransmisionResponse_TodasLasViolaciones(t *testing.T) {
+	detalleLargo := strings.Repeat("x", validation.MaxLongitudDetalle+1)
+	body := `{"extra":true,"transmittedFiles":[
+		{"fileName":"","transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":""}},
+		{"fileName":"A.txt","transmissionResult":{"status":"PENDING","code":"00001","detail":"` + detalleLargo + `"}},
+		{"fileName":"A.txt","otro":1,"transmissionResult":{"status":"ERROR","code":"","desconocido":"x"}}
+	]}`
+
+	_, err := validation.DecodificarTransmisionResponse([]byte(body))
+
+	assert.ElementsMatch(t, []string{
+		"extra",
+		"transmittedFiles[2].otro",
+		"transmittedFiles[2].transmissionResult.desconocido",
+		"transmittedFiles[0].fileName",
+		"transmittedFiles[1].transmissionResult.status",
+		"transmittedFiles[1].transmissionResult.code",
+		"transmittedFiles[1].transmissionResult.detail",
+		"transmittedFiles[2].fileName",
+	}, campos(err))
+	assert.Contains(t, err.Error(), "está duplicado con transmittedFiles[1]")
+}
+
+func TestDecodificarTransmisionResponse_CodigoOpcional(t *testing.T) {
+	body := `{"transmittedFiles":[{"fileName":"A.txt","transmissionResult":{"status":"ERROR","detail":"Rechazado"}}]}`
+
+	transmisionResponse, err := validation.DecodificarTransmisionResponse([]byte(body))
+
+	assert.NoError(t, err)
+	assert.Empty(t, transmisionResponse.TransmittedFiles[0].TransmissionResult.Code)
+}
+
+func TestDecodificarTransmisionResponse_TipoInvalido(t *testing.T) {
+	body := `{"transmittedFiles":[{"fileName":123,
+		"transmissionResult":{"status":"SUCCESSFUL","code":"0000","detail":""}}]}`
+
+	_, err := validation.DecodificarTransmisionResponse([]byte(body))
+
+	assert.Contains(t, err.Error(), "fileName: tipo inválido, se esperaba string")
+}
+
+func TestValidarTransmisionResponse_DetalleMultibyte(t *testing.T) {
+	// La longitud del detalle se cuenta en caracteres, igual que varchar en Postgres
+	transmisionResponse := &models.TransmisionResponse{
+		TransmittedFiles: []models.TransmittedFile{
+			{
+				FileName: "TUTGMF0001000120240312-0001.txt",
+				TransmissionResult: models.TransmissionResult{
+					Status: models.StatusError,
+					Code:   "0001",
+					Detail: strings.Repeat("ñ", validation.MaxLongitudDetalle),
+				},
+			},
+		},
+	}
+
+	assert.Empty(t, validation.ValidarTransmisionResponse(transmisionResponse))
+}
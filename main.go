@@ -1,32 +1,52 @@
 package main
 
 import (
+	"context"
+
 	"gmf_transmission_response/config"
 	"gmf_transmission_response/connection"
 	"gmf_transmission_response/internal/handler"
+	"gmf_transmission_response/internal/logs"
 	"gmf_transmission_response/internal/routes"
-	"os"
+	"log"
 )
 
 var (
+	appConfig      *config.Config
 	archivoHandler *handler.ArchivoHandler
+	healthHandler  *handler.HealthHandler
 	dbManager      *connection.DBManager
+	esperarTareas  func(ctx context.Context) error
 )
 
 func init() {
+	// Cargar y validar la configuración de la aplicación
+	var err error
+	appConfig, err = config.Load()
+	if err != nil {
+		log.Fatalf("Error al cargar la configuración: %v", err)
+	}
+
 	// Inicializar la aplicación con todos los componentes
-	archivoHandler, dbManager = config.InitApplication()
+	archivoHandler, healthHandler, dbManager, esperarTareas = config.InitApplication(appConfig)
 }
 
 func main() {
 	// configurar las rutas de la aplicación
-	routes.SetupRoutes(archivoHandler)
+	routes.SetupRoutes(archivoHandler, healthHandler)
+
+	// Crear el servidor HTTP, que al detenerse espera las tareas en segundo plano y cierra la base de datos
+	server, err := connection.NewServer(appConfig.Server, nil, dbManager,
+		connection.WithTareasEnSegundoPlano(esperarTareas))
+	if err != nil {
+		dbManager.CloseDB()
+		log.Fatalf("Error al crear el servidor: %v", err)
+	}
 
-	// Iniciar el servidor HTTP
-	host := os.Getenv("HOST")
-	port := os.Getenv("PORT")
-	connection.StartServer(host, port)
+	// Iniciar el servidor HTTP y esperar la señal de terminación
+	if err := server.Run(); err != nil {
+		log.Fatalf("Error al ejecutar el servidor: %v", err)
+	}
 
-	// Limpiar los recursos de la aplicación
-	defer config.CleanupApplication(dbManager)
+	logs.Logger.LogInfo("Recursos limpiados correctamente 🧹", "APP_CLEANUP")
 }
@@ -0,0 +1,2 @@
+ALTER TABLE cgd_archivo_estados DROP COLUMN IF EXISTS gaw_rta_trans_codigo;
+ALTER TABLE cgd_archivo_estados DROP COLUMN IF EXISTS gaw_rta_trans_estado;
@@ -0,0 +1,4 @@
+-- Agrega a cgd_archivo_estados el resultado reportado por el gateway que originó cada transición.
+-- La tabla es compartida con otros servicios, por lo que solo se agregan las columnas faltantes.
+ALTER TABLE cgd_archivo_estados ADD COLUMN IF NOT EXISTS gaw_rta_trans_estado varchar(50);
+ALTER TABLE cgd_archivo_estados ADD COLUMN IF NOT EXISTS gaw_rta_trans_codigo varchar(4);
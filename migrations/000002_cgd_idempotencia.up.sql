@@ -0,0 +1,11 @@
+-- Crea cgd_idempotencia, donde se guarda la respuesta enviada para cada Idempotency-Key recibido en /transmission.
+CREATE TABLE IF NOT EXISTS cgd_idempotencia (
+    clave            varchar(255) PRIMARY KEY,
+    hash_solicitud   char(64)     NOT NULL,
+    estado           varchar(20)  NOT NULL DEFAULT 'COMPLETED',
+    codigo_respuesta smallint     NOT NULL,
+    cuerpo_respuesta text         NOT NULL,
+    fecha_creacion   timestamp    NOT NULL,
+    fecha_expiracion timestamp    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cgd_idempotencia_fecha_expiracion ON cgd_idempotencia (fecha_expiracion);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cgd_jobs;
@@ -0,0 +1,16 @@
+-- Crea cgd_jobs, donde se guardan las solicitudes de /transmission recibidas en modo asíncrono y su resultado.
+CREATE TABLE IF NOT EXISTS cgd_jobs (
+    id                  varchar(36)   PRIMARY KEY,
+    estado              varchar(20)   NOT NULL,
+    total_archivos      integer       NOT NULL,
+    solicitud           text          NOT NULL,
+    request_id          varchar(100),
+    resultado           text,
+    error               varchar(1000),
+    owner               varchar(100),
+    lease_until         timestamp,
+    fecha_creacion      timestamp     NOT NULL,
+    fecha_actualizacion timestamp     NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cgd_jobs_estado ON cgd_jobs (estado);
+CREATE INDEX IF NOT EXISTS idx_cgd_jobs_fecha_actualizacion ON cgd_jobs (fecha_actualizacion);